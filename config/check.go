@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/btchead/go-reusables/config/yaml"
+)
+
+// ErrInvalidConfig is returned by Report.Err when the checked configuration has issues
+var ErrInvalidConfig = errors.New("invalid config")
+
+// IssueKind classifies the problems found by ValidateFile
+type IssueKind string
+
+const (
+	// IssueSyntax is malformed YAML, a broken profile layout, an anchor limit or a value of the wrong type
+	IssueSyntax IssueKind = "syntax"
+	// IssueUnknownKey is a key that does not match any field, usually a typo
+	IssueUnknownKey IssueKind = "unknown_key"
+	// IssueValidation is a value rejected by a validate tag
+	IssueValidation IssueKind = "validation"
+)
+
+// Issue is a single problem found in a configuration file
+type Issue struct {
+	Kind IssueKind
+	// Path is the dotted YAML path of the field, e.g. server.port or endpoints[1].url, empty for
+	// problems with the whole document
+	Path string
+	// Line is the line in the file, 0 when unknown such as for values set by environment variables
+	Line    int
+	Message string
+}
+
+// String returns the issue as e.g. "server.port (line 3): failed on the 'max=65535' tag"
+func (i Issue) String() string {
+	switch {
+	case i.Path != "" && i.Line > 0:
+		return fmt.Sprintf("%s (line %d): %s", i.Path, i.Line, i.Message)
+	case i.Path != "":
+		return fmt.Sprintf("%s: %s", i.Path, i.Message)
+	default:
+		return i.Message
+	}
+}
+
+// Report lists the issues found by ValidateFile, ordered by line
+type Report struct {
+	File   string
+	Issues []Issue
+}
+
+// Valid reports whether the configuration has no issues
+func (r *Report) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns nil for a valid configuration, or an error wrapping ErrInvalidConfig listing every issue
+func (r *Report) Err() error {
+	if r.Valid() {
+		return nil
+	}
+	errs := make([]error, len(r.Issues))
+	for i, issue := range r.Issues {
+		errs[i] = errors.New(issue.String())
+	}
+	return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, r.File, errors.Join(errs...))
+}
+
+// ValidateFile runs the full load pipeline on a configuration file without keeping the result:
+// defaults, parsing, unknown keys, environment overrides and validation. Every problem found is
+// reported instead of stopping at the first one, for use behind a --check-config flag. The error is
+// only set when the file can't be read
+func ValidateFile[T any](path string, opts ...Option) (*Report, error) {
+	return New[T](opts...).ValidateFile(path)
+}
+
+// ValidateFile checks a configuration file like the ValidateFile function, using the Config's validator
+// and options. A load result passed with WithLoadResult is not filled
+func (c *Config[T]) ValidateFile(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Check on a copy, so the load result of real loads is left untouched
+	check := *c
+	check.options.loadResult = nil
+	trace := newLoadTrace()
+
+	report := &Report{File: path}
+	var target T
+	if err := check.applyDefaults(trace, reflect.ValueOf(&target), ""); err != nil {
+		report.add(Issue{Kind: IssueSyntax, Message: fmt.Sprintf("failed to apply defaults: %v", err)})
+		return report, nil
+	}
+
+	if err := check.parser.Check(data); err != nil {
+		report.addSyntax(err)
+		return report, nil
+	}
+	resolved, merged, err := resolveProfile(data, check.options.profile)
+	if err != nil {
+		report.addSyntax(err)
+		return report, nil
+	}
+
+	// Unknown keys are reported even when values have the wrong type
+	unknown, err := check.parser.UnknownKeys(resolved)
+	if err != nil {
+		report.addSyntax(err)
+		return report, nil
+	}
+	for path, line := range unknown {
+		if merged {
+			line = 0
+		}
+		report.add(Issue{Kind: IssueUnknownKey, Path: path, Line: line, Message: "unknown key"})
+	}
+
+	if err := check.parser.Parse(resolved, &target); err != nil {
+		// Validating a partially decoded document would report spurious errors
+		report.addSyntax(err)
+		return report.sorted(), nil
+	}
+	if err := check.applyEnv(trace, &target); err != nil {
+		report.add(Issue{Kind: IssueSyntax, Message: fmt.Sprintf("failed to apply environment: %v", err)})
+		return report.sorted(), nil
+	}
+
+	if err := check.Validate(&target); err != nil {
+		trace.documents = append(trace.documents, resolved)
+		lines := map[string]int{}
+		if !merged {
+			lines, _ = check.parser.FieldLines(resolved)
+		}
+		report.addValidation(err, reflect.TypeOf(target), check.presentPaths(trace), lines)
+	}
+	return report.sorted(), nil
+}
+
+// add appends an issue to the report
+func (r *Report) add(issue Issue) {
+	r.Issues = append(r.Issues, issue)
+}
+
+// addSyntax adds a parse error, with an issue per value when it reports type mismatches
+func (r *Report) addSyntax(err error) {
+	var coercion *yaml.CoercionError
+	if !errors.As(err, &coercion) {
+		r.add(Issue{Kind: IssueSyntax, Message: err.Error()})
+		return
+	}
+	for _, mismatch := range coercion.Mismatches {
+		msg := fmt.Sprintf("cannot use %s %q as %s", mismatch.YAMLType, mismatch.Value, mismatch.GoType)
+		if mismatch.Coercible {
+			msg += " (convertible with lenient types)"
+		}
+		r.add(Issue{Kind: IssueSyntax, Path: mismatch.Path, Line: mismatch.Line, Message: msg})
+	}
+}
+
+// addValidation adds an issue per failed validate tag of the target type,
+// located with the field lines of the document
+func (r *Report) addValidation(err error, targetType reflect.Type, present map[string]bool, lines map[string]int) {
+	for _, issue := range validationIssues(err, targetType, present, lines) {
+		r.add(issue)
+	}
+}
+
+// sorted orders the issues by line, keeping issues without a line last
+func (r *Report) sorted() *Report {
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		a, b := r.Issues[i], r.Issues[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Path < b.Path
+	})
+	return r
+}
+
+// yamlPath converts a validator struct namespace such as "AppConfig.Endpoints[1].URL" into the dotted
+// YAML path of the field, "endpoints[1].url". Slice indexes keep their brackets and map keys become segments
+func yamlPath(t reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := ""
+	for _, segment := range segments[1:] {
+		name, indexes, _ := strings.Cut(segment, "[")
+
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return strings.Join(segments[1:], ".")
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return strings.Join(segments[1:], ".")
+		}
+		path, _ = fieldPath(path, field)
+		t = field.Type
+
+		for indexes != "" {
+			var index string
+			index, indexes, _ = strings.Cut(indexes, "]")
+			indexes = strings.TrimPrefix(indexes, "[")
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Map {
+				path += "." + index
+			} else {
+				path += "[" + index + "]"
+			}
+			if t.Kind() == reflect.Map || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+				t = t.Elem()
+			}
+		}
+	}
+	return path
+}
+
+// lineOf returns the line of the field or of the closest enclosing value reported as a whole, such as a slice
+func lineOf(lines map[string]int, path string) int {
+	for path != "" {
+		if line, ok := lines[path]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			return 0
+		}
+		path = path[:cut]
+	}
+	return 0
+}
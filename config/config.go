@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -15,69 +16,138 @@ import (
 type Config[T any] struct {
 	validator *validator.Validate
 	parser    *yaml.Parser[T]
+	options   options
 }
 
-// New creates a new Config instance with default validator
-func New[T any]() *Config[T] {
-	return &Config[T]{
-		validator: validator.New(),
-		parser:    yaml.NewParser[T](),
+// New creates a new Config instance with default validator and the built-in validation tags
+func New[T any](opts ...Option) *Config[T] {
+	v := validator.New()
+	if err := RegisterValidations(v); err != nil {
+		panic(err)
 	}
+	return NewWithValidator[T](v, opts...)
 }
 
 // NewWithValidator creates a new Config instance with custom validator
-func NewWithValidator[T any](v *validator.Validate) *Config[T] {
-	return &Config[T]{
+func NewWithValidator[T any](v *validator.Validate, opts ...Option) *Config[T] {
+	c := &Config[T]{
 		validator: v,
 		parser:    yaml.NewParser[T](),
 	}
+
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+
+	if c.options.disallowAnchors {
+		c.parser.WithDisallowAnchors()
+	}
+	if c.options.flexibleKeys {
+		c.parser.WithFlexibleKeys()
+	}
+	if c.options.lenientTypes {
+		c.parser.WithLenientTypes()
+	}
+	if c.options.durationUnit > 0 {
+		c.parser.WithDurationUnit(c.options.durationUnit)
+	}
+	if c.options.maxAliasExpansion != nil {
+		c.parser.WithMaxAliasExpansion(*c.options.maxAliasExpansion)
+	}
+	if c.options.syncOnSave {
+		c.parser.WithSync()
+	}
+	if c.options.saveBackup != "" {
+		c.parser.WithBackup(c.options.saveBackup)
+	}
+
+	return c
 }
 
-// LoadFromFile loads configuration from a YAML file and applies defaults and validation
+// LoadFromFile loads configuration from a YAML file and applies defaults and validation.
+// XML and INI files are loaded too, see FormatOf
 func (c *Config[T]) LoadFromFile(filename string, target *T) error {
+	trace := c.beginLoad()
+
 	// First apply defaults
-	if err := c.ApplyDefaults(target); err != nil {
+	if err := c.applyDefaults(trace, reflect.ValueOf(target), ""); err != nil {
 		return fmt.Errorf("failed to apply defaults: %w", err)
 	}
 
 	// Load from file if it exists
 	if c.parser.FileExists(filename) {
-		if err := c.parser.ParseFile(filename, target); err != nil {
+		if err := c.parseFile(trace, filename, target); err != nil {
 			return fmt.Errorf("failed to load config file: %w", err)
 		}
 	}
 
-	// Validate the final configuration
-	if err := c.Validate(target); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	// Environment variables take precedence over files
+	if err := c.applyEnv(trace, target); err != nil {
+		return fmt.Errorf("failed to apply environment: %w", err)
 	}
 
-	return nil
+	// Validate the final configuration
+	return c.validateLoaded(trace, target)
 }
 
 // LoadFromYAML loads configuration from YAML data and applies defaults and validation
 func (c *Config[T]) LoadFromYAML(data []byte, target *T) error {
+	trace := c.beginLoad()
+
 	// First apply defaults
-	if err := c.ApplyDefaults(target); err != nil {
+	if err := c.applyDefaults(trace, reflect.ValueOf(target), ""); err != nil {
 		return fmt.Errorf("failed to apply defaults: %w", err)
 	}
 
 	// Parse YAML
-	if err := c.parser.Parse(data, target); err != nil {
+	if err := c.parse(trace, data, target, Origin{Source: SourceYAML}); err != nil {
 		return fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
+	// Environment variables take precedence over files
+	if err := c.applyEnv(trace, target); err != nil {
+		return fmt.Errorf("failed to apply environment: %w", err)
+	}
+
 	// Validate the final configuration
-	if err := c.Validate(target); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+	return c.validateLoaded(trace, target)
+}
+
+// LoadFromFiles loads configuration from several YAML files and directories, applies defaults and validation.
+// Directories are expanded to their *.yaml and *.yml fragments in lexical order, and every fragment is
+// merged over the previous ones, so later files override earlier values
+func (c *Config[T]) LoadFromFiles(paths []string, target *T) error {
+	files, err := expandPaths(paths)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	trace := c.beginLoad()
+
+	// First apply defaults
+	if err := c.applyDefaults(trace, reflect.ValueOf(target), ""); err != nil {
+		return fmt.Errorf("failed to apply defaults: %w", err)
+	}
+
+	// Merge each fragment over the result of the previous ones
+	for _, file := range files {
+		if err := c.parseFile(trace, file, target); err != nil {
+			return fmt.Errorf("failed to load config file %s: %w", file, err)
+		}
+	}
+
+	// Environment variables take precedence over files
+	if err := c.applyEnv(trace, target); err != nil {
+		return fmt.Errorf("failed to apply environment: %w", err)
+	}
+
+	// Validate the final configuration
+	return c.validateLoaded(trace, target)
 }
 
 // ApplyDefaults applies default values from struct tags to the target
 func (c *Config[T]) ApplyDefaults(target *T) error {
-	return c.applyDefaults(reflect.ValueOf(target))
+	return c.applyDefaults(nil, reflect.ValueOf(target), "")
 }
 
 // Validate validates the configuration using the validator package
@@ -85,7 +155,8 @@ func (c *Config[T]) Validate(target *T) error {
 	return c.validator.Struct(target)
 }
 
-// SaveToFile saves the configuration to a YAML file
+// SaveToFile saves the configuration to a YAML file, omitting fields tagged `secret:"true"`. The file is
+// written to a temporary file and renamed over the previous one, so a crash can't leave it half written
 func (c *Config[T]) SaveToFile(filename string, source *T) error {
 	return c.parser.WriteFile(filename, source)
 }
@@ -104,8 +175,8 @@ func (c *Config[T]) GenerateTemplateToFile(filename string) error {
 
 // Load is a convenience function that creates a new config, applies defaults,
 // loads from file (if exists), and validates in one call
-func Load[T any](filename string) (*T, error) {
-	cfg := New[T]()
+func Load[T any](filename string, opts ...Option) (*T, error) {
+	cfg := New[T](opts...)
 	var target T
 
 	if err := cfg.LoadFromFile(filename, &target); err != nil {
@@ -117,8 +188,8 @@ func Load[T any](filename string) (*T, error) {
 
 // LoadWithDefaults is a convenience function that applies defaults to the provided target
 // then loads from file (if exists) and validates
-func LoadWithDefaults[T any](filename string, target *T) error {
-	cfg := New[T]()
+func LoadWithDefaults[T any](filename string, target *T, opts ...Option) error {
+	cfg := New[T](opts...)
 	return cfg.LoadFromFile(filename, target)
 }
 
@@ -132,8 +203,51 @@ func GenerateTemplateToFile[T any](filename string) error {
 	return yaml.GenerateTemplateToFile[T](filename)
 }
 
-// applyDefaults recursively applies default values
-func (c *Config[T]) applyDefaults(v reflect.Value) error {
+// RegisterKind registers T as the type decoded for interface-typed fields whose section has kind: name,
+// e.g. RegisterKind[S3Sink]("s3") for a []Sink field. Unknown kinds fail with yaml.ErrUnknownKind
+func RegisterKind[T any](name string) {
+	yaml.RegisterKind[T](name)
+}
+
+// parseFile reads a configuration file, converted to YAML unless it is a YAML file, and parses it into the target
+func (c *Config[T]) parseFile(trace *loadTrace, filename string, target *T) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read YAML file: %w", err)
+	}
+
+	format := c.options.format
+	if format == "" {
+		format = FormatOf(filename)
+	}
+	if data, err = toYAML(format, data, reflect.TypeFor[T]()); err != nil {
+		return err
+	}
+	return c.parse(trace, data, target, Origin{Source: SourceFile, Location: filename})
+}
+
+// parse checks the alias limits, resolves the selected profile and parses the YAML data into the target,
+// recording the fields it sets with the origin. The limits are checked first since resolving profiles expands aliases
+func (c *Config[T]) parse(trace *loadTrace, data []byte, target *T, origin Origin) error {
+	if err := c.parser.Check(data); err != nil {
+		return err
+	}
+
+	resolved, merged, err := resolveProfile(data, c.options.profile)
+	if err != nil {
+		return err
+	}
+	if err := c.parser.Parse(resolved, target); err != nil {
+		return err
+	}
+	if trace != nil {
+		trace.documents = append(trace.documents, resolved)
+	}
+	return c.recordDocument(trace, resolved, origin, !merged)
+}
+
+// applyDefaults recursively applies default values, path is the dotted YAML path of v
+func (c *Config[T]) applyDefaults(trace *loadTrace, v reflect.Value, path string) error {
 	if v.Kind() == reflect.Ptr {
 		if v.IsNil() {
 			return nil
@@ -145,30 +259,27 @@ func (c *Config[T]) applyDefaults(v reflect.Value) error {
 		return nil
 	}
 
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := t.Field(i)
-
-		// Skip unexported fields
-		if !field.CanSet() {
-			continue
-		}
+	fields := fieldsOf(v.Type())
+	for i := range fields {
+		info := &fields[i]
+		field := v.Field(info.index)
+		fieldPath := info.path(path)
 
 		// Handle nested structs
-		if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
-			if err := c.applyDefaults(field); err != nil {
+		if info.nested {
+			if err := c.applyDefaults(trace, field, fieldPath); err != nil {
 				return err
 			}
 			continue
 		}
 
 		// Apply default if field is zero value and default tag exists
-		defaultValue := fieldType.Tag.Get("default")
-		if defaultValue != "" && c.isZeroValue(field) {
-			if err := c.setFieldValue(field, defaultValue); err != nil {
-				return fmt.Errorf("failed to set default for field %s: %w", fieldType.Name, err)
-			}
+		if info.defaultErr != nil && c.isZeroValue(field) {
+			return fmt.Errorf("failed to set default for field %s: %w", info.field.Name, info.defaultErr)
+		}
+		if info.defaultValue.IsValid() && c.isZeroValue(field) {
+			field.Set(info.defaultFor())
+			c.recordDefault(trace, fieldPath)
 		}
 	}
 
@@ -198,7 +309,7 @@ func (c *Config[T]) isZeroValue(v reflect.Value) bool {
 }
 
 // setFieldValue sets a field value from a string representation
-func (c *Config[T]) setFieldValue(field reflect.Value, value string) error {
+func setFieldValue(field reflect.Value, value string) error {
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -1,10 +1,20 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"net"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
+
+	"github.com/btchead/go-reusables/config/yaml"
+	"github.com/go-playground/validator/v10"
 )
 
 type TestAppConfig struct {
@@ -73,3 +83,861 @@ func TestConfig_GenerateTemplateToFile(t *testing.T) {
 		t.Error("Template file should have been created")
 	}
 }
+
+func TestConfig_LoadFromFiles_MergesFragmentsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/10-server.yaml", []byte("server:\n  host: first\n  port: 9000\n"), 0644); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+	if err := os.WriteFile(dir+"/20-override.yaml", []byte("server:\n  host: second\n"), 0644); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	cfg := New[TestAppConfig]()
+
+	var appConfig TestAppConfig
+	if err := cfg.LoadFromFiles([]string{dir}, &appConfig); err != nil {
+		t.Fatalf("LoadFromFiles failed: %v", err)
+	}
+
+	if appConfig.Server.Host != "second" {
+		t.Errorf("Expected host from last fragment 'second', got '%s'", appConfig.Server.Host)
+	}
+
+	if appConfig.Server.Port != 9000 {
+		t.Errorf("Expected port from first fragment 9000, got %d", appConfig.Server.Port)
+	}
+}
+
+func TestWatcher_ReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	fragment := dir + "/server.yaml"
+	if err := os.WriteFile(fragment, []byte("server:\n  port: 9000\n"), 0644); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	watcher, err := NewWatcher[TestAppConfig](nil, []string{dir},
+		WithPollInterval(10*time.Millisecond),
+		WithDebounce(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+
+	if watcher.Current().Server.Port != 9000 {
+		t.Fatalf("Expected initial port 9000, got %d", watcher.Current().Server.Port)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go watcher.Run(ctx)
+
+	if err := os.WriteFile(dir+"/zz-port.yaml", []byte("server:\n  port: 9100\n"), 0644); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	select {
+	case snapshot := <-watcher.Changes():
+		if snapshot.Server.Port != 9100 {
+			t.Errorf("Expected reloaded port 9100, got %d", snapshot.Server.Port)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for reload")
+	}
+
+	if watcher.Current().Server.Port != 9100 {
+		t.Errorf("Expected current port 9100, got %d", watcher.Current().Server.Port)
+	}
+}
+
+func TestWatcher_MissingPath(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewWatcher[TestAppConfig](nil, []string{dir, filepath.Join(dir, "conf.d")})
+	if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), "conf.d") {
+		t.Errorf("Expected the missing path to fail the watcher, got %v", err)
+	}
+}
+
+func TestConfig_LoadFromYAML_WithProfile(t *testing.T) {
+	data := []byte(`
+debug: true
+profiles:
+  default:
+    server:
+      host: localhost
+      port: 8000
+  production:
+    server:
+      port: 443
+`)
+
+	t.Run("selected profile merged over default", func(t *testing.T) {
+		var appConfig TestAppConfig
+		if err := New[TestAppConfig](WithProfile("production")).LoadFromYAML(data, &appConfig); err != nil {
+			t.Fatalf("LoadFromYAML failed: %v", err)
+		}
+
+		if appConfig.Server.Host != "localhost" {
+			t.Errorf("Expected host from default profile 'localhost', got '%s'", appConfig.Server.Host)
+		}
+		if appConfig.Server.Port != 443 {
+			t.Errorf("Expected port from production profile 443, got %d", appConfig.Server.Port)
+		}
+		if !appConfig.Debug {
+			t.Error("Expected top-level debug value to be kept")
+		}
+	})
+
+	t.Run("default profile without selection", func(t *testing.T) {
+		var appConfig TestAppConfig
+		if err := New[TestAppConfig]().LoadFromYAML(data, &appConfig); err != nil {
+			t.Fatalf("LoadFromYAML failed: %v", err)
+		}
+
+		if appConfig.Server.Port != 8000 {
+			t.Errorf("Expected port from default profile 8000, got %d", appConfig.Server.Port)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		var appConfig TestAppConfig
+		if err := New[TestAppConfig](WithProfile("staging")).LoadFromYAML(data, &appConfig); err == nil {
+			t.Error("Expected error for unknown profile")
+		}
+	})
+}
+
+func TestConfig_LoadFromYAML_WithDisallowAnchors(t *testing.T) {
+	data := []byte(`
+server: &server
+  host: localhost
+  port: 8000
+`)
+
+	var appConfig TestAppConfig
+	if err := New[TestAppConfig]().LoadFromYAML(data, &appConfig); err != nil {
+		t.Fatalf("LoadFromYAML failed: %v", err)
+	}
+
+	if err := New[TestAppConfig](WithDisallowAnchors()).LoadFromYAML(data, &appConfig); !errors.Is(err, yaml.ErrAnchorsDisallowed) {
+		t.Errorf("Expected ErrAnchorsDisallowed, got %v", err)
+	}
+}
+
+func TestLoadWithEmbedded(t *testing.T) {
+	embedded := fstest.MapFS{
+		"defaults.yaml": {Data: []byte("server:\n  host: embedded\n  port: 8000\ndebug: true\n")},
+	}
+
+	override := filepath.Join(t.TempDir(), "override.yaml")
+	if err := os.WriteFile(override, []byte("server:\n  port: 9000\n"), 0644); err != nil {
+		t.Fatalf("Failed to write override: %v", err)
+	}
+	t.Setenv("APP_SERVER_HOST", "from-env")
+
+	appConfig, err := LoadWithEmbedded[TestAppConfig](embedded, "defaults.yaml", override, WithEnvPrefix("APP"))
+	if err != nil {
+		t.Fatalf("LoadWithEmbedded failed: %v", err)
+	}
+
+	if appConfig.Server.Host != "from-env" {
+		t.Errorf("Expected host from env 'from-env', got '%s'", appConfig.Server.Host)
+	}
+	if appConfig.Server.Port != 9000 {
+		t.Errorf("Expected port from override 9000, got %d", appConfig.Server.Port)
+	}
+	if !appConfig.Debug {
+		t.Error("Expected debug from embedded defaults")
+	}
+
+	if _, err := LoadWithEmbedded[TestAppConfig](embedded, "missing.yaml", ""); err == nil {
+		t.Error("Expected error for missing embedded file")
+	}
+}
+
+func TestConfig_LoadResultProvenance(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("debug: true\nserver:\n  port: 9000\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("APP_SERVER_HOST", "from-env")
+
+	var result LoadResult
+	var appConfig TestAppConfig
+	cfg := New[TestAppConfig](WithEnvPrefix("APP"), WithLoadResult(&result))
+	if err := cfg.LoadFromFile(file, &appConfig); err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+
+	expected := map[string]Origin{
+		"debug":                 {Source: SourceFile, Location: file, Line: 1},
+		"server.port":           {Source: SourceFile, Location: file, Line: 3},
+		"server.host":           {Source: SourceEnv, Location: "APP_SERVER_HOST"},
+		"nested_config.timeout": {Source: SourceDefault},
+		"features":              {Source: SourceDefault},
+	}
+	for path, want := range expected {
+		if got, ok := result.Origin(path); !ok || got != want {
+			t.Errorf("Expected origin of %s to be %v, got %v", path, want, got)
+		}
+	}
+
+	if got := result.Provenance()["server.port"].String(); got != "file "+file+":3" {
+		t.Errorf("Unexpected origin string %q", got)
+	}
+	wantDefaults := []string{"debug", "features", "nested_config.timeout", "server.host", "server.port"}
+	if got := result.Defaults(); !reflect.DeepEqual(got, wantDefaults) {
+		t.Errorf("Expected defaults %v, got %v", wantDefaults, got)
+	}
+	if got, want := result.Implicit(), []string{"features", "nested_config.timeout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected implicit settings %v, got %v", want, got)
+	}
+}
+
+func TestConfig_EnvCollectionOverrides(t *testing.T) {
+	type Endpoint struct {
+		URL    string `yaml:"url"`
+		Weight int    `yaml:"weight"`
+	}
+	type Tenant struct {
+		Limit int `yaml:"limit"`
+	}
+	type CollectionConfig struct {
+		Endpoints []Endpoint          `yaml:"endpoints"`
+		Tenants   map[string]Tenant   `yaml:"tenants"`
+		Labels    map[string]string   `yaml:"labels"`
+		Ports     []int               `yaml:"ports"`
+		Groups    map[string][]string `yaml:"groups"`
+	}
+
+	data := []byte(`
+endpoints:
+  - url: http://a
+    weight: 1
+  - url: http://b
+    weight: 2
+tenants:
+  acme:
+    limit: 10
+  globex:
+    limit: 20
+labels:
+  team: core
+ports: [80]
+groups:
+  admins: [alice]
+`)
+	t.Setenv("APP_ENDPOINTS_1_URL", "http://b2")
+	t.Setenv("APP_ENDPOINTS_2_URL", "http://c")
+	t.Setenv("APP_TENANTS_ACME_LIMIT", "50")
+	t.Setenv("APP_LABELS_TEAM", "edge")
+	t.Setenv("APP_LABELS_REGION", "eu")
+	t.Setenv("APP_PORTS_0", "8080")
+	t.Setenv("APP_GROUPS_ADMINS_1", "bob")
+
+	var result LoadResult
+	var cfg CollectionConfig
+	if err := New[CollectionConfig](WithEnvPrefix("APP"), WithLoadResult(&result)).LoadFromYAML(data, &cfg); err != nil {
+		t.Fatalf("LoadFromYAML failed: %v", err)
+	}
+
+	expected := CollectionConfig{
+		Endpoints: []Endpoint{{URL: "http://a", Weight: 1}, {URL: "http://b2", Weight: 2}, {URL: "http://c"}},
+		Tenants:   map[string]Tenant{"acme": {Limit: 50}, "globex": {Limit: 20}},
+		Labels:    map[string]string{"team": "edge", "region": "eu"},
+		Ports:     []int{8080},
+		Groups:    map[string][]string{"admins": {"alice", "bob"}},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, cfg)
+	}
+
+	if got, _ := result.Origin("tenants.acme.limit"); got != (Origin{Source: SourceEnv, Location: "APP_TENANTS_ACME_LIMIT"}) {
+		t.Errorf("Unexpected origin of tenants.acme.limit: %v", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	type ServerV1 struct {
+		Host    string        `yaml:"host" default:"localhost"`
+		Port    int           `yaml:"port" validate:"min=1"`
+		Timeout time.Duration `yaml:"timeout"`
+	}
+	type AppV1 struct {
+		Server ServerV1 `yaml:"server"`
+		Debug  bool     `yaml:"debug"`
+	}
+
+	// Compatible: new optional field and changed default
+	type ServerV2 struct {
+		Host    string        `yaml:"host" default:"0.0.0.0"`
+		Port    int           `yaml:"port" validate:"min=1"`
+		Timeout time.Duration `yaml:"timeout"`
+		TLS     bool          `yaml:"tls"`
+	}
+	type AppV2 struct {
+		Server ServerV2 `yaml:"server"`
+		Debug  bool     `yaml:"debug"`
+	}
+
+	// Incompatible: removed, retyped, stricter and new required fields
+	type ServerV3 struct {
+		Host    string `yaml:"host" default:"localhost"`
+		Port    int    `yaml:"port" validate:"min=1024"`
+		Timeout int    `yaml:"timeout"`
+		Token   string `yaml:"token" validate:"required"`
+	}
+	type AppV3 struct {
+		Server ServerV3 `yaml:"server"`
+	}
+
+	old := Fingerprint[AppV1]()
+	if old != Fingerprint[AppV1]() {
+		t.Fatal("Expected a stable fingerprint")
+	}
+	if old == Fingerprint[AppV2]() {
+		t.Error("Expected fingerprint to change with the schema")
+	}
+	if err := VerifyCompatibility[AppV1](old); err != nil {
+		t.Errorf("Expected identical schema to be compatible, got %v", err)
+	}
+	if err := VerifyCompatibility[AppV2](old); err != nil {
+		t.Errorf("Expected compatible schema, got %v", err)
+	}
+
+	err := VerifyCompatibility[AppV3](old)
+	if !errors.Is(err, ErrIncompatibleSchema) {
+		t.Fatalf("Expected ErrIncompatibleSchema, got %v", err)
+	}
+	for _, want := range []string{
+		"'debug' was removed",
+		"'server.timeout' changed type",
+		"'server.port' changed validation rules",
+		"'server.token' was added as required without a default",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+
+	if err := VerifyCompatibility[AppV1]("garbage"); err == nil || errors.Is(err, ErrIncompatibleSchema) {
+		t.Errorf("Expected invalid fingerprint error, got %v", err)
+	}
+}
+
+func TestConfig_BuiltinValidations(t *testing.T) {
+	type InfraConfig struct {
+		Listen   string        `validate:"hostport"`
+		Interval time.Duration `validate:"duration_min=1s"`
+		Allowed  []string      `validate:"cidr_list"`
+		Endpoint string        `validate:"url_scheme=https"`
+		CertFile string        `validate:"file_exists"`
+		DataDir  string        `validate:"dir_writable"`
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(certFile, []byte("cert"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	valid := InfraConfig{
+		Listen:   ":8080",
+		Interval: 5 * time.Second,
+		Allowed:  []string{"10.0.0.0/8", "192.168.1.0/24"},
+		Endpoint: "https://api.example.com",
+		CertFile: certFile,
+		DataDir:  dir,
+	}
+	cfg := New[InfraConfig]()
+	if err := cfg.Validate(&valid); err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+
+	invalid := InfraConfig{
+		Listen:   "localhost:99999",
+		Interval: 500 * time.Millisecond,
+		Allowed:  []string{"10.0.0.0/33"},
+		Endpoint: "http://api.example.com",
+		CertFile: dir,
+		DataDir:  certFile,
+	}
+	err := cfg.Validate(&invalid)
+	for _, tag := range []string{"hostport", "duration_min", "cidr_list", "url_scheme", "file_exists", "dir_writable"} {
+		if err == nil || !strings.Contains(err.Error(), "'"+tag+"' tag") {
+			t.Errorf("Expected %s to fail, got %v", tag, err)
+		}
+	}
+}
+
+func TestConfig_CollectionValidations(t *testing.T) {
+	type CollectionConfig struct {
+		Upstreams []string          `validate:"min_items=1,max_items=3,unique_items"`
+		Labels    map[string]string `validate:"max_items=2,keys_match=^[a-z0-9_]+$"`
+		Groups    [][]int           `validate:"dive,max_items=2,unique_items"`
+		Routes    map[string][]int  `validate:"unique_items"`
+	}
+
+	cfg := New[CollectionConfig]()
+	valid := CollectionConfig{
+		Upstreams: []string{"a", "b"},
+		Labels:    map[string]string{"team": "core", "tier_1": "x"},
+		Groups:    [][]int{{1, 2}, {3}},
+		Routes:    map[string][]int{"a": {1}, "b": {2}},
+	}
+	if err := cfg.Validate(&valid); err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *CollectionConfig)
+		tag    string
+	}{
+		{"too few items", func(c *CollectionConfig) { c.Upstreams = nil }, "min_items"},
+		{"too many items", func(c *CollectionConfig) { c.Upstreams = []string{"a", "b", "c", "d"} }, "max_items"},
+		{"duplicate items", func(c *CollectionConfig) { c.Upstreams = []string{"a", "a"} }, "unique_items"},
+		{"too many keys", func(c *CollectionConfig) { c.Labels = map[string]string{"a": "", "b": "", "c": ""} }, "max_items"},
+		{"key mismatch", func(c *CollectionConfig) { c.Labels = map[string]string{"Team": "core"} }, "keys_match"},
+		{"nested too many items", func(c *CollectionConfig) { c.Groups = [][]int{{1, 2, 3}} }, "max_items"},
+		{"nested duplicate items", func(c *CollectionConfig) { c.Groups = [][]int{{1, 1}} }, "unique_items"},
+		{"duplicate map values", func(c *CollectionConfig) { c.Routes = map[string][]int{"a": {1}, "b": {1}} }, "unique_items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			err := cfg.Validate(&c)
+			if err == nil || !strings.Contains(err.Error(), "'"+tt.tag+"' tag") {
+				t.Errorf("Expected %s to fail, got %v", tt.tag, err)
+			}
+		})
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	type Endpoint struct {
+		URL string `yaml:"url" validate:"required,url"`
+	}
+	type CheckConfig struct {
+		Server    TestServerConfig `yaml:"server"`
+		Endpoints []Endpoint       `yaml:"endpoints" validate:"dive"`
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		return path
+	}
+
+	valid := write("valid.yaml", "server:\n  port: 9090\nendpoints:\n  - url: https://example.com\n")
+	report, err := ValidateFile[CheckConfig](valid)
+	if err != nil || !report.Valid() || report.Err() != nil {
+		t.Fatalf("Expected a valid report, got %v, %v", report, err)
+	}
+
+	invalid := write("invalid.yaml", "server:\n  prot: 9090\n  port: 70000\nendpoints:\n  - url: not-a-url\n")
+	report, err = ValidateFile[CheckConfig](invalid)
+	if err != nil {
+		t.Fatalf("Expected a report, got %v", err)
+	}
+	want := []Issue{
+		{Kind: IssueUnknownKey, Path: "server.prot", Line: 2, Message: "unknown key"},
+		{Kind: IssueValidation, Path: "server.port", Line: 3, Message: "failed on the 'max=65535' tag"},
+		{Kind: IssueValidation, Path: "endpoints[0].url", Line: 5, Message: "failed on the 'url' tag"},
+	}
+	if !reflect.DeepEqual(report.Issues, want) {
+		t.Errorf("Expected issues %v, got %v", want, report.Issues)
+	}
+	if err := report.Err(); !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), "server.prot (line 2): unknown key") {
+		t.Errorf("Expected ErrInvalidConfig listing the issues, got %v", err)
+	}
+
+	mismatch := write("mismatch.yaml", "server:\n  port: eighty\n  hots: localhost\n")
+	report, _ = ValidateFile[CheckConfig](mismatch)
+	if len(report.Issues) != 2 || report.Issues[0].Kind != IssueSyntax || report.Issues[0].Path != "server.port" ||
+		report.Issues[1].Kind != IssueUnknownKey {
+		t.Errorf("Expected a type mismatch and an unknown key, got %v", report.Issues)
+	}
+
+	if _, err := ValidateFile[CheckConfig](filepath.Join(dir, "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a read error for a missing file, got %v", err)
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	type Endpoint struct {
+		URL string `yaml:"url"`
+	}
+	type PathConfig struct {
+		Server    TestServerConfig            `yaml:"server"`
+		Debug     bool                        `yaml:"debug"`
+		Timeout   *TestNestedConfig           `yaml:"timeout"`
+		Endpoints []Endpoint                  `yaml:"endpoints"`
+		Tenants   map[string]TestNestedConfig `yaml:"tenants"`
+	}
+
+	cfg := PathConfig{Server: TestServerConfig{Port: 8080}, Endpoints: []Endpoint{{URL: "a"}, {URL: "b"}}}
+
+	if port, err := Get(&cfg, "server.port"); err != nil || port != 8080 {
+		t.Errorf("Expected port 8080, got %v, %v", port, err)
+	}
+	for _, path := range []string{"endpoints[1].url", "endpoints.1.url"} {
+		if url, err := Get(&cfg, path); err != nil || url != "b" {
+			t.Errorf("Expected url b at %s, got %v, %v", path, url, err)
+		}
+	}
+	if timeout, err := Get(&cfg, "timeout.timeout"); err != nil || timeout != nil {
+		t.Errorf("Expected nil through nil pointer, got %v, %v", timeout, err)
+	}
+	for _, path := range []string{"server.missing", "endpoints[2].url", "tenants.acme"} {
+		if _, err := Get(&cfg, path); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("Expected ErrPathNotFound for %s, got %v", path, err)
+		}
+	}
+
+	if err := Set(&cfg, "server.port", 9090); err != nil || cfg.Server.Port != 9090 {
+		t.Errorf("Expected port 9090, got %d, %v", cfg.Server.Port, err)
+	}
+	if err := Set(&cfg, "debug", "true"); err != nil || !cfg.Debug {
+		t.Errorf("Expected debug parsed from string, got %v, %v", cfg.Debug, err)
+	}
+	if err := Set(&cfg, "timeout.timeout", "5s"); err != nil || cfg.Timeout == nil || cfg.Timeout.Timeout != 5*time.Second {
+		t.Errorf("Expected allocated timeout of 5s, got %+v, %v", cfg.Timeout, err)
+	}
+	if err := Set(&cfg, "tenants.acme.timeout", time.Minute); err != nil || cfg.Tenants["acme"].Timeout != time.Minute {
+		t.Errorf("Expected map entry set, got %+v, %v", cfg.Tenants, err)
+	}
+	if err := Set(&cfg, "endpoints[0].url", "c"); err != nil || cfg.Endpoints[0].URL != "c" {
+		t.Errorf("Expected slice element set, got %+v, %v", cfg.Endpoints, err)
+	}
+	if err := Set(&cfg, "server.port", 1.5); err == nil {
+		t.Error("Expected error converting a fraction to int")
+	}
+	if err := Set(&cfg, "server.host", 42); err == nil {
+		t.Error("Expected error setting an int on a string field")
+	}
+}
+
+type testSource interface {
+	URL() string
+}
+
+type testHTTPSource struct {
+	Endpoint string        `yaml:"endpoint" validate:"required"`
+	Timeout  time.Duration `yaml:"timeout" default:"5s"`
+}
+
+func (s *testHTTPSource) URL() string { return s.Endpoint }
+
+func TestRegisterKind(t *testing.T) {
+	RegisterKind[testHTTPSource]("http")
+
+	type SourcesConfig struct {
+		Sources []testSource `yaml:"sources" validate:"dive"`
+	}
+
+	var cfg SourcesConfig
+	err := New[SourcesConfig]().LoadFromYAML([]byte("sources:\n  - kind: http\n    endpoint: https://example.com\n"), &cfg)
+	if err != nil {
+		t.Fatalf("LoadFromYAML failed: %v", err)
+	}
+	if len(cfg.Sources) != 1 || cfg.Sources[0].URL() != "https://example.com" {
+		t.Errorf("Expected http source, got %#v", cfg.Sources)
+	}
+
+	err = New[SourcesConfig]().LoadFromYAML([]byte("sources:\n  - kind: http\n"), &SourcesConfig{})
+	if err == nil || !strings.Contains(err.Error(), "Endpoint") {
+		t.Errorf("Expected validation of the concrete type, got %v", err)
+	}
+	err = New[SourcesConfig]().LoadFromYAML([]byte("sources:\n  - kind: grpc\n"), &SourcesConfig{})
+	if !errors.Is(err, yaml.ErrUnknownKind) {
+		t.Errorf("Expected ErrUnknownKind, got %v", err)
+	}
+}
+
+func TestCachedMetadata(t *testing.T) {
+	cfg := New[TestAppConfig]()
+
+	var first, second TestAppConfig
+	if err := cfg.ApplyDefaults(&first); err != nil {
+		t.Fatalf("ApplyDefaults failed: %v", err)
+	}
+	first.Features[0] = "changed"
+	if err := cfg.ApplyDefaults(&second); err != nil {
+		t.Fatalf("ApplyDefaults failed: %v", err)
+	}
+	if !reflect.DeepEqual(second.Features, []string{"feature1", "feature2"}) {
+		t.Errorf("Expected cached slice default to be copied, got %v", second.Features)
+	}
+
+	template, err := GenerateTemplate[TestAppConfig]()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+	expected := string(template)
+	template[0] = '!'
+	again, err := GenerateTemplate[TestAppConfig]()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+	if string(again) != expected {
+		t.Error("Expected cached template to be copied")
+	}
+}
+
+type ListenTestConfig struct {
+	HTTP struct {
+		Addr string `yaml:"addr" addr:"listen"`
+	} `yaml:"http"`
+	Metrics struct {
+		Port int `yaml:"port" addr:"listen"`
+	} `yaml:"metrics"`
+	Admin []string `yaml:"admin" addr:"listen"`
+}
+
+func TestCheckListenAddrs(t *testing.T) {
+	cfg := &ListenTestConfig{}
+	cfg.HTTP.Addr = "127.0.0.1:8080"
+	cfg.Metrics.Port = 9090
+	cfg.Admin = []string{"127.0.0.2:8080", ":0"}
+	if err := CheckListenAddrs(cfg); err != nil {
+		t.Fatalf("Expected distinct addresses to pass, got %v", err)
+	}
+
+	cfg.Admin = []string{":8080", "localhost", "[::1]:70000"}
+	err := CheckListenAddrs(cfg)
+	if !errors.Is(err, ErrListenAddr) {
+		t.Fatalf("Expected ErrListenAddr, got %v", err)
+	}
+	for _, want := range []string{
+		"http.addr: 127.0.0.1:8080 conflicts with admin[0] at :8080",
+		"admin[1]: malformed address 'localhost'",
+		"admin[2]: port of '[::1]:70000' must be a number",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	cfg = &ListenTestConfig{}
+	cfg.HTTP.Addr = listener.Addr().String()
+	if err := CheckListenAddrs(cfg); err != nil {
+		t.Errorf("Expected no error without probing, got %v", err)
+	}
+	if err := CheckListenAddrs(cfg, WithBindProbe()); err == nil || !strings.Contains(err.Error(), "http.addr: cannot bind") {
+		t.Errorf("Expected the probe to report the address in use, got %v", err)
+	}
+}
+
+func TestValidationUnsetFields(t *testing.T) {
+	type UnsetConfig struct {
+		Server struct {
+			Host string `yaml:"host" validate:"required"`
+			Port int    `yaml:"port" validate:"min=1"`
+		} `yaml:"server"`
+		Workers int `yaml:"workers" validate:"min=1"`
+	}
+
+	var result LoadResult
+	var unsetConfig UnsetConfig
+	err := New[UnsetConfig](WithLoadResult(&result)).LoadFromYAML([]byte("server:\n  port: 0\n"), &unsetConfig)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) || len(fieldErrs) != 3 {
+		t.Errorf("Expected the error to unwrap to the validator's errors, got %v", err)
+	}
+
+	want := []Issue{
+		{Kind: IssueValidation, Path: "server.host", Message: "not set, required by the 'required' tag"},
+		{Kind: IssueValidation, Path: "server.port", Message: "set to 0, failed on the 'min=1' tag"},
+		{Kind: IssueValidation, Path: "workers", Message: "not set, required by the 'min=1' tag"},
+	}
+	if !reflect.DeepEqual(validationErr.Issues, want) {
+		t.Errorf("Expected issues %v, got %v", want, validationErr.Issues)
+	}
+	if got, want := result.Unset(), []string{"server.host", "workers"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected unset fields %v, got %v", want, got)
+	}
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("workers: 0\nserver:\n  host: localhost\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	report, err := ValidateFile[UnsetConfig](file)
+	if err != nil {
+		t.Fatalf("Expected a report, got %v", err)
+	}
+	want = []Issue{
+		{Kind: IssueValidation, Path: "workers", Line: 1, Message: "set to 0, failed on the 'min=1' tag"},
+		{Kind: IssueValidation, Path: "server.port", Message: "not set, required by the 'min=1' tag"},
+	}
+	if !reflect.DeepEqual(report.Issues, want) {
+		t.Errorf("Expected issues %v, got %v", want, report.Issues)
+	}
+}
+
+func TestValidationUnsetFields_ConcurrentLoads(t *testing.T) {
+	type PortConfig struct {
+		Port int `yaml:"port" validate:"min=1"`
+	}
+
+	// Loads sharing a Config keep their own trace, so a field one load set isn't present in another
+	cfg := New[PortConfig]()
+	var wg sync.WaitGroup
+	for i := range 50 {
+		data, want := []byte("port: 0\n"), "set to 0"
+		if i%2 == 1 {
+			data, want = []byte("{}\n"), "not set"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var portConfig PortConfig
+			err := cfg.LoadFromYAML(data, &portConfig)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected the port to be %s, got %v", want, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConfig_LegacyFormats(t *testing.T) {
+	type Listener struct {
+		Host string `yaml:"host" xml:"host,attr"`
+		Port int    `yaml:"port" validate:"min=1"`
+		Name string `yaml:"name" xml:",chardata"`
+	}
+	type Pool struct {
+		Size int `yaml:"size" default:"4"`
+	}
+	type LegacyConfig struct {
+		Server    TestServerConfig `yaml:"server" ini:"http"`
+		Hosts     []string         `yaml:"hosts" xml:"hosts>host"`
+		Listeners []Listener       `yaml:"listeners" xml:"listener"`
+		Timeout   time.Duration    `yaml:"timeout" default:"5s"`
+		Debug     bool             `yaml:"debug"`
+		Database  struct {
+			Pool Pool `yaml:"pool"`
+		} `yaml:"database"`
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	xmlFile := write("app.xml", `<?xml version="1.0"?>
+<config debug="true">
+  <server><host>example.com</host><port>9090</port></server>
+  <hosts><host>a</host><host>b</host></hosts>
+  <listener host="0.0.0.0" port="80">public</listener>
+  <listener host="127.0.0.1" port="8081">admin</listener>
+  <timeout>30s</timeout>
+</config>`)
+	var fromXML LegacyConfig
+	if err := New[LegacyConfig]().LoadFromFile(xmlFile, &fromXML); err != nil {
+		t.Fatalf("LoadFromFile(xml) failed: %v", err)
+	}
+	wantListeners := []Listener{{"0.0.0.0", 80, "public"}, {"127.0.0.1", 8081, "admin"}}
+	if fromXML.Server.Host != "example.com" || fromXML.Server.Port != 9090 || !fromXML.Debug ||
+		!reflect.DeepEqual(fromXML.Hosts, []string{"a", "b"}) || !reflect.DeepEqual(fromXML.Listeners, wantListeners) ||
+		fromXML.Timeout != 30*time.Second || fromXML.Database.Pool.Size != 4 {
+		t.Errorf("Unexpected XML configuration: %+v", fromXML)
+	}
+
+	iniFile := write("app.conf", `; legacy settings
+debug = true
+timeout = 1m
+hosts = a, b
+
+[http]
+host = "example.com"
+port: 9090
+
+[database.pool]
+size = 8
+`)
+	var fromINI LegacyConfig
+	if err := New[LegacyConfig](WithFormat(FormatINI)).LoadFromFile(iniFile, &fromINI); err != nil {
+		t.Fatalf("LoadFromFile(ini) failed: %v", err)
+	}
+	if fromINI.Server.Host != "example.com" || fromINI.Server.Port != 9090 || !fromINI.Debug ||
+		!reflect.DeepEqual(fromINI.Hosts, []string{"a", "b"}) || fromINI.Timeout != time.Minute || fromINI.Database.Pool.Size != 8 {
+		t.Errorf("Unexpected INI configuration: %+v", fromINI)
+	}
+
+	var invalid LegacyConfig
+	err := New[LegacyConfig]().LoadFromFile(write("invalid.ini", "[http]\nport = 0\n"), &invalid)
+	if err == nil || !strings.Contains(err.Error(), "server.port") {
+		t.Errorf("Expected validation to apply to INI files, got %v", err)
+	}
+	if err := New[LegacyConfig]().LoadFromFile(write("broken.ini", "[http\n"), &invalid); err == nil {
+		t.Error("Expected an error for an invalid section header")
+	}
+	if err := New[LegacyConfig](WithFormat("toml")).LoadFromFile(xmlFile, &invalid); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+var benchmarkYAML = []byte(`
+server:
+  host: localhost
+  port: 9090
+debug: true
+features: [a, b, c]
+nested_config:
+  timeout: 10s
+nested_configs:
+  acme:
+    timeout: 1m
+  globex:
+    timeout: 2m
+`)
+
+func BenchmarkLoadFromYAML(b *testing.B) {
+	cfg := New[TestAppConfig]()
+	b.ReportAllocs()
+	for b.Loop() {
+		var target TestAppConfig
+		if err := cfg.LoadFromYAML(benchmarkYAML, &target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkApplyDefaults(b *testing.B) {
+	cfg := New[TestAppConfig]()
+	b.ReportAllocs()
+	for b.Loop() {
+		var target TestAppConfig
+		if err := cfg.ApplyDefaults(&target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateTemplate(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := GenerateTemplate[TestAppConfig](); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+)
+
+// LoadFromEmbedded loads the baseline configuration from a file in fsys, typically an embed.FS,
+// overlays the optional on-disk override file and environment variables, then validates
+func (c *Config[T]) LoadFromEmbedded(fsys fs.FS, name, overridePath string, target *T) error {
+	trace := c.beginLoad()
+
+	// First apply defaults
+	if err := c.applyDefaults(trace, reflect.ValueOf(target), ""); err != nil {
+		return fmt.Errorf("failed to apply defaults: %w", err)
+	}
+
+	// Load the baseline shipped inside the binary
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("failed to read embedded config %s: %w", name, err)
+	}
+	if err := c.parse(trace, data, target, Origin{Source: SourceEmbedded, Location: name}); err != nil {
+		return fmt.Errorf("failed to load embedded config %s: %w", name, err)
+	}
+
+	// Overlay the on-disk override if it exists
+	if overridePath != "" && c.parser.FileExists(overridePath) {
+		if err := c.parseFile(trace, overridePath, target); err != nil {
+			return fmt.Errorf("failed to load config file %s: %w", overridePath, err)
+		}
+	}
+
+	// Environment variables take precedence over both files
+	if err := c.applyEnv(trace, target); err != nil {
+		return fmt.Errorf("failed to apply environment: %w", err)
+	}
+
+	// Validate the final configuration
+	return c.validateLoaded(trace, target)
+}
+
+// LoadWithEmbedded is a convenience function that loads an embedded baseline configuration,
+// overlays the optional on-disk override and environment variables, and validates in one call
+func LoadWithEmbedded[T any](fsys fs.FS, name, overridePath string, opts ...Option) (*T, error) {
+	cfg := New[T](opts...)
+	var target T
+
+	if err := cfg.LoadFromEmbedded(fsys, name, overridePath, &target); err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv overrides fields from environment variables named after their YAML path,
+// for example PREFIX_SERVER_PORT for the port field of the server section.
+// It does nothing unless an env prefix is configured
+func (c *Config[T]) applyEnv(trace *loadTrace, target *T) error {
+	if c.options.envPrefix == "" {
+		return nil
+	}
+	return c.applyEnvValue(trace, reflect.ValueOf(target).Elem(), strings.ToUpper(c.options.envPrefix), "")
+}
+
+// applyEnvValue recursively applies environment overrides to the struct fields, path is the dotted YAML path of v
+func (c *Config[T]) applyEnvValue(trace *loadTrace, v reflect.Value, prefix, path string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := fieldsOf(v.Type())
+	for i := range fields {
+		info := &fields[i]
+		if info.env == "" {
+			continue
+		}
+		if err := c.applyEnvField(trace, v.Field(info.index), prefix+"_"+info.env, info.path(path)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyEnvField overrides a single value from the variable named key. Structs are descended into and
+// slices and maps without a variable for the whole value have their entries overridden by index or key
+func (c *Config[T]) applyEnvField(trace *loadTrace, field reflect.Value, key, path string) error {
+	// Handle nested structs
+	if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
+		if field.Kind() == reflect.Ptr && field.IsNil() && envHasPrefix(key+"_") {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return c.applyEnvValue(trace, field, key, path)
+	}
+
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		switch field.Kind() {
+		case reflect.Slice:
+			return c.applyEnvSlice(trace, field, key, path)
+		case reflect.Map:
+			return c.applyEnvMap(trace, field, key, path)
+		}
+		return nil
+	}
+
+	if c.options.durationUnit > 0 && field.Type() == reflect.TypeOf(time.Duration(0)) {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			value = (time.Duration(n) * c.options.durationUnit).String()
+		}
+	}
+	if err := setFieldValue(field, value); err != nil {
+		return fmt.Errorf("failed to set field %s from %s: %w", path, key, err)
+	}
+	c.record(trace, path, Origin{Source: SourceEnv, Location: key})
+	return nil
+}
+
+// applyEnvSlice overrides slice elements by index, e.g. PREFIX_ENDPOINTS_0_URL. Variables for the index
+// after the last element append a zero element, so entries can be added in order
+func (c *Config[T]) applyEnvSlice(trace *loadTrace, field reflect.Value, key, path string) error {
+	for i := 0; ; i++ {
+		elemKey := key + "_" + strconv.Itoa(i)
+		if i >= field.Len() {
+			if !envHasPrefix(elemKey) {
+				return nil
+			}
+			field.Set(reflect.Append(field, reflect.Zero(field.Type().Elem())))
+		}
+
+		if err := c.applyEnvField(trace, field.Index(i), elemKey, path+"."+strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+}
+
+// applyEnvMap overrides map entries by key, e.g. PREFIX_TENANTS_ACME_LIMIT for the limit of the acme
+// entry. Maps of scalars also gain entries for unknown keys, named after the lower-cased variable suffix
+func (c *Config[T]) applyEnvMap(trace *loadTrace, field reflect.Value, key, path string) error {
+	if field.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for _, mapKey := range field.MapKeys() {
+		entryKey := key + "_" + envSegment(mapKey.String())
+		known[entryKey] = true
+		if !envHasPrefix(entryKey) {
+			continue
+		}
+
+		// Map values are not addressable, override a copy and store it back
+		elem := reflect.New(field.Type().Elem()).Elem()
+		elem.Set(field.MapIndex(mapKey))
+		if err := c.applyEnvField(trace, elem, entryKey, path+"."+mapKey.String()); err != nil {
+			return err
+		}
+		field.SetMapIndex(mapKey, elem)
+	}
+
+	switch field.Type().Elem().Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Slice, reflect.Map:
+		// The variable suffix can't be split into an entry key and a nested path
+		return nil
+	}
+
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		suffix, ok := strings.CutPrefix(name, key+"_")
+		if !ok || suffix == "" || known[name] {
+			continue
+		}
+
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		mapKey := reflect.New(field.Type().Key()).Elem()
+		mapKey.SetString(strings.ToLower(suffix))
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if err := c.applyEnvField(trace, elem, name, path+"."+mapKey.String()); err != nil {
+			return err
+		}
+		field.SetMapIndex(mapKey, elem)
+	}
+	return nil
+}
+
+// envHasPrefix reports whether the variable key or a variable nested under it is set
+func envHasPrefix(key string) bool {
+	if _, ok := os.LookupEnv(key); ok {
+		return true
+	}
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, key+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// envSegment converts a YAML name or map key to its environment variable segment
+func envSegment(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// envName returns the environment variable segment for a field, derived from its YAML name
+func envName(field reflect.StructField) string {
+	name := field.Name
+	if tag := field.Tag.Get("yaml"); tag != "" {
+		tagName := strings.Split(tag, ",")[0]
+		if tagName == "-" {
+			return ""
+		}
+		if tagName != "" {
+			name = tagName
+		}
+	}
+	return envSegment(name)
+}
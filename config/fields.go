@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+// structFields caches the fieldInfo of each struct type walked by applyDefaults and the
+// environment overrides, so repeated loads don't parse tags and default values again
+var structFields sync.Map // reflect.Type -> []fieldInfo
+
+// fieldInfo is the reflection metadata of an exported struct field
+type fieldInfo struct {
+	// index is the position of the field in the struct
+	index int
+	// field is the struct field
+	field reflect.StructField
+	// name is the YAML name, empty for inline fields
+	name string
+	// excluded is set for fields tagged yaml:"-"
+	excluded bool
+	// env is the environment variable segment of the field, empty when excluded
+	env string
+	// nested is set for struct and pointer to struct fields, which are descended into
+	nested bool
+	// defaultValue is the parsed default tag, invalid when the field has no default
+	defaultValue reflect.Value
+	// defaultErr is the error parsing the default tag
+	defaultErr error
+}
+
+// path returns the dotted YAML path of the field below the parent path, matching fieldPath
+func (f *fieldInfo) path(parent string) string {
+	switch {
+	case f.excluded:
+		return ""
+	case f.name == "":
+		return parent
+	case parent == "":
+		return f.name
+	}
+	return parent + "." + f.name
+}
+
+// defaultFor returns the parsed default of the field. Slices are copied so configurations
+// don't share the backing array of the cached value
+func (f *fieldInfo) defaultFor() reflect.Value {
+	if f.defaultValue.Kind() != reflect.Slice {
+		return f.defaultValue
+	}
+	copied := reflect.MakeSlice(f.defaultValue.Type(), f.defaultValue.Len(), f.defaultValue.Len())
+	reflect.Copy(copied, f.defaultValue)
+	return copied
+}
+
+// fieldsOf returns the metadata of the exported fields of the struct type, computing it on first use
+func fieldsOf(t reflect.Type) []fieldInfo {
+	if cached, ok := structFields.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		info := fieldInfo{index: i, field: field, env: envName(field)}
+		if name, ok := fieldPath("", field); !ok {
+			info.excluded = true
+		} else {
+			info.name = name
+		}
+		info.nested = field.Type.Kind() == reflect.Struct ||
+			(field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct)
+
+		if tag := field.Tag.Get("default"); tag != "" && !info.nested {
+			value := reflect.New(field.Type).Elem()
+			if err := setFieldValue(value, tag); err != nil {
+				info.defaultErr = err
+			} else {
+				info.defaultValue = value
+			}
+		}
+		fields = append(fields, info)
+	}
+
+	cached, _ := structFields.LoadOrStore(t, fields)
+	return cached.([]fieldInfo)
+}
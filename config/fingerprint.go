@@ -0,0 +1,230 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrIncompatibleSchema is returned by VerifyCompatibility when config files written for the
+// fingerprinted schema may not load as intended with the current one
+var ErrIncompatibleSchema = errors.New("incompatible config schema")
+
+// fingerprintVersion prefixes fingerprints so the format can change
+const fingerprintVersion = "v1"
+
+// schemaField is the fingerprint of a single configurable value
+type schemaField struct {
+	typ        string
+	validation string
+	def        string
+}
+
+// Fingerprint returns a stable fingerprint of the configuration schema of T: the YAML paths of its
+// fields with their types, validation rules and defaults. It starts with a hash of the whole schema,
+// followed by per-field hashes that let VerifyCompatibility report what changed
+func Fingerprint[T any]() string {
+	fields := schemaFields(reflect.TypeFor[T]())
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	entries := make([]string, 0, len(paths))
+	for _, path := range paths {
+		field := fields[path]
+		entries = append(entries, path+"="+shortHash(field.typ)+"."+shortHash(field.validation)+"."+shortHash(field.def))
+	}
+	schema := strings.Join(entries, ";")
+
+	sum := sha256.Sum256([]byte(schema))
+	return fingerprintVersion + ":" + hex.EncodeToString(sum[:8]) + ":" + base64.RawURLEncoding.EncodeToString([]byte(schema))
+}
+
+// VerifyCompatibility compares the schema of T with a fingerprint taken from an older binary, e.g. by
+// deploy tooling before rolling out. It returns an error wrapping ErrIncompatibleSchema listing removed
+// fields, whose values would be ignored, fields whose type or validation rules changed and new required
+// fields without a default. Added optional fields and changed defaults are compatible
+func VerifyCompatibility[T any](oldFingerprint string) error {
+	current := Fingerprint[T]()
+	if oldFingerprint == current {
+		return nil
+	}
+
+	old, err := parseFingerprint(oldFingerprint)
+	if err != nil {
+		return err
+	}
+	fields, _ := parseFingerprint(current)
+	schema := schemaFields(reflect.TypeFor[T]())
+
+	var problems []string
+	for path, oldField := range old {
+		field, ok := fields[path]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("'%s' was removed", path))
+		case field.typ != oldField.typ:
+			problems = append(problems, fmt.Sprintf("'%s' changed type", path))
+		case field.validation != oldField.validation:
+			problems = append(problems, fmt.Sprintf("'%s' changed validation rules", path))
+		}
+	}
+	for path, field := range schema {
+		if _, ok := old[path]; !ok && field.def == "" && isRequired(field.validation) {
+			problems = append(problems, fmt.Sprintf("'%s' was added as required without a default", path))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	slices.Sort(problems)
+	return fmt.Errorf("%w: %s", ErrIncompatibleSchema, strings.Join(problems, ", "))
+}
+
+// parseFingerprint returns the field hashes of a fingerprint, keyed by path
+func parseFingerprint(fingerprint string) (map[string]schemaField, error) {
+	parts := strings.SplitN(fingerprint, ":", 3)
+	if len(parts) != 3 || parts[0] != fingerprintVersion {
+		return nil, fmt.Errorf("invalid config fingerprint '%s'", fingerprint)
+	}
+	schema, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid config fingerprint: %w", err)
+	}
+
+	fields := make(map[string]schemaField)
+	if len(schema) == 0 {
+		return fields, nil
+	}
+	for entry := range strings.SplitSeq(string(schema), ";") {
+		path, hashes, ok := strings.Cut(entry, "=")
+		parts := strings.Split(hashes, ".")
+		if !ok || len(parts) != 3 {
+			return nil, fmt.Errorf("invalid config fingerprint entry '%s'", entry)
+		}
+		fields[path] = schemaField{typ: parts[0], validation: parts[1], def: parts[2]}
+	}
+	return fields, nil
+}
+
+// schemaFields returns the configurable values of the type keyed by their dotted YAML path.
+// Struct elements of slices and maps are described under path[] and path{}
+func schemaFields(t reflect.Type) map[string]schemaField {
+	fields := make(map[string]schemaField)
+	collectSchemaFields(t, "", fields)
+	return fields
+}
+
+// collectSchemaFields adds the fields of the struct type, descending into nested structs
+func collectSchemaFields(t reflect.Type, path string, fields map[string]schemaField) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		fieldPath, ok := fieldPath(path, fieldType)
+		if !ok {
+			continue
+		}
+
+		ft := fieldType.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct && !isLeafType(ft):
+			collectSchemaFields(ft, fieldPath, fields)
+			continue
+		case ft.Kind() == reflect.Slice && isStructType(ft.Elem()):
+			collectSchemaFields(ft.Elem(), fieldPath+"[]", fields)
+		case ft.Kind() == reflect.Map && isStructType(ft.Elem()):
+			collectSchemaFields(ft.Elem(), fieldPath+"{}", fields)
+		}
+
+		fields[fieldPath] = schemaField{
+			typ:        schemaTypeName(ft),
+			validation: fieldType.Tag.Get("validate"),
+			def:        fieldType.Tag.Get("default"),
+		}
+	}
+}
+
+// isStructType reports whether values of the type are decoded field by field
+func isStructType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isLeafType(t)
+}
+
+// isLeafType reports whether a struct type decodes itself, like time.Time
+func isLeafType(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(reflect.TypeFor[yaml.Unmarshaler]()) ||
+		ptr.Implements(reflect.TypeFor[encoding.TextUnmarshaler]())
+}
+
+// schemaTypeName describes how a type is written in YAML. Named basic types are described by their
+// kind, so renaming a Go type doesn't change the fingerprint
+func schemaTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeFor[time.Duration]():
+		return "duration"
+	case t.Kind() == reflect.Struct && !isLeafType(t):
+		return "struct"
+	case t.Kind() == reflect.Struct:
+		return t.String()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "[]" + schemaTypeName(t.Elem())
+	case reflect.Map:
+		return "map[" + schemaTypeName(t.Key()) + "]" + schemaTypeName(t.Elem())
+	default:
+		return t.Kind().String()
+	}
+}
+
+// isRequired reports whether the validation rules require a value
+func isRequired(validation string) bool {
+	for rule := range strings.SplitSeq(validation, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// shortHash returns a short hash of the value, empty for empty values
+func shortHash(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a configuration file. XML and INI files are converted to a YAML document
+// before loading, so they get the same defaults, profiles, environment overrides and validation
+type Format string
+
+const (
+	// FormatYAML is the native format
+	FormatYAML Format = "yaml"
+	// FormatXML reads the children and attributes of the root element as the configuration. Fields are
+	// named by their xml tag, falling back to the YAML name; "wrapper>item" tags read lists nested in a
+	// wrapper element and ",chardata" tags read the text of elements with attributes
+	FormatXML Format = "xml"
+	// FormatINI reads keys before the first section as top level fields and [section] or [parent.child]
+	// headers as nested sections. Fields are named by their ini tag, falling back to the YAML name.
+	// Repeated keys and comma-separated values fill lists
+	FormatINI Format = "ini"
+)
+
+// xmlTextKey holds the text of XML elements that also have attributes or children
+const xmlTextKey = "#text"
+
+// FormatOf returns the format of a configuration file from its extension: XML for .xml, INI for .ini,
+// YAML for anything else
+func FormatOf(filename string) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xml":
+		return FormatXML
+	case ".ini":
+		return FormatINI
+	default:
+		return FormatYAML
+	}
+}
+
+// toYAML converts a configuration file of the format to a YAML document for the type
+func toYAML(format Format, data []byte, t reflect.Type) ([]byte, error) {
+	var root *yaml.Node
+	var err error
+	switch format {
+	case FormatYAML:
+		return data, nil
+	case FormatXML:
+		root, err = decodeXML(data)
+	case FormatINI:
+		root, err = decodeINI(data)
+	default:
+		return nil, fmt.Errorf("unsupported configuration format '%s'", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", strings.ToUpper(string(format)), err)
+	}
+	return yaml.Marshal(adaptNode(root, t, format))
+}
+
+// decodeXML returns the attributes and children of the root element as a mapping
+func decodeXML(data []byte) (*yaml.Node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return &yaml.Node{Kind: yaml.MappingNode}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			if root.Kind != yaml.MappingNode {
+				return &yaml.Node{Kind: yaml.MappingNode}, nil
+			}
+			return root, nil
+		}
+	}
+}
+
+// decodeXMLElement returns an element as a scalar of its text, or as a mapping of its attributes and
+// children when it has any. Repeated children become a sequence
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, attr := range start.Attr {
+		appendKey(node, attr.Name.Local, scalarNode(attr.Value))
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			appendKey(node, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			if len(node.Content) == 0 {
+				return scalarNode(value), nil
+			}
+			if value != "" {
+				appendKey(node, xmlTextKey, scalarNode(value))
+			}
+			return node, nil
+		}
+	}
+}
+
+// decodeINI returns the keys and sections of an INI file as a mapping
+func decodeINI(data []byte) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	section := root
+	lines := strings.Split(string(bytes.TrimPrefix(data, []byte("\ufeff"))), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+		case line[0] == '[':
+			name, ok := strings.CutSuffix(line[1:], "]")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("line %d: invalid section header '%s'", i+1, line)
+			}
+			section = root
+			for _, part := range strings.Split(name, ".") {
+				section = childMapping(section, strings.TrimSpace(part))
+			}
+		default:
+			separator := strings.IndexAny(line, "=:")
+			if separator <= 0 {
+				return nil, fmt.Errorf("line %d: expected key = value, got '%s'", i+1, line)
+			}
+			key := strings.TrimSpace(line[:separator])
+			appendKey(section, key, scalarNode(unquoteINI(strings.TrimSpace(line[separator+1:]))))
+		}
+	}
+	return root, nil
+}
+
+// unquoteINI removes the double or single quotes around a value
+func unquoteINI(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// scalarNode returns an untagged scalar, resolved by the type of the field it is decoded into
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// lookupKey returns the value of the key in the mapping, nil if absent
+func lookupKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// appendKey adds the key to the mapping. A repeated key turns its value into a sequence of all values
+func appendKey(node *yaml.Node, key string, value *yaml.Node) {
+	existing := lookupKey(node, key)
+	switch {
+	case existing == nil:
+		node.Content = append(node.Content, scalarNode(key), value)
+	case existing.Kind == yaml.SequenceNode:
+		existing.Content = append(existing.Content, value)
+	default:
+		*existing = yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{Kind: existing.Kind, Value: existing.Value, Content: existing.Content}, value}}
+	}
+}
+
+// childMapping returns the mapping under the key, adding it if absent
+func childMapping(node *yaml.Node, key string) *yaml.Node {
+	if child := lookupKey(node, key); child != nil && child.Kind == yaml.MappingNode {
+		return child
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, scalarNode(key), child)
+	return child
+}
+
+// adaptNode renames the keys of decoded XML or INI data to the YAML names of the fields of the type and
+// shapes lists, so the document decodes like YAML. Keys matching no field are kept for the parser
+func adaptNode(node *yaml.Node, t reflect.Type, format Format) *yaml.Node {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return node
+		}
+		adapted := &yaml.Node{Kind: yaml.MappingNode}
+		consumed := make(map[string]bool)
+		adaptStruct(node, t, format, adapted, consumed)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; !consumed[key] && key != xmlTextKey {
+				adapted.Content = append(adapted.Content, node.Content[i], node.Content[i+1])
+			}
+		}
+		return adapted
+	case reflect.Slice, reflect.Array:
+		items := node
+		switch {
+		case node.Kind == yaml.SequenceNode:
+		case node.Kind == yaml.ScalarNode && format == FormatINI:
+			items = &yaml.Node{Kind: yaml.SequenceNode}
+			if node.Value != "" {
+				for _, item := range strings.Split(node.Value, ",") {
+					items.Content = append(items.Content, scalarNode(strings.TrimSpace(item)))
+				}
+			}
+		default:
+			items = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}}
+		}
+		for i, item := range items.Content {
+			items.Content[i] = adaptNode(item, t.Elem(), format)
+		}
+		return items
+	case reflect.Map:
+		if node.Kind == yaml.MappingNode {
+			for i := 1; i < len(node.Content); i += 2 {
+				node.Content[i] = adaptNode(node.Content[i], t.Elem(), format)
+			}
+		}
+	}
+	return node
+}
+
+// adaptStruct adds the fields of the struct type found in the node to the adapted mapping under their
+// YAML names, descending into inline fields, and marks the keys it used
+func adaptStruct(node *yaml.Node, t reflect.Type, format Format, adapted *yaml.Node, consumed map[string]bool) {
+	for _, info := range fieldsOf(t) {
+		if info.excluded {
+			continue
+		}
+		if info.name == "" {
+			inline := info.field.Type
+			if inline.Kind() == reflect.Ptr {
+				inline = inline.Elem()
+			}
+			if inline.Kind() == reflect.Struct {
+				adaptStruct(node, inline, format, adapted, consumed)
+			}
+			continue
+		}
+
+		source, item, ok := sourceName(info, format)
+		if !ok {
+			continue
+		}
+		value := lookupKey(node, source)
+		if value == nil {
+			continue
+		}
+		consumed[source] = true
+		if item != "" {
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+			if value = lookupKey(value, item); value == nil {
+				value = &yaml.Node{Kind: yaml.SequenceNode}
+			}
+		}
+		adapted.Content = append(adapted.Content, scalarNode(info.name), adaptNode(value, info.field.Type, format))
+	}
+}
+
+// sourceName returns the key of the field in a file of the format: the name of its xml or ini tag,
+// falling back to its YAML name, and for "wrapper>item" XML tags the key of the items in the wrapper.
+// Fields tagged "-" for the format are not ok
+func sourceName(info fieldInfo, format Format) (string, string, bool) {
+	name, opts, _ := strings.Cut(info.field.Tag.Get(string(format)), ",")
+	switch {
+	case name == "-":
+		return "", "", false
+	case format == FormatXML && name == "" && strings.Contains(opts, "chardata"):
+		return xmlTextKey, "", true
+	case name == "":
+		return info.name, "", true
+	}
+	if format == FormatXML {
+		if wrapper, item, ok := strings.Cut(name, ">"); ok {
+			return wrapper, item, true
+		}
+	}
+	return name, "", true
+}
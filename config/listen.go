@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ErrListenAddr is returned by CheckListenAddrs when listen addresses are malformed, conflict or can't be bound
+var ErrListenAddr = errors.New("invalid listen address")
+
+// ListenOption configures CheckListenAddrs
+type ListenOption func(*listenCheck)
+
+// listenCheck holds the settings of CheckListenAddrs
+type listenCheck struct {
+	probe bool
+}
+
+// WithBindProbe makes CheckListenAddrs bind every address and close it again, reporting addresses that
+// are in use or not permitted. Probing has side effects and races with other processes, so it is meant
+// for startup checks rather than validating files
+func WithBindProbe() ListenOption {
+	return func(c *listenCheck) {
+		c.probe = true
+	}
+}
+
+// listenAddr is a listen address found in the configuration
+type listenAddr struct {
+	path string
+	addr string
+	host string
+	port int
+}
+
+// CheckListenAddrs verifies the fields tagged `addr:"listen"`, at any depth, before services fail at
+// bind time. String fields hold "host:port" or ":port" addresses and integer fields a port. Addresses
+// must be well-formed and no two may listen on the same port of overlapping hosts; port 0 and empty
+// values are skipped. All problems are returned, joined in an error wrapping ErrListenAddr
+func CheckListenAddrs[T any](cfg *T, opts ...ListenOption) error {
+	check := &listenCheck{}
+	for _, opt := range opts {
+		opt(check)
+	}
+
+	var addrs []listenAddr
+	var errs []error
+	collectListenAddrs(reflect.ValueOf(cfg), "", &addrs, &errs)
+
+	sort.SliceStable(addrs, func(i, j int) bool { return addrs[i].path < addrs[j].path })
+	for i, a := range addrs {
+		for _, b := range addrs[:i] {
+			if a.port == b.port && hostsOverlap(a.host, b.host) {
+				errs = append(errs, fmt.Errorf("%s: %s conflicts with %s at %s", a.path, a.addr, b.path, b.addr))
+			}
+		}
+	}
+
+	if check.probe {
+		for _, a := range addrs {
+			listener, err := net.Listen("tcp", a.addr)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: cannot bind %s: %w", a.path, a.addr, err))
+				continue
+			}
+			listener.Close()
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrListenAddr, errors.Join(errs...))
+}
+
+// collectListenAddrs walks v, whose dotted YAML path is path, and collects the listen addresses of
+// tagged fields, recording malformed ones as errors
+func collectListenAddrs(v reflect.Value, path string, addrs *[]listenAddr, errs *[]error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := fieldsOf(v.Type())
+		for i := range fields {
+			info := &fields[i]
+			field := v.Field(info.index)
+			fieldPath := info.path(path)
+			if info.field.Tag.Get("addr") == "listen" {
+				collectListenField(field, fieldPath, addrs, errs)
+				continue
+			}
+			collectListenAddrs(field, fieldPath, addrs, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectListenAddrs(v.Index(i), path+"["+strconv.Itoa(i)+"]", addrs, errs)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			collectListenAddrs(v.MapIndex(key), joinFieldPath(path, key.String()), addrs, errs)
+		}
+	}
+}
+
+// collectListenField parses the value of a tagged field, a string address, a port or a slice of them
+func collectListenField(v reflect.Value, path string, addrs *[]listenAddr, errs *[]error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	var addr string
+	switch v.Kind() {
+	case reflect.String:
+		addr = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		addr = ":" + strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		addr = ":" + strconv.FormatUint(v.Uint(), 10)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectListenField(v.Index(i), path+"["+strconv.Itoa(i)+"]", addrs, errs)
+		}
+		return
+	default:
+		*errs = append(*errs, fmt.Errorf("%s: unsupported listen address type %s", path, v.Type()))
+		return
+	}
+	if addr == "" {
+		return
+	}
+
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: malformed address '%s', expected host:port or :port", path, addr))
+		return
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port < 0 || port > 65535 {
+		*errs = append(*errs, fmt.Errorf("%s: port of '%s' must be a number between 0 and 65535", path, addr))
+		return
+	}
+	if port == 0 {
+		// The system picks a free port
+		return
+	}
+	*addrs = append(*addrs, listenAddr{path: path, addr: addr, host: host, port: port})
+}
+
+// hostsOverlap reports whether listening on both hosts can collide: one is a wildcard or both are the same
+func hostsOverlap(a, b string) bool {
+	if isWildcardHost(a) || isWildcardHost(b) {
+		return true
+	}
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA != nil && ipB != nil {
+		return ipA.Equal(ipB)
+	}
+	return a == b
+}
+
+// isWildcardHost reports whether the host listens on all interfaces
+func isWildcardHost(host string) bool {
+	if host == "" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// joinFieldPath appends a map key to a dotted path
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,108 @@
+package config
+
+import "time"
+
+// Option configures a Config instance
+type Option func(*options)
+
+type options struct {
+	profile           string
+	envPrefix         string
+	durationUnit      time.Duration
+	lenientTypes      bool
+	flexibleKeys      bool
+	disallowAnchors   bool
+	maxAliasExpansion *int
+	loadResult        *LoadResult
+	syncOnSave        bool
+	saveBackup        string
+	format            Format
+}
+
+// WithProfile selects the profile merged over the default profile
+// when the configuration file uses the profiles layout
+func WithProfile(name string) Option {
+	return func(o *options) {
+		o.profile = name
+	}
+}
+
+// WithEnvPrefix enables environment variable overrides applied after the configuration files.
+// Variables are named after the upper-cased YAML path, e.g. APP_SERVER_PORT for prefix "APP"
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithDurationUnit interprets bare integers in YAML duration fields in the given unit,
+// so "timeout: 30" means 30 seconds with time.Second. Duration strings like "30s" keep working
+func WithDurationUnit(unit time.Duration) Option {
+	return func(o *options) {
+		o.durationUnit = unit
+	}
+}
+
+// WithLenientTypes performs safe conversions of quoted YAML scalars, such as "8080" into an int field,
+// instead of failing with a yaml.CoercionError
+func WithLenientTypes() Option {
+	return func(o *options) {
+		o.lenientTypes = true
+	}
+}
+
+// WithFlexibleKeys matches YAML keys to fields ignoring case, dashes and underscores,
+// so serverPort, server-port and server_port all map to the same field
+func WithFlexibleKeys() Option {
+	return func(o *options) {
+		o.flexibleKeys = true
+	}
+}
+
+// WithDisallowAnchors rejects configuration using YAML anchors or aliases,
+// for security-sensitive loads of user-provided configuration
+func WithDisallowAnchors() Option {
+	return func(o *options) {
+		o.disallowAnchors = true
+	}
+}
+
+// WithMaxAliasExpansion sets the maximum number of nodes a document using aliases may expand to,
+// yaml.DefaultMaxAliasExpansion by default. A limit of zero or less disables the check
+func WithMaxAliasExpansion(limit int) Option {
+	return func(o *options) {
+		o.maxAliasExpansion = &limit
+	}
+}
+
+// WithLoadResult fills the result on every load, recording where each field's value came from.
+// A Config with a load result must not be used for concurrent loads
+func WithLoadResult(result *LoadResult) Option {
+	return func(o *options) {
+		o.loadResult = result
+	}
+}
+
+// WithSyncOnSave makes SaveToFile flush the file and its directory to disk before returning,
+// so configuration saved by admin endpoints survives a power loss
+func WithSyncOnSave() Option {
+	return func(o *options) {
+		o.syncOnSave = true
+	}
+}
+
+// WithSaveBackup makes SaveToFile keep the previous version of an existing file,
+// named after the file with the suffix appended, e.g. ".bak"
+func WithSaveBackup(suffix string) Option {
+	return func(o *options) {
+		o.saveBackup = suffix
+	}
+}
+
+// WithFormat reads configuration files in the format instead of the one of their extension,
+// see FormatOf
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
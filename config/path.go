@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrPathNotFound is returned by Get and Set when a path does not address a field of the configuration
+var ErrPathNotFound = errors.New("config path not found")
+
+// Get returns the value at the dotted YAML path of the configuration, e.g. "server.port". Slice elements
+// are addressed by index, as "endpoints[1].url" or "endpoints.1.url", and map entries by key, as
+// "tenants.acme.limit". Paths through a nil pointer return nil
+func Get[T any](cfg *T, path string) (any, error) {
+	v := reflect.ValueOf(cfg).Elem()
+	for _, segment := range pathSegments(path) {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+
+		next, err := pathChild(v, segment, path)
+		if err != nil {
+			return nil, err
+		}
+		v = next
+	}
+	return v.Interface(), nil
+}
+
+// Set assigns the value at the dotted YAML path of the configuration, addressed like Get. The value must
+// be assignable or convertible to the field's numeric type, or a string parsed like default tags and
+// environment variables. Nil pointers along the path are allocated. The configuration is not validated
+func Set[T any](cfg *T, path string, value any) error {
+	return setPath(reflect.ValueOf(cfg).Elem(), pathSegments(path), path, value)
+}
+
+// setPath assigns the value at the remaining segments below v. Map entries are not addressable,
+// so they are set on a copy that is stored back
+func setPath(v reflect.Value, segments []string, path string, value any) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(segments) == 0 {
+		if err := assignValue(v, value); err != nil {
+			return fmt.Errorf("failed to set '%s': %w", path, err)
+		}
+		return nil
+	}
+
+	if v.Kind() != reflect.Map {
+		child, err := pathChild(v, segments[0], path)
+		if err != nil {
+			return err
+		}
+		return setPath(child, segments[1:], path, value)
+	}
+
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("%w: '%s'", ErrPathNotFound, path)
+	}
+	key := reflect.New(v.Type().Key()).Elem()
+	key.SetString(segments[0])
+	elem := reflect.New(v.Type().Elem()).Elem()
+	if existing := v.MapIndex(key); existing.IsValid() {
+		elem.Set(existing)
+	}
+	if err := setPath(elem, segments[1:], path, value); err != nil {
+		return err
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	v.SetMapIndex(key, elem)
+	return nil
+}
+
+// pathChild returns the struct field, slice element or map entry named by the segment
+func pathChild(v reflect.Value, segment, path string) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if field, ok := yamlField(v, segment); ok {
+			return field, nil
+		}
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < v.Len() {
+			return v.Index(i), nil
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			key := reflect.New(v.Type().Key()).Elem()
+			key.SetString(segment)
+			if elem := v.MapIndex(key); elem.IsValid() {
+				return elem, nil
+			}
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("%w: '%s'", ErrPathNotFound, path)
+}
+
+// yamlField returns the exported field of the struct with the YAML name, searching inline fields
+func yamlField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		fieldName, ok := fieldPath("", fieldType)
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		if fieldName == "" {
+			// Inline fields keep the parent path
+			for field.Kind() == reflect.Ptr && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() != reflect.Struct {
+				continue
+			}
+			if inner, ok := yamlField(field, name); ok {
+				return inner, true
+			}
+			continue
+		}
+		if fieldName == name {
+			return field, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// pathSegments splits a dotted path into segments, turning indexes such as [1] into segments
+func pathSegments(path string) []string {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// assignValue sets the field to the value, converting numbers and parsing strings
+func assignValue(field reflect.Value, value any) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case isNumericKind(v.Kind()) && isNumericKind(field.Kind()):
+		converted := v.Convert(field.Type())
+		if !converted.Convert(v.Type()).Equal(v) {
+			return fmt.Errorf("%v overflows %s", value, field.Type())
+		}
+		field.Set(converted)
+	case v.Kind() == reflect.String:
+		return setFieldValue(field, v.String())
+	default:
+		return fmt.Errorf("cannot use %s as %s", v.Type(), field.Type())
+	}
+	return nil
+}
+
+// isNumericKind reports whether values of the kind are integers or floats
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
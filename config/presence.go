@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError is returned, wrapped, by loads whose final configuration fails validation. It has an
+// issue per failed validate tag, telling fields no source set apart from fields set to a zero value,
+// e.g. "server.port: not set, required by the 'min=1' tag". It unwraps to validator.ValidationErrors
+type ValidationError struct {
+	Issues []Issue
+	err    error
+}
+
+// Error lists the issues
+func (e *ValidationError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issues[i] = issue.String()
+	}
+	return strings.Join(issues, "; ")
+}
+
+// Unwrap returns the validator's error
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// loadTrace records what the sources of a load set, to tell fields nobody set from fields
+// explicitly set to their zero value
+type loadTrace struct {
+	// documents are the resolved YAML documents, scanned for their keys only when needed
+	documents [][]byte
+	// set are the paths set by default tags and environment variables
+	set map[string]bool
+}
+
+// newLoadTrace creates an empty load trace
+func newLoadTrace() *loadTrace {
+	return &loadTrace{set: make(map[string]bool)}
+}
+
+// presentPaths returns the dotted YAML paths set by any source of the load
+func (c *Config[T]) presentPaths(trace *loadTrace) map[string]bool {
+	present := make(map[string]bool)
+	if trace == nil {
+		return present
+	}
+	for path := range trace.set {
+		present[path] = true
+	}
+	for _, document := range trace.documents {
+		lines, err := c.parser.FieldLines(document)
+		if err != nil {
+			continue
+		}
+		for path := range lines {
+			present[path] = true
+		}
+	}
+	return present
+}
+
+// validateLoaded validates the loaded configuration. Before validating, the fields with a validate tag
+// left at their zero value by every source are noted in the load result, if one was requested
+func (c *Config[T]) validateLoaded(trace *loadTrace, target *T) error {
+	var present map[string]bool
+	if result := c.options.loadResult; result != nil {
+		present = c.presentPaths(trace)
+		result.unset = unsetPaths(reflect.ValueOf(target), "", present, nil)
+		sort.Strings(result.unset)
+	}
+
+	err := c.Validate(target)
+	if err == nil {
+		return nil
+	}
+	if present == nil {
+		present = c.presentPaths(trace)
+	}
+	issues := validationIssues(err, reflect.TypeOf(*target), present, nil)
+	return fmt.Errorf("validation failed: %w", &ValidationError{Issues: issues, err: err})
+}
+
+// unsetPaths appends the paths below v of the fields with a validate tag whose value is zero and that
+// no source set. Nested structs are descended into, collection elements are not
+func unsetPaths(v reflect.Value, path string, present map[string]bool, unset []string) []string {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return unset
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return unset
+	}
+
+	fields := fieldsOf(v.Type())
+	for i := range fields {
+		info := &fields[i]
+		if info.excluded {
+			continue
+		}
+		field := v.Field(info.index)
+		fieldPath := info.path(path)
+
+		if tag := info.field.Tag.Get("validate"); tag != "" && tag != "-" && field.IsZero() && !present[fieldPath] {
+			unset = append(unset, fieldPath)
+			continue
+		}
+		if info.nested {
+			unset = unsetPaths(field, fieldPath, present, unset)
+		}
+	}
+	return unset
+}
+
+// validationIssues returns an issue per failed validate tag of the target type, located with the field
+// lines of the document. Zero values are reported as not set when no source set the field
+func validationIssues(err error, targetType reflect.Type, present map[string]bool, lines map[string]int) []Issue {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []Issue{{Kind: IssueValidation, Message: err.Error()}}
+	}
+
+	issues := make([]Issue, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		tag := fieldErr.Tag()
+		if fieldErr.Param() != "" {
+			tag += "=" + fieldErr.Param()
+		}
+		path := yamlPath(targetType, fieldErr.StructNamespace())
+
+		message := fmt.Sprintf("failed on the '%s' tag", tag)
+		if value := reflect.ValueOf(fieldErr.Value()); !value.IsValid() || value.IsZero() {
+			if present[path] {
+				message = fmt.Sprintf("set to %s, failed on the '%s' tag", zeroDescription(value), tag)
+			} else {
+				message = fmt.Sprintf("not set, required by the '%s' tag", tag)
+			}
+		}
+		issues = append(issues, Issue{Kind: IssueValidation, Path: path, Line: lineOf(lines, path), Message: message})
+	}
+	return issues
+}
+
+// zeroDescription describes an explicitly set zero value, e.g. 0 or an empty string
+func zeroDescription(value reflect.Value) string {
+	if !value.IsValid() {
+		return "null"
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return "an empty string"
+	case reflect.Slice:
+		return "an empty list"
+	case reflect.Map:
+		return "an empty map"
+	case reflect.Ptr, reflect.Interface:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", value.Interface())
+	}
+}
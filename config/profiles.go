@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// profilesKey is the top-level key holding per-environment profiles
+	profilesKey = "profiles"
+	// defaultProfile is the profile every other profile is merged over
+	defaultProfile = "default"
+)
+
+// resolveProfile flattens a document using the profiles layout into a plain document.
+// Top-level keys outside of profiles form the base, the default profile is merged over it
+// and the selected profile over the result. Documents without profiles are returned unchanged,
+// the boolean reports whether the document was rewritten
+func resolveProfile(data []byte, profile string) ([]byte, bool, error) {
+	var document map[string]any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, false, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	rawProfiles, ok := document[profilesKey]
+	if !ok {
+		if profile != "" && profile != defaultProfile {
+			return nil, false, fmt.Errorf("profile '%s' requested but config has no profiles", profile)
+		}
+		return data, false, nil
+	}
+
+	profiles, ok := rawProfiles.(map[string]any)
+	if !ok {
+		return nil, false, fmt.Errorf("'%s' must be a mapping of profile names", profilesKey)
+	}
+	delete(document, profilesKey)
+
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	result := document
+	for _, name := range []string{defaultProfile, profile} {
+		raw, exists := profiles[name]
+		if !exists {
+			if name == defaultProfile {
+				continue
+			}
+			return nil, false, fmt.Errorf("profile '%s' not found", name)
+		}
+		if raw == nil {
+			continue
+		}
+
+		values, ok := raw.(map[string]any)
+		if !ok {
+			return nil, false, fmt.Errorf("profile '%s' must be a mapping", name)
+		}
+		result = mergeMaps(result, values)
+
+		if profile == defaultProfile {
+			break
+		}
+	}
+
+	merged, err := yaml.Marshal(result)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal profile '%s': %w", profile, err)
+	}
+	return merged, true, nil
+}
+
+// mergeMaps deep-merges override into base. Nested mappings are merged key by key,
+// all other values in override replace the ones in base
+func mergeMaps(base, override map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range override {
+		overrideMap, overrideIsMap := value.(map[string]any)
+		baseMap, baseIsMap := result[key].(map[string]any)
+		if overrideIsMap && baseIsMap {
+			result[key] = mergeMaps(baseMap, overrideMap)
+			continue
+		}
+		result[key] = value
+	}
+
+	return result
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Source identifies the kind of source a configuration value came from
+type Source string
+
+const (
+	// SourceDefault is a default struct tag
+	SourceDefault Source = "default"
+	// SourceFile is a YAML file on disk
+	SourceFile Source = "file"
+	// SourceEmbedded is a YAML file read from an fs.FS such as an embed.FS
+	SourceEmbedded Source = "embedded"
+	// SourceYAML is YAML data passed to LoadFromYAML
+	SourceYAML Source = "yaml"
+	// SourceEnv is an environment variable
+	SourceEnv Source = "env"
+)
+
+// Origin describes where a field's final value came from
+type Origin struct {
+	Source Source
+	// Location is the file path, embedded file name or environment variable name,
+	// empty for defaults and YAML data
+	Location string
+	// Line is the line of the value in the YAML document, 0 when unknown such as after merging profiles
+	Line int
+}
+
+// String returns the origin as e.g. "file config.yaml:12", "env APP_SERVER_PORT" or "default"
+func (o Origin) String() string {
+	switch {
+	case o.Location != "" && o.Line > 0:
+		return fmt.Sprintf("%s %s:%d", o.Source, o.Location, o.Line)
+	case o.Location != "":
+		return fmt.Sprintf("%s %s", o.Source, o.Location)
+	case o.Line > 0:
+		return fmt.Sprintf("%s line %d", o.Source, o.Line)
+	default:
+		return string(o.Source)
+	}
+}
+
+// LoadResult describes the outcome of a load. Pass it with WithLoadResult to have it filled
+type LoadResult struct {
+	provenance map[string]Origin
+	// defaults are the paths of the fields set from default tags, in the order they were applied
+	defaults []string
+	// unset are the paths of the fields with a validate tag no source set
+	unset []string
+}
+
+// Provenance returns where the final value of every field set during the load came from,
+// keyed by dotted YAML path such as "server.port". Fields left at their zero value are absent
+func (r *LoadResult) Provenance() map[string]Origin {
+	return r.provenance
+}
+
+// Origin returns where the final value of the field at the dotted YAML path came from
+func (r *LoadResult) Origin(path string) (Origin, bool) {
+	origin, ok := r.provenance[path]
+	return origin, ok
+}
+
+// Defaults returns the dotted YAML paths of the fields that received their default value during
+// ApplyDefaults, sorted, including fields later overridden by files or the environment
+func (r *LoadResult) Defaults() []string {
+	defaults := slices.Clone(r.defaults)
+	sort.Strings(defaults)
+	return defaults
+}
+
+// Implicit returns the dotted YAML paths of the fields whose final value is their default, sorted,
+// so startup logs can tell settings nobody configured from operator-provided ones
+func (r *LoadResult) Implicit() []string {
+	var implicit []string
+	for _, path := range r.defaults {
+		if r.provenance[path].Source == SourceDefault {
+			implicit = append(implicit, path)
+		}
+	}
+	sort.Strings(implicit)
+	return implicit
+}
+
+// Unset returns the dotted YAML paths of the fields with a validate tag that no default, file or
+// environment variable set, sorted. It is filled before validation, so these fields can be reported
+// as missing settings even when a failing validation stops the load
+func (r *LoadResult) Unset() []string {
+	return r.unset
+}
+
+// beginLoad returns the trace of a new load, passed down to the steps of the load, and resets the load
+// result if one was requested. The trace is not kept on the Config, so loads can run concurrently
+func (c *Config[T]) beginLoad() *loadTrace {
+	if result := c.options.loadResult; result != nil {
+		result.provenance = make(map[string]Origin)
+		result.defaults = nil
+		result.unset = nil
+	}
+	return newLoadTrace()
+}
+
+// record notes the origin of a field's value in the trace of the load, nil outside loads, replacing the
+// origin of earlier values
+func (c *Config[T]) record(trace *loadTrace, path string, origin Origin) {
+	if trace != nil {
+		trace.set[path] = true
+	}
+
+	result := c.options.loadResult
+	if result == nil {
+		return
+	}
+	if result.provenance == nil {
+		result.provenance = make(map[string]Origin)
+	}
+	result.provenance[path] = origin
+}
+
+// recordDefault notes a field set from its default tag
+func (c *Config[T]) recordDefault(trace *loadTrace, path string) {
+	c.record(trace, path, Origin{Source: SourceDefault})
+	if result := c.options.loadResult; result != nil {
+		result.defaults = append(result.defaults, path)
+	}
+}
+
+// recordDocument notes the fields set by a parsed YAML document. Lines are dropped when the
+// document was rewritten by profile merging, since they no longer match the source
+func (c *Config[T]) recordDocument(trace *loadTrace, data []byte, origin Origin, keepLines bool) error {
+	if c.options.loadResult == nil {
+		return nil
+	}
+
+	lines, err := c.parser.FieldLines(data)
+	if err != nil {
+		return err
+	}
+	for path, line := range lines {
+		fieldOrigin := origin
+		if keepLines {
+			fieldOrigin.Line = line
+		}
+		c.record(trace, path, fieldOrigin)
+	}
+	return nil
+}
+
+// fieldPath appends the YAML name of the field to the dotted path. Inline fields keep the parent path
+// and fields excluded from YAML are reported as not ok
+func fieldPath(path string, field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	switch {
+	case name == "-":
+		return "", false
+	case strings.Contains(opts, "inline"):
+		return path, true
+	case name == "":
+		name = strings.ToLower(field.Name)
+	}
+
+	if path == "" {
+		return name, true
+	}
+	return path + "." + name, true
+}
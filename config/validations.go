@@ -0,0 +1,223 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validations are the validation tags for common infrastructure values registered by New
+var validations = map[string]validator.Func{
+	"hostport":     validateHostPort,
+	"duration_min": validateDurationMin,
+	"cidr_list":    validateCIDRList,
+	"url_scheme":   validateURLScheme,
+	"file_exists":  validateFileExists,
+	"dir_writable": validateDirWritable,
+	"max_items":    validateMaxItems,
+	"min_items":    validateMinItems,
+	"unique_items": validateUniqueItems,
+	"keys_match":   validateKeysMatch,
+}
+
+// keyPatterns caches the compiled keys_match patterns by parameter
+var keyPatterns sync.Map
+
+// RegisterValidations registers the built-in validation tags on a custom validator:
+// hostport, duration_min, cidr_list, url_scheme, file_exists, dir_writable, max_items,
+// min_items, unique_items and keys_match. New registers them automatically
+func RegisterValidations(v *validator.Validate) error {
+	for tag, fn := range validations {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("failed to register validation '%s': %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// validateHostPort accepts host:port with a numeric port between 1 and 65535. The host may be empty,
+// as in listen addresses like ":8080"
+func validateHostPort(fl validator.FieldLevel) bool {
+	_, port, err := net.SplitHostPort(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// validateDurationMin accepts durations of at least the parameter, e.g. duration_min=1s
+func validateDurationMin(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Type() != reflect.TypeOf(time.Duration(0)) {
+		return false
+	}
+
+	minimum, err := time.ParseDuration(fl.Param())
+	if err != nil {
+		panic(fmt.Sprintf("invalid duration_min parameter '%s': %v", fl.Param(), err))
+	}
+	return time.Duration(field.Int()) >= minimum
+}
+
+// validateCIDRList accepts a slice of CIDR blocks or a comma-separated string of them
+func validateCIDRList(fl validator.FieldLevel) bool {
+	var blocks []string
+	field := fl.Field()
+	switch {
+	case field.Kind() == reflect.String:
+		if field.String() == "" {
+			return true
+		}
+		blocks = strings.Split(field.String(), ",")
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < field.Len(); i++ {
+			blocks = append(blocks, field.Index(i).String())
+		}
+	default:
+		return false
+	}
+
+	for _, block := range blocks {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(block)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validateURLScheme accepts absolute URLs using one of the space-separated schemes, e.g. url_scheme=https
+func validateURLScheme(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return slices.Contains(strings.Fields(fl.Param()), strings.ToLower(u.Scheme))
+}
+
+// validateFileExists accepts paths of existing regular files
+func validateFileExists(fl validator.FieldLevel) bool {
+	info, err := os.Stat(fl.Field().String())
+	return err == nil && info.Mode().IsRegular()
+}
+
+// validateDirWritable accepts paths of existing directories the process can create files in
+func validateDirWritable(fl validator.FieldLevel) bool {
+	dir := fl.Field().String()
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return false
+	}
+
+	probe, err := os.CreateTemp(dir, ".writable-*")
+	if err != nil {
+		return false
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return true
+}
+
+// itemCount returns the number of items of a slice, array or map, false for other kinds
+func itemCount(field reflect.Value) (int, bool) {
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return field.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+// itemLimit parses the item count parameter of a tag
+func itemLimit(tag, param string) int {
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 0 {
+		panic(fmt.Sprintf("invalid %s parameter '%s': expected a non-negative integer", tag, param))
+	}
+	return n
+}
+
+// validateMaxItems accepts slices, arrays and maps with at most the parameter items, e.g. max_items=10.
+// Combined with dive it limits nested collections too, e.g. max_items=10,dive,max_items=5
+func validateMaxItems(fl validator.FieldLevel) bool {
+	n, ok := itemCount(fl.Field())
+	return ok && n <= itemLimit("max_items", fl.Param())
+}
+
+// validateMinItems accepts slices, arrays and maps with at least the parameter items, e.g. min_items=1
+func validateMinItems(fl validator.FieldLevel) bool {
+	n, ok := itemCount(fl.Field())
+	return ok && n >= itemLimit("min_items", fl.Param())
+}
+
+// validateUniqueItems accepts slices and arrays without equal items and maps without equal values.
+// Items that can't be map keys, such as slices, are compared deeply
+func validateUniqueItems(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	var items []reflect.Value
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			items = append(items, field.Index(i))
+		}
+	case reflect.Map:
+		iter := field.MapRange()
+		for iter.Next() {
+			items = append(items, iter.Value())
+		}
+	default:
+		return false
+	}
+
+	if field.Type().Elem().Comparable() {
+		seen := make(map[any]struct{}, len(items))
+		for _, item := range items {
+			if _, ok := seen[item.Interface()]; ok {
+				return false
+			}
+			seen[item.Interface()] = struct{}{}
+		}
+		return true
+	}
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i].Interface(), items[j].Interface()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateKeysMatch accepts maps with string keys all matching the regular expression parameter,
+// e.g. keys_match=^[a-z0-9_]+$. The expression can't contain commas or pipes, which separate tags
+func validateKeysMatch(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.Map || field.Type().Key().Kind() != reflect.String {
+		return false
+	}
+
+	pattern, ok := keyPatterns.Load(fl.Param())
+	if !ok {
+		compiled, err := regexp.Compile(fl.Param())
+		if err != nil {
+			panic(fmt.Sprintf("invalid keys_match parameter '%s': %v", fl.Param(), err))
+		}
+		pattern, _ = keyPatterns.LoadOrStore(fl.Param(), compiled)
+	}
+	for _, key := range field.MapKeys() {
+		if !pattern.(*regexp.Regexp).MatchString(key.String()) {
+			return false
+		}
+	}
+	return true
+}
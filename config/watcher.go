@@ -0,0 +1,265 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// WatchOption configures a Watcher
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval time.Duration
+	debounce time.Duration
+	onError  func(error)
+}
+
+// WithPollInterval sets how often the watched paths are checked for changes
+func WithPollInterval(interval time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.interval = interval
+	}
+}
+
+// WithDebounce sets how long the watched paths must stay unchanged before a reload,
+// so a burst of edits results in a single reload and notification
+func WithDebounce(debounce time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.debounce = debounce
+	}
+}
+
+// WithErrorHandler sets a callback invoked when a reload fails.
+// The previous snapshot stays active in that case
+func WithErrorHandler(handler func(error)) WatchOption {
+	return func(o *watchOptions) {
+		o.onError = handler
+	}
+}
+
+// fileState is the observed state of a single watched file
+type fileState struct {
+	modTime int64
+	size    int64
+}
+
+// Watcher reloads configuration whenever one of the watched files or directory fragments changes
+type Watcher[T any] struct {
+	config  *Config[T]
+	paths   []string
+	options watchOptions
+	current atomic.Pointer[T]
+	changes chan *T
+	state   map[string]fileState
+}
+
+// NewWatcher creates a watcher over the given files and directories and performs the initial load.
+// Every path must exist, so a mistyped path fails instead of never being watched; files removed later,
+// e.g. while an editor replaces them, are skipped until they reappear. If cfg is nil a default Config is used
+func NewWatcher[T any](cfg *Config[T], paths []string, opts ...WatchOption) (*Watcher[T], error) {
+	if cfg == nil {
+		cfg = New[T]()
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("failed to watch config path %s: %w", path, err)
+		}
+	}
+
+	w := &Watcher[T]{
+		config: cfg,
+		paths:  paths,
+		options: watchOptions{
+			interval: time.Second,
+			debounce: 100 * time.Millisecond,
+		},
+		changes: make(chan *T, 1),
+	}
+
+	for _, opt := range opts {
+		opt(&w.options)
+	}
+
+	state, err := w.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	w.state = state
+	w.current.Store(target)
+	return w, nil
+}
+
+// Current returns the most recently loaded configuration snapshot.
+// The returned value must be treated as read-only
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// Changes returns a channel receiving the new snapshot after each successful reload.
+// Only the latest snapshot is kept if the receiver falls behind
+func (w *Watcher[T]) Changes() <-chan *T {
+	return w.changes
+}
+
+// Run polls the watched paths until the context is cancelled
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.options.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		state, err := w.snapshot()
+		if err != nil {
+			w.handleError(err)
+			continue
+		}
+		if maps.Equal(state, w.state) {
+			continue
+		}
+
+		// Wait for the batch of changes to settle
+		state, err = w.waitStable(ctx, state)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			w.handleError(err)
+			continue
+		}
+		w.state = state
+
+		target, err := w.load()
+		if err != nil {
+			w.handleError(err)
+			continue
+		}
+
+		w.current.Store(target)
+		w.notify(target)
+	}
+}
+
+// waitStable re-checks the watched paths until two consecutive snapshots are equal
+func (w *Watcher[T]) waitStable(ctx context.Context, state map[string]fileState) (map[string]fileState, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(w.options.debounce):
+		}
+
+		next, err := w.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		if maps.Equal(next, state) {
+			return next, nil
+		}
+		state = next
+	}
+}
+
+// load builds a fresh snapshot from all watched paths
+func (w *Watcher[T]) load() (*T, error) {
+	var target T
+	if err := w.config.LoadFromFiles(w.paths, &target); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// snapshot records modification time and size of every watched file
+func (w *Watcher[T]) snapshot() (map[string]fileState, error) {
+	files, err := expandPaths(w.paths)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]fileState, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to stat config file %s: %w", file, err)
+		}
+		state[file] = fileState{
+			modTime: info.ModTime().UnixNano(),
+			size:    info.Size(),
+		}
+	}
+	return state, nil
+}
+
+// notify replaces any pending notification with the latest snapshot
+func (w *Watcher[T]) notify(target *T) {
+	select {
+	case <-w.changes:
+	default:
+	}
+	w.changes <- target
+}
+
+func (w *Watcher[T]) handleError(err error) {
+	if w.options.onError != nil {
+		w.options.onError(err)
+	}
+}
+
+// expandPaths resolves directories to their YAML fragments in lexical order.
+// Paths that do not exist are skipped
+func expandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to stat config path %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config directory %s: %w", path, err)
+		}
+
+		var fragments []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if ext == ".yaml" || ext == ".yml" {
+				fragments = append(fragments, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(fragments)
+		files = append(files, fragments...)
+	}
+	return files, nil
+}
@@ -0,0 +1,96 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxAliasExpansion is the default limit on the number of nodes a document using aliases may expand to
+const DefaultMaxAliasExpansion = 100000
+
+var (
+	// ErrExcessiveAliasing is returned when alias expansion exceeds the configured limit
+	ErrExcessiveAliasing = errors.New("excessive alias expansion")
+	// ErrAnchorsDisallowed is returned when a document uses anchors or aliases while they are disallowed
+	ErrAnchorsDisallowed = errors.New("anchors and aliases are not allowed")
+)
+
+// checkAliases inspects the document without expanding it, rejecting anchors when disallowed
+// and documents whose aliases expand to more than maxNodes nodes
+func checkAliases(data []byte, disallowAnchors bool, maxNodes int) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return checkAliasNodes(&root, disallowAnchors, maxNodes)
+}
+
+// checkAliasNodes verifies the anchor and alias limits of an already parsed node tree
+func checkAliasNodes(root *yaml.Node, disallowAnchors bool, maxNodes int) error {
+	if disallowAnchors {
+		if node := findAnchor(root); node != nil {
+			return fmt.Errorf("%w: found at line %d", ErrAnchorsDisallowed, node.Line)
+		}
+		return nil
+	}
+
+	if maxNodes <= 0 {
+		return nil
+	}
+
+	counter := aliasCounter{limit: maxNodes, sizes: make(map[*yaml.Node]int)}
+	if counter.size(root) > maxNodes && counter.aliases > 0 {
+		return fmt.Errorf("%w: document expands to more than %d nodes", ErrExcessiveAliasing, maxNodes)
+	}
+	return nil
+}
+
+// findAnchor returns the first node declaring an anchor or referencing one
+func findAnchor(node *yaml.Node) *yaml.Node {
+	if node.Anchor != "" || node.Kind == yaml.AliasNode {
+		return node
+	}
+	for _, child := range node.Content {
+		if found := findAnchor(child); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// aliasCounter computes the expanded size of a node tree, memoizing anchored subtrees
+type aliasCounter struct {
+	limit   int
+	aliases int
+	sizes   map[*yaml.Node]int
+}
+
+// size returns the number of nodes after alias expansion, stopping early once past the limit
+func (a *aliasCounter) size(node *yaml.Node) int {
+	if node.Kind == yaml.AliasNode {
+		a.aliases++
+		if node.Alias == nil {
+			return 1
+		}
+		return a.size(node.Alias)
+	}
+
+	if size, ok := a.sizes[node]; ok {
+		return size
+	}
+
+	size := 1
+	for _, child := range node.Content {
+		size += a.size(child)
+		if size > a.limit {
+			break
+		}
+	}
+
+	if node.Anchor != "" {
+		a.sizes[node] = size
+	}
+	return size
+}
@@ -0,0 +1,137 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TypeMismatch describes a YAML value that cannot be decoded into the type of its field
+type TypeMismatch struct {
+	Path     string // dotted field path, e.g. server.port or backoff[1]
+	Line     int
+	Value    string
+	YAMLType string // YAML tag or node kind, e.g. !!str or mapping
+	GoType   string
+	// Coercible is true when lenient mode would convert the value safely
+	Coercible bool
+}
+
+func (m TypeMismatch) String() string {
+	msg := fmt.Sprintf("%s (line %d): cannot use %s %q as %s", m.Path, m.Line, m.YAMLType, m.Value, m.GoType)
+	if m.Coercible {
+		msg += " (convertible with lenient types)"
+	}
+	return msg
+}
+
+// CoercionError reports every type mismatch of a document
+type CoercionError struct {
+	Mismatches []TypeMismatch
+}
+
+func (e *CoercionError) Error() string {
+	parts := make([]string, len(e.Mismatches))
+	for i, mismatch := range e.Mismatches {
+		parts[i] = mismatch.String()
+	}
+	return "type mismatch: " + strings.Join(parts, "; ")
+}
+
+// checkTypes reports scalars whose YAML type does not match their field type.
+// In lenient mode safe conversions, such as "8080" into an int field, are applied instead of reported
+func checkTypes(root *yaml.Node, t reflect.Type, lenient bool) error {
+	var mismatches []TypeMismatch
+
+	walkTyped(root, t, "", func(node *yaml.Node, t reflect.Type, path string) {
+		mismatch, ok := typeMismatch(node, t)
+		if !ok {
+			return
+		}
+		if lenient && mismatch.Coercible {
+			coerce(node, t)
+			return
+		}
+		mismatch.Path = path
+		mismatches = append(mismatches, mismatch)
+	})
+
+	if len(mismatches) > 0 {
+		return &CoercionError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// typeMismatch compares a node with the type it decodes into
+func typeMismatch(node *yaml.Node, t reflect.Type) (TypeMismatch, bool) {
+	mismatch := TypeMismatch{Line: node.Line, Value: node.Value, YAMLType: node.Tag, GoType: t.String()}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		mismatch.YAMLType, mismatch.Value = "mapping", "{...}"
+		return mismatch, true
+	case yaml.SequenceNode:
+		mismatch.YAMLType, mismatch.Value = "sequence", "[...]"
+		return mismatch, true
+	}
+
+	if node.Tag == "!!null" {
+		return mismatch, false
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return mismatch, false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			return mismatch, node.Tag != "!!str"
+		}
+		if node.Tag == "!!int" {
+			return mismatch, false
+		}
+		_, err := strconv.ParseInt(strings.TrimSpace(node.Value), 10, t.Bits())
+		mismatch.Coercible = node.Tag == "!!str" && err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if node.Tag == "!!int" {
+			return mismatch, false
+		}
+		_, err := strconv.ParseUint(strings.TrimSpace(node.Value), 10, t.Bits())
+		mismatch.Coercible = node.Tag == "!!str" && err == nil
+	case reflect.Float32, reflect.Float64:
+		if node.Tag == "!!float" || node.Tag == "!!int" {
+			return mismatch, false
+		}
+		_, err := strconv.ParseFloat(strings.TrimSpace(node.Value), t.Bits())
+		mismatch.Coercible = node.Tag == "!!str" && err == nil
+	case reflect.Bool:
+		if node.Tag == "!!bool" {
+			return mismatch, false
+		}
+		_, err := strconv.ParseBool(strings.TrimSpace(node.Value))
+		mismatch.Coercible = node.Tag == "!!str" && err == nil
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		// A scalar where a collection is expected
+	default:
+		return mismatch, false
+	}
+	return mismatch, true
+}
+
+// coerce rewrites a coercible string scalar as a plain scalar of the field type
+func coerce(node *yaml.Node, t reflect.Type) {
+	value := strings.TrimSpace(node.Value)
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		node.Tag = "!!float"
+	case reflect.Bool:
+		b, _ := strconv.ParseBool(value)
+		node.Tag, value = "!!bool", strconv.FormatBool(b)
+	default:
+		node.Tag = "!!int"
+	}
+	node.Value = value
+	node.Style = 0
+}
@@ -0,0 +1,23 @@
+package yaml
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyDurationUnit rewrites bare integer scalars decoded into time.Duration fields
+// as duration strings in the given unit
+func applyDurationUnit(root *yaml.Node, t reflect.Type, unit time.Duration) {
+	walkTyped(root, t, "", func(node *yaml.Node, t reflect.Type, path string) {
+		if t != durationType || node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			return
+		}
+		if n, err := strconv.ParseInt(node.Value, 0, 64); err == nil {
+			node.Value = (time.Duration(n) * unit).String()
+			node.Tag = "!!str"
+		}
+	})
+}
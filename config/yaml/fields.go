@@ -0,0 +1,126 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldLines returns the dotted paths of the struct fields set by the YAML data with the line of
+// their value, e.g. "server.port" -> 12. Slices, maps and custom decoded values are reported as a whole
+func (p *Parser[T]) FieldLines(data []byte) (map[string]int, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	targetType := reflect.TypeOf((*T)(nil))
+	if p.flexibleKeys {
+		normalizeKeys(&root, targetType)
+	}
+
+	lines := make(map[string]int)
+	collectFieldLines(&root, targetType, "", lines)
+	return lines, nil
+}
+
+// collectFieldLines descends into mappings decoded as structs and records the line of every other value
+func collectFieldLines(node *yaml.Node, t reflect.Type, path string, lines map[string]int) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectFieldLines(child, t, path, lines)
+		}
+		return
+	case yaml.AliasNode:
+		if node.Alias != nil && node.Alias.Kind == yaml.MappingNode {
+			collectFieldLines(node.Alias, t, path, lines)
+			return
+		}
+	}
+
+	if node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct && !customDecoded(t) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if field, ok := fieldByYAMLName(t, key); ok {
+				collectFieldLines(node.Content[i+1], field, joinPath(path, key), lines)
+			}
+		}
+		return
+	}
+
+	if path != "" {
+		lines[path] = node.Line
+	}
+}
+
+// UnknownKeys returns the dotted paths of the mapping keys that do not match a struct field with the line
+// of the key, e.g. "server.prot" -> 3. Entries of maps and slices are checked against their element type,
+// custom decoded values are not checked
+func (p *Parser[T]) UnknownKeys(data []byte) (map[string]int, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	targetType := reflect.TypeOf((*T)(nil))
+	if p.flexibleKeys {
+		normalizeKeys(&root, targetType)
+	}
+
+	unknown := make(map[string]int)
+	collectUnknownKeys(&root, targetType, "", unknown)
+	return unknown, nil
+}
+
+// collectUnknownKeys descends into the collections decoded by the type and records the keys of mappings
+// decoded as structs that have no matching field. Merge keys are resolved by the decoder and skipped
+func collectUnknownKeys(node *yaml.Node, t reflect.Type, path string, unknown map[string]int) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectUnknownKeys(child, t, path, unknown)
+		}
+		return
+	case yaml.AliasNode:
+		// The anchored node is checked where it is defined
+		return
+	}
+
+	if customDecoded(t) {
+		return
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, child := range node.Content {
+			collectUnknownKeys(child, t.Elem(), path+"["+strconv.Itoa(i)+"]", unknown)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			collectUnknownKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), unknown)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Tag == "!!merge" {
+				continue
+			}
+			if field, ok := fieldByYAMLName(t, key.Value); ok {
+				collectUnknownKeys(node.Content[i+1], field, joinPath(path, key.Value), unknown)
+			} else {
+				unknown[joinPath(path, key.Value)] = key.Line
+			}
+		}
+	}
+}
@@ -1,25 +1,68 @@
 package yaml
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Generator creates YAML templates from struct definitions
-type Generator[T any] struct{}
+type Generator[T any] struct {
+	deterministic bool
+}
 
 // NewGenerator creates a new YAML template generator
 func NewGenerator[T any]() *Generator[T] {
 	return &Generator[T]{}
 }
 
+// WithDeterministicOutput guarantees byte-stable output suitable for committing as a golden file:
+// fields in declaration order, two-space indentation at every level including list items,
+// no blank lines and a single trailing newline
+func (g *Generator[T]) WithDeterministicOutput() *Generator[T] {
+	g.deterministic = true
+	return g
+}
+
+// templateKey identifies a generated template, which only depends on the type and the output mode
+type templateKey struct {
+	t             reflect.Type
+	deterministic bool
+}
+
+// templates caches the generated templates by templateKey
+var templates sync.Map // templateKey -> []byte
+
 // GenerateTemplate creates a YAML template with comments showing default values and validation rules
 func (g *Generator[T]) GenerateTemplate() ([]byte, error) {
 	var target T
-	return g.generateFromStruct(reflect.TypeOf(target), 0)
+	key := templateKey{t: reflect.TypeOf(target), deterministic: g.deterministic}
+	if cached, ok := templates.Load(key); ok {
+		return bytes.Clone(cached.([]byte)), nil
+	}
+
+	data, err := g.generate(key.t)
+	if err != nil {
+		return nil, err
+	}
+	templates.Store(key, data)
+	return bytes.Clone(data), nil
+}
+
+// generate creates the template of the type in the generator's output mode
+func (g *Generator[T]) generate(t reflect.Type) ([]byte, error) {
+	if g.deterministic {
+		lines, err := g.generateLines(t, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	}
+	return g.generateFromStruct(t, 0)
 }
 
 // GenerateTemplateToFile creates a YAML template file with comments
@@ -29,7 +72,7 @@ func (g *Generator[T]) GenerateTemplateToFile(filename string) error {
 		return err
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := writeFileAtomic(filename, data, false, ""); err != nil {
 		return fmt.Errorf("failed to write template file: %w", err)
 	}
 
@@ -100,9 +143,7 @@ func (g *Generator[T]) generateFromStruct(t reflect.Type, indent int) ([]byte, e
 			}
 			lines = append(lines, string(nestedData))
 		} else {
-			// Generate example value
-			exampleValue := g.generateExampleValue(field)
-			lines = append(lines, indentStr+fieldName+": "+exampleValue)
+			lines = append(lines, g.valueLine(indentStr, fieldName, field))
 		}
 	}
 
@@ -166,14 +207,159 @@ func (g *Generator[T]) generateFromStructType(t reflect.Type, indent int) ([]byt
 			}
 			lines = append(lines, string(nestedData))
 		} else {
-			exampleValue := g.generateExampleValue(field)
-			lines = append(lines, indentStr+fieldName+": "+exampleValue)
+			lines = append(lines, g.valueLine(indentStr, fieldName, field))
 		}
 	}
 
 	return []byte(strings.Join(lines, "\n")), nil
 }
 
+// generateLines generates the template of a struct type line by line, indenting every line
+// including multi-line values consistently
+func (g *Generator[T]) generateLines(t reflect.Type, indent int) ([]string, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type must be a struct, got %s", t.Kind())
+	}
+
+	var lines []string
+	indentStr := strings.Repeat("  ", indent)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		fieldName, ok := g.fieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct {
+			fieldType = fieldType.Elem()
+		}
+
+		switch {
+		case fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}):
+			nested, err := g.generateLines(fieldType, indent+1)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) == 0 {
+				lines = append(lines, indentStr+fieldName+": {}")
+				continue
+			}
+			lines = append(lines, indentStr+fieldName+":")
+			lines = append(lines, nested...)
+		case fieldType.Kind() == reflect.Map && fieldType.Elem().Kind() == reflect.Struct:
+			nested, err := g.generateLines(fieldType.Elem(), indent+2)
+			if err != nil {
+				return nil, err
+			}
+			exampleKey := g.generateExampleKey(fieldType.Elem().Name())
+			lines = append(lines, indentStr+fieldName+":")
+			if len(nested) == 0 {
+				lines = append(lines, indentStr+"  "+exampleKey+": {}")
+				continue
+			}
+			lines = append(lines, indentStr+"  "+exampleKey+":")
+			lines = append(lines, nested...)
+		default:
+			exampleValue := g.generateExampleValue(field)
+			comment := g.validationComment(field)
+			if !strings.HasPrefix(exampleValue, "\n") {
+				lines = append(lines, indentStr+fieldName+": "+exampleValue+comment)
+				continue
+			}
+
+			// Multi-line values are block sequences, re-indent them under the key
+			lines = append(lines, indentStr+fieldName+":"+comment)
+			for _, item := range strings.Split(strings.TrimPrefix(exampleValue, "\n"), "\n") {
+				lines = append(lines, indentStr+"  "+strings.TrimSpace(item))
+			}
+		}
+	}
+
+	return lines, nil
+}
+
+// fieldName returns the YAML key of a field and whether the field is part of the template
+func (g *Generator[T]) fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	yamlTag := field.Tag.Get("yaml")
+	if yamlTag == "-" {
+		return "", false
+	}
+
+	if name, _, _ := strings.Cut(yamlTag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+// valueLine renders a scalar or sequence field with its example value and validation comment
+func (g *Generator[T]) valueLine(indentStr, fieldName string, field reflect.StructField) string {
+	exampleValue := g.generateExampleValue(field)
+	comment := g.validationComment(field)
+	if comment != "" && strings.HasPrefix(exampleValue, "\n") {
+		return indentStr + fieldName + ":" + comment + exampleValue
+	}
+	return indentStr + fieldName + ": " + exampleValue + comment
+}
+
+// validationComment describes secret fields and the allowed values and ranges of a field's validate tag
+// as a trailing comment, or returns an empty string when there are none
+func (g *Generator[T]) validationComment(field reflect.StructField) string {
+	rules := validationRules(field)
+
+	var parts []string
+	if isSecret(field) {
+		parts = append(parts, "secret, not written by SaveToFile")
+	}
+	if oneof, ok := rules["oneof"]; ok {
+		parts = append(parts, "one of: "+strings.Join(strings.Fields(oneof), ", "))
+	}
+
+	var bounds []string
+	if minimum, ok := rules["min"]; ok {
+		bounds = append(bounds, "min: "+minimum)
+	}
+	if maximum, ok := rules["max"]; ok {
+		bounds = append(bounds, "max: "+maximum)
+	}
+	if len(bounds) > 0 {
+		// min and max bound the length of strings, slices and maps
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+			parts = append(parts, "length "+strings.Join(bounds, ", "))
+		default:
+			parts = append(parts, strings.Join(bounds, ", "))
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return " # " + strings.Join(parts, "; ")
+}
+
+// validationRules parses the validate tag into rule names and parameters
+func validationRules(field reflect.StructField) map[string]string {
+	rules := make(map[string]string)
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name != "" {
+			rules[name] = param
+		}
+	}
+	return rules
+}
+
 // generateFieldComment creates a comment describing the field
 func (g *Generator[T]) generateFieldComment(field reflect.StructField) string {
 	var parts []string
@@ -214,15 +400,79 @@ func (g *Generator[T]) generateExampleKey(structTypeName string) string {
 
 // generateExampleValue creates an example value for a field
 func (g *Generator[T]) generateExampleValue(field reflect.StructField) string {
+	rules := validationRules(field)
+
+	// Never pre-fill secrets, their defaults are credentials too
+	if isSecret(field) {
+		if _, required := rules["required"]; required && field.Type.Kind() == reflect.String {
+			return requiredPlaceholder
+		}
+		return g.generateTypeExample(field.Type)
+	}
+
 	// Use default value if available
 	if defaultValue := field.Tag.Get("default"); defaultValue != "" {
 		return g.formatExampleValue(field.Type, defaultValue)
 	}
 
+	// Derive the example from the validation rules, so it passes validation or is clearly not a real value
+	if example, ok := constraintExample(field.Type, rules); ok {
+		return example
+	}
+
 	// Generate type-appropriate example
 	return g.generateTypeExample(field.Type)
 }
 
+// requiredPlaceholder is the example of required strings without a default or allowed values,
+// instead of a value that looks real
+const requiredPlaceholder = `"<REQUIRED>"`
+
+// constraintExample derives an example from the validate rules: the first allowed value, the lower
+// bound of numbers and durations, or a placeholder for required strings
+func constraintExample(fieldType reflect.Type, rules map[string]string) (string, bool) {
+	if values := strings.Fields(rules["oneof"]); len(values) > 0 {
+		if fieldType.Kind() == reflect.String {
+			return fmt.Sprintf(`"%s"`, values[0]), true
+		}
+		return values[0], true
+	}
+
+	if fieldType == reflect.TypeOf(time.Duration(0)) {
+		for _, rule := range []string{"duration_min", "min", "gte"} {
+			if minimum, ok := rules[rule]; ok {
+				return fmt.Sprintf(`"%s"`, minimum), true
+			}
+		}
+		return "", false
+	}
+
+	if isNumeric(fieldType) {
+		for _, rule := range []string{"min", "gte"} {
+			if minimum, ok := rules[rule]; ok {
+				return minimum, true
+			}
+		}
+		// The smallest integer above an exclusive bound
+		if bound, ok := rules["gt"]; ok {
+			if n, err := strconv.ParseInt(bound, 10, 64); err == nil && !isFloat(fieldType) {
+				return strconv.FormatInt(n+1, 10), true
+			}
+		}
+		return "", false
+	}
+
+	if _, required := rules["required"]; required && fieldType.Kind() == reflect.String {
+		return requiredPlaceholder, true
+	}
+	return "", false
+}
+
+// isFloat reports whether the type is a floating point number
+func isFloat(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64
+}
+
 // formatExampleValue formats a default value appropriately for YAML
 func (g *Generator[T]) formatExampleValue(fieldType reflect.Type, value string) string {
 	switch fieldType.Kind() {
@@ -277,6 +527,22 @@ func (g *Generator[T]) generateTypeExample(fieldType reflect.Type) string {
 	}
 }
 
+// isNumeric reports whether the type is an integer or float, excluding durations
+func isNumeric(fieldType reflect.Type) bool {
+	if fieldType == reflect.TypeOf(time.Duration(0)) {
+		return false
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
 // getTypeDescription returns a human-readable type description
 func (g *Generator[T]) getTypeDescription(fieldType reflect.Type) string {
 	if fieldType == reflect.TypeOf(time.Duration(0)) {
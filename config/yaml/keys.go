@@ -0,0 +1,99 @@
+package yaml
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// normalizeKeys renames mapping keys that match a struct field only when case, dashes and underscores
+// are ignored to the field's YAML name, so serverPort, server-port and server_port all decode into server_port
+func normalizeKeys(node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			normalizeKeys(child, t)
+		}
+		return
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			normalizeKeys(node.Alias, t)
+		}
+		return
+	}
+
+	if customDecoded(t) {
+		return
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, child := range node.Content {
+			normalizeKeys(child, t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			normalizeKeys(node.Content[i], t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := fieldByYAMLName(t, key.Value)
+			if !ok {
+				var name string
+				if name, field, ok = fieldByNormalizedName(t, normalizeKey(key.Value)); ok {
+					key.Value = name
+				}
+			}
+			if ok {
+				normalizeKeys(node.Content[i+1], field)
+			}
+		}
+	}
+}
+
+// fieldByNormalizedName returns the YAML name and type of the field whose normalized name matches,
+// following inline structs
+func fieldByNormalizedName(t reflect.Type, normalized string) (string, reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			inline := field.Type
+			if inline.Kind() == reflect.Ptr {
+				inline = inline.Elem()
+			}
+			if inline.Kind() == reflect.Struct {
+				if found, fieldType, ok := fieldByNormalizedName(inline, normalized); ok {
+					return found, fieldType, true
+				}
+			}
+			continue
+		}
+
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if normalizeKey(name) == normalized {
+			return name, field.Type, true
+		}
+	}
+	return "", nil, false
+}
+
+// normalizeKey lowercases the key and drops dashes and underscores
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+}
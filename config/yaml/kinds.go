@@ -0,0 +1,376 @@
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KindKey is the mapping key selecting the concrete type of an interface-typed field
+const KindKey = "kind"
+
+// ErrUnknownKind is returned when the mapping of an interface-typed field has no kind,
+// or a kind that is not registered for the interface
+var ErrUnknownKind = errors.New("unknown kind")
+
+// kinds holds the concrete types registered with RegisterKind by kind name. A name may be
+// registered for several types, as long as they implement different interfaces
+var kinds struct {
+	mu    sync.RWMutex
+	types map[string][]reflect.Type
+}
+
+// RegisterKind registers T as the type decoded for interface-typed fields whose mapping has
+// kind: name, for every interface T or *T implements. Register kinds before loading, e.g. in init
+func RegisterKind[T any](name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	kinds.mu.Lock()
+	defer kinds.mu.Unlock()
+
+	if kinds.types == nil {
+		kinds.types = make(map[string][]reflect.Type)
+	}
+	for _, existing := range kinds.types[name] {
+		if existing == t {
+			return
+		}
+	}
+	kinds.types[name] = append(kinds.types[name], t)
+}
+
+// isKinded reports whether values of the type are decoded by kind, i.e. it is a non-empty interface
+func isKinded(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() > 0
+}
+
+// implementsKind reports whether the registered type, or a pointer to it, implements the interface
+func implementsKind(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// kindType returns the type registered for the kind name that implements the interface
+func kindType(iface reflect.Type, name string) (reflect.Type, error) {
+	kinds.mu.RLock()
+	defer kinds.mu.RUnlock()
+
+	for _, t := range kinds.types[name] {
+		if implementsKind(t, iface) {
+			return t, nil
+		}
+	}
+
+	var registered []string
+	for kind, types := range kinds.types {
+		for _, t := range types {
+			if implementsKind(t, iface) {
+				registered = append(registered, kind)
+				break
+			}
+		}
+	}
+	sort.Strings(registered)
+	return nil, fmt.Errorf("%w '%s' for %s, registered: %s", ErrUnknownKind, name, iface, strings.Join(registered, ", "))
+}
+
+// kindName returns the kind name the concrete type of a value stored in the interface is registered as
+func kindName(iface, concrete reflect.Type) (string, bool) {
+	if concrete.Kind() == reflect.Ptr {
+		concrete = concrete.Elem()
+	}
+
+	kinds.mu.RLock()
+	defer kinds.mu.RUnlock()
+
+	for name, types := range kinds.types {
+		for _, t := range types {
+			if t == concrete && implementsKind(t, iface) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// decodeKinds decodes the node into out, decoding interface-typed fields into the types registered for
+// their kind. The decoder can't set interface values it did not create, so these nodes are swapped for
+// nulls before decoding and decoded into their concrete type afterwards. path is the dotted path of node
+func decodeKinds(node *yaml.Node, out reflect.Value, path string) error {
+	detached := make(map[*yaml.Node]*yaml.Node)
+	detachKinds(node, out.Type(), detached)
+
+	if err := node.Decode(out.Interface()); err != nil {
+		return err
+	}
+	if len(detached) == 0 {
+		return nil
+	}
+	return fillKinds(node, out, path, detached)
+}
+
+// detachKinds replaces the nodes decoded into interface-typed fields with nulls, keeping a copy
+// of each original node by the placeholder
+func detachKinds(node *yaml.Node, t reflect.Type, detached map[*yaml.Node]*yaml.Node) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, ok := detached[node]; ok {
+		// An anchored node reached again through an alias
+		return
+	}
+
+	if isKinded(t) {
+		original := *node
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			// The anchored node may already be swapped for a placeholder
+			original = *node.Alias
+			if anchored, ok := detached[node.Alias]; ok {
+				original = *anchored
+			}
+		}
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null", Line: node.Line, Column: node.Column}
+		detached[node] = &original
+		return
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			detachKinds(child, t, detached)
+		}
+		return
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			detachKinds(node.Alias, t, detached)
+		}
+		return
+	}
+
+	if customDecoded(t) {
+		return
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, child := range node.Content {
+			detachKinds(child, t.Elem(), detached)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			detachKinds(node.Content[i+1], t.Elem(), detached)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if field, ok := fieldByYAMLName(t, node.Content[i].Value); ok {
+				detachKinds(node.Content[i+1], field, detached)
+			}
+		}
+	}
+}
+
+// fillKinds walks the decoded value alongside the node tree and decodes the detached nodes
+// into the interface-typed values they belong to
+func fillKinds(node *yaml.Node, v reflect.Value, path string, detached map[*yaml.Node]*yaml.Node) error {
+	if original, ok := detached[node]; ok {
+		return decodeKind(original, v, path)
+	}
+	return eachChild(node, v, path, func(child *yaml.Node, v reflect.Value, path string) error {
+		return fillKinds(child, v, path, detached)
+	})
+}
+
+// decodeKind decodes the mapping into a new value of the type registered for its kind and stores it
+// in the interface value v. Null nodes leave the interface nil
+func decodeKind(node *yaml.Node, v reflect.Value, path string) error {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s (line %d): %w: expected a mapping with a '%s' key for %s", path, node.Line, ErrUnknownKind, KindKey, v.Type())
+	}
+
+	var name string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == KindKey {
+			name = node.Content[i+1].Value
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("%s (line %d): %w: missing '%s' key for %s", path, node.Line, ErrUnknownKind, KindKey, v.Type())
+	}
+
+	t, err := kindType(v.Type(), name)
+	if err != nil {
+		return fmt.Errorf("%s (line %d): %w", path, node.Line, err)
+	}
+
+	// The kind key is dropped unless the concrete type has a field for it
+	body := *node
+	if t.Kind() != reflect.Struct || !hasKindField(t) {
+		body.Content = make([]*yaml.Node, 0, len(node.Content))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != KindKey {
+				body.Content = append(body.Content, node.Content[i], node.Content[i+1])
+			}
+		}
+	}
+
+	target := reflect.New(t)
+	if err := decodeKinds(&body, target, path); err != nil {
+		return err
+	}
+	if t.Implements(v.Type()) {
+		v.Set(target.Elem())
+	} else {
+		v.Set(target)
+	}
+	return nil
+}
+
+// hasKindField reports whether the struct decodes the kind key into one of its fields
+func hasKindField(t reflect.Type) bool {
+	_, ok := structFieldByYAMLName(t, KindKey)
+	return ok
+}
+
+// addKinds adds the kind key to the encoded mappings of interface-typed values whose concrete type is registered
+func addKinds(node *yaml.Node, v reflect.Value) {
+	eachChild(node, v, "", func(child *yaml.Node, v reflect.Value, _ string) error {
+		if !isKinded(v.Type()) {
+			addKinds(child, v)
+			return nil
+		}
+		if v.IsNil() || child.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		concrete := v.Elem()
+		addKinds(child, concrete)
+		name, ok := kindName(v.Type(), concrete.Type())
+		if !ok {
+			return nil
+		}
+		for i := 0; i+1 < len(child.Content); i += 2 {
+			if child.Content[i].Value == KindKey {
+				return nil
+			}
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: KindKey}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+		child.Content = append([]*yaml.Node{key, value}, child.Content...)
+		return nil
+	})
+}
+
+// eachChild calls fn with the child nodes of a mapping or sequence and the values they were decoded into.
+// Map entries are not addressable, so fn gets a copy that is stored back afterwards
+func eachChild(node *yaml.Node, v reflect.Value, path string, fn func(child *yaml.Node, v reflect.Value, path string) error) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := fn(child, v, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			return eachChild(node.Alias, v, path, fn)
+		}
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if customDecoded(v.Type()) {
+		return nil
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+		for i, child := range node.Content {
+			if i >= v.Len() {
+				break
+			}
+			if err := fn(child, v.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case node.Kind == yaml.MappingNode && v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := reflect.ValueOf(node.Content[i].Value).Convert(v.Type().Key())
+			elem := v.MapIndex(key)
+			if !elem.IsValid() {
+				continue
+			}
+			entry := reflect.New(v.Type().Elem()).Elem()
+			entry.Set(elem)
+			if err := fn(node.Content[i+1], entry, joinPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, entry)
+		}
+	case node.Kind == yaml.MappingNode && v.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if field, ok := fieldValueByYAMLName(v, key); ok {
+				if err := fn(node.Content[i+1], field, joinPath(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fieldValueByYAMLName returns the struct field decoded from the key, following inline structs
+func fieldValueByYAMLName(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			inline := v.Field(i)
+			if inline.Kind() == reflect.Ptr {
+				if inline.IsNil() {
+					continue
+				}
+				inline = inline.Elem()
+			}
+			if inline.Kind() == reflect.Struct {
+				if found, ok := fieldValueByYAMLName(inline, key); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if name == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -3,16 +3,54 @@ package yaml
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Parser handles YAML parsing operations
-type Parser[T any] struct{}
+type Parser[T any] struct {
+	disallowAnchors   bool
+	maxAliasExpansion int
+	durationUnit      time.Duration
+	lenientTypes      bool
+	flexibleKeys      bool
+	syncWrites        bool
+	backupSuffix      string
+}
 
 // NewParser creates a new YAML parser for the specified type
 func NewParser[T any]() *Parser[T] {
-	return &Parser[T]{}
+	return &Parser[T]{maxAliasExpansion: DefaultMaxAliasExpansion}
+}
+
+// WithDisallowAnchors rejects documents using anchors or aliases,
+// for security-sensitive loads of user-provided configuration
+func (p *Parser[T]) WithDisallowAnchors() *Parser[T] {
+	p.disallowAnchors = true
+	return p
+}
+
+// WithMaxAliasExpansion sets the maximum number of nodes a document using aliases may expand to.
+// A limit of zero or less disables the check
+func (p *Parser[T]) WithMaxAliasExpansion(limit int) *Parser[T] {
+	p.maxAliasExpansion = limit
+	return p
+}
+
+// WithSync makes WriteFile flush the file and its directory to disk before returning,
+// so a written file survives a power loss
+func (p *Parser[T]) WithSync() *Parser[T] {
+	p.syncWrites = true
+	return p
+}
+
+// WithBackup makes WriteFile keep the previous version of an existing file,
+// named after the file with the suffix appended, e.g. ".bak"
+func (p *Parser[T]) WithBackup(suffix string) *Parser[T] {
+	p.backupSuffix = suffix
+	return p
 }
 
 // ParseFile reads and parses a YAML file into the target struct
@@ -25,31 +63,90 @@ func (p *Parser[T]) ParseFile(filename string, target *T) error {
 	return p.Parse(data, target)
 }
 
-// Parse parses YAML data into the target struct
+// WithDurationUnit interprets bare integers decoded into time.Duration fields in the given unit,
+// so "timeout: 30" means 30 seconds with time.Second. Duration strings like "30s" keep working
+func (p *Parser[T]) WithDurationUnit(unit time.Duration) *Parser[T] {
+	p.durationUnit = unit
+	return p
+}
+
+// WithLenientTypes performs safe conversions of quoted scalars, such as "8080" into an int field,
+// instead of reporting them as type mismatches
+func (p *Parser[T]) WithLenientTypes() *Parser[T] {
+	p.lenientTypes = true
+	return p
+}
+
+// WithFlexibleKeys matches mapping keys to fields ignoring case, dashes and underscores,
+// so serverPort, server-port and server_port all map to the same field
+func (p *Parser[T]) WithFlexibleKeys() *Parser[T] {
+	p.flexibleKeys = true
+	return p
+}
+
+// Parse parses YAML data into the target struct.
+// Values that do not match their field types are reported with a *CoercionError
 func (p *Parser[T]) Parse(data []byte, target *T) error {
-	if err := yaml.Unmarshal(data, target); err != nil {
+	// Decode through a node tree so values can be checked and rewritten against the target type first
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if err := checkAliasNodes(&root, p.disallowAnchors, p.maxAliasExpansion); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		return nil
+	}
+
+	targetType := reflect.TypeOf(target)
+	if p.flexibleKeys {
+		normalizeKeys(&root, targetType)
+	}
+	if p.durationUnit > 0 {
+		applyDurationUnit(&root, targetType, p.durationUnit)
+	}
+	if err := checkTypes(&root, targetType, p.lenientTypes); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	if err := decodeKinds(&root, reflect.ValueOf(target), ""); err != nil {
 		return fmt.Errorf("failed to parse YAML: %w", err)
 	}
 	return nil
 }
 
-// Marshal converts a struct to YAML bytes
+// Check verifies the anchor and alias limits of YAML data without expanding it
+func (p *Parser[T]) Check(data []byte) error {
+	return checkAliases(data, p.disallowAnchors, p.maxAliasExpansion)
+}
+
+// Marshal converts a struct to YAML bytes. Fields tagged `secret:"true"` are omitted,
+// so dumping the current configuration can't persist credentials
 func (p *Parser[T]) Marshal(source *T) ([]byte, error) {
-	data, err := yaml.Marshal(source)
+	var root yaml.Node
+	if err := root.Encode(source); err != nil {
+		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	omitSecrets(&root, reflect.TypeOf(source))
+	addKinds(&root, reflect.ValueOf(source))
+
+	data, err := yaml.Marshal(&root)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
 	}
 	return data, nil
 }
 
-// WriteFile writes a struct to a YAML file
+// WriteFile writes a struct to a YAML file. The file is replaced atomically, so a crash leaves
+// either the previous or the new version, and an existing file keeps its mode
 func (p *Parser[T]) WriteFile(filename string, source *T) error {
 	data, err := p.Marshal(source)
 	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := writeFileAtomic(filename, data, p.syncWrites, p.backupSuffix); err != nil {
 		return fmt.Errorf("failed to write YAML file: %w", err)
 	}
 
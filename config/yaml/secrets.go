@@ -0,0 +1,60 @@
+package yaml
+
+import (
+	"reflect"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretTag marks fields holding credentials, e.g. `secret:"true"`. Secret fields are never
+// written by Marshal and never pre-filled in generated templates
+const secretTag = "secret"
+
+// isSecret reports whether the field is tagged as secret
+func isSecret(field reflect.StructField) bool {
+	secret, _ := strconv.ParseBool(field.Tag.Get(secretTag))
+	return secret
+}
+
+// omitSecrets removes the secret fields from a node tree encoded from a value of the type
+func omitSecrets(node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			omitSecrets(child, t)
+		}
+		return
+	}
+
+	if customDecoded(t) {
+		return
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, child := range node.Content {
+			omitSecrets(child, t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			omitSecrets(node.Content[i], t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			field, ok := structFieldByYAMLName(t, node.Content[i].Value)
+			if ok && isSecret(field) {
+				continue
+			}
+			if ok {
+				omitSecrets(node.Content[i+1], field.Type)
+			}
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+		node.Content = content
+	}
+}
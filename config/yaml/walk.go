@@ -0,0 +1,144 @@
+package yaml
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	unmarshalerType     = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// visitFunc is called for every node the target type decodes itself, with the node's field path
+type visitFunc func(node *yaml.Node, t reflect.Type, path string)
+
+// walkTyped walks the node tree alongside the target type. Scalars, and collections whose shape
+// does not match the type, are passed to visit. Types with custom unmarshaling are not descended into
+func walkTyped(node *yaml.Node, t reflect.Type, path string, visit visitFunc) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkTyped(child, t, path, visit)
+		}
+		return
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			walkTyped(node.Alias, t, path, visit)
+		}
+		return
+	}
+
+	if customDecoded(t) {
+		return
+	}
+
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, child := range node.Content {
+			walkTyped(child, t.Elem(), path+"["+strconv.Itoa(i)+"]", visit)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkTyped(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), visit)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if field, ok := fieldByYAMLName(t, key); ok {
+				walkTyped(node.Content[i+1], field, joinPath(path, key), visit)
+			}
+		}
+	default:
+		visit(node, t, path)
+	}
+}
+
+// customDecoded reports whether values of the type are decoded by custom logic
+func customDecoded(t reflect.Type) bool {
+	if t == timeType || t.Kind() == reflect.Interface {
+		return true
+	}
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(unmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// joinPath appends a key to a dotted field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// fieldByYAMLName returns the type of the struct field decoded from the key, following inline structs
+func fieldByYAMLName(t reflect.Type, key string) (reflect.Type, bool) {
+	field, ok := structFieldByYAMLName(t, key)
+	return field.Type, ok
+}
+
+// yamlFields caches the fields of each struct type by YAML name, see structFieldByYAMLName
+var yamlFields sync.Map // reflect.Type -> map[string]reflect.StructField
+
+// structFieldByYAMLName returns the struct field decoded from the key, following inline structs
+func structFieldByYAMLName(t reflect.Type, key string) (reflect.StructField, bool) {
+	field, ok := yamlFieldsOf(t)[key]
+	return field, ok
+}
+
+// yamlFieldsOf returns the exported fields of the struct type by YAML name, with the fields of inline
+// structs flattened in. When names collide the first field in declaration order wins
+func yamlFieldsOf(t reflect.Type) map[string]reflect.StructField {
+	if cached, ok := yamlFields.Load(t); ok {
+		return cached.(map[string]reflect.StructField)
+	}
+
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			inline := field.Type
+			if inline.Kind() == reflect.Ptr {
+				inline = inline.Elem()
+			}
+			if inline.Kind() == reflect.Struct {
+				for key, found := range yamlFieldsOf(inline) {
+					if _, ok := fields[key]; !ok {
+						fields[key] = found
+					}
+				}
+			}
+			continue
+		}
+
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = field
+		}
+	}
+
+	cached, _ := yamlFields.LoadOrStore(t, fields)
+	return cached.(map[string]reflect.StructField)
+}
@@ -0,0 +1,93 @@
+package yaml
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// writeFileAtomic writes data to a temporary file next to filename and renames it over filename, so
+// readers and crashes never see a partially written file. An existing file keeps its mode and symlinks
+// are written through; new files are created with mode 0644. With sync the file and its directory are
+// flushed to disk before returning. With a backup suffix the previous contents are kept in a file named
+// after filename with the suffix appended
+func writeFileAtomic(filename string, data []byte, sync bool, backupSuffix string) error {
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+
+	mode := fs.FileMode(0644)
+	previous, err := os.Stat(filename)
+	switch {
+	case err == nil:
+		mode = previous.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if backupSuffix != "" && previous != nil {
+		old, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filename+backupSuffix, old, sync, ""); err != nil {
+			return err
+		}
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	renamed := false
+	defer func() {
+		if !renamed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if sync {
+		if err := tmp.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	renamed = true
+
+	if sync {
+		return syncDir(dir)
+	}
+	return nil
+}
+
+// syncDir flushes a directory entry change, such as a rename, to disk. Windows can't sync directories,
+// renames there are durable once the file was synced
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -1,7 +1,9 @@
 package yaml
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -176,6 +178,46 @@ func TestParser_WriteFile(t *testing.T) {
 	}
 }
 
+func TestParser_WriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("string_field: previous\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	link := filepath.Join(dir, "current.yaml")
+	if err := os.Symlink(file, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	parser := NewParser[TestConfig]().WithSync().WithBackup(".bak")
+	if err := parser.WriteFile(link, &TestConfig{StringField: "saved", IntField: 1}); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var saved TestConfig
+	if err := parser.ParseFile(file, &saved); err != nil || saved.StringField != "saved" {
+		t.Errorf("Expected the symlink target to be replaced, got %+v, %v", saved, err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the symlink to be kept, got %v, %v", info, err)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode 0600 to be kept, got %v, %v", info, err)
+	}
+	if backup, err := os.ReadFile(file + ".bak"); err != nil || string(backup) != "string_field: previous\n" {
+		t.Errorf("Expected the previous version in the backup, got %q, %v", backup, err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("Expected no temporary files to be left, got %v", entries)
+	}
+
+	if err := parser.WriteFile(filepath.Join(dir, "missing", "config.yaml"), &saved); err == nil {
+		t.Error("Expected an error writing to a missing directory")
+	}
+}
+
 func TestParser_FileExists(t *testing.T) {
 	parser := NewParser[TestConfig]()
 
@@ -330,3 +372,391 @@ func TestGeneratorConvenienceFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestGenerator_WithDeterministicOutput(t *testing.T) {
+	first, err := NewGenerator[TestConfig]().WithDeterministicOutput().GenerateTemplate()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+
+	second, err := NewGenerator[TestConfig]().WithDeterministicOutput().GenerateTemplate()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+
+	if string(first) != string(second) {
+		t.Error("Deterministic output should be identical across runs")
+	}
+
+	expected := `string_field: "default_string"
+int_field: 42 # min: 1
+bool_field: true
+duration_field: 5m
+slice_field:
+  - "item1"
+  - "item2"
+  - "item3"
+nested:
+  nested_string: "nested_default"
+  nested_int: 100 # min: 50
+`
+	if string(first) != expected {
+		t.Errorf("Unexpected template:\n%s\nexpected:\n%s", first, expected)
+	}
+
+	// The golden output must be valid YAML that round-trips into the struct
+	var config TestConfig
+	if err := Parse(first, &config); err != nil {
+		t.Fatalf("Generated template should parse: %v", err)
+	}
+	if len(config.SliceField) != 3 || config.NestedField.NestedInt != 100 {
+		t.Errorf("Unexpected parsed template: %+v", config)
+	}
+}
+
+func TestGenerator_ValidationComments(t *testing.T) {
+	type ServerConfig struct {
+		Level string   `yaml:"level" validate:"required,oneof=debug info warn"`
+		Port  int      `yaml:"port" validate:"min=1,max=65535"`
+		Name  string   `yaml:"name" default:"api" validate:"min=2,max=20"`
+		Tags  []string `yaml:"tags" validate:"max=3"`
+	}
+
+	template, err := NewGenerator[ServerConfig]().WithDeterministicOutput().GenerateTemplate()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+
+	expected := `level: "debug" # one of: debug, info, warn
+port: 1 # min: 1, max: 65535
+name: "api" # length min: 2, max: 20
+tags: # length max: 3
+  - "item1"
+  - "item2"
+`
+	if string(template) != expected {
+		t.Errorf("Unexpected template:\n%s\nexpected:\n%s", template, expected)
+	}
+
+	var config ServerConfig
+	if err := Parse(template, &config); err != nil {
+		t.Fatalf("Generated template should parse: %v", err)
+	}
+
+	legacy, err := NewGenerator[ServerConfig]().GenerateTemplate()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+	if !strings.Contains(string(legacy), "tags: # length max: 3\n  - ") {
+		t.Errorf("Expected comment before sequence items, got:\n%s", legacy)
+	}
+}
+
+func TestGenerator_ConstraintExamples(t *testing.T) {
+	type ServiceConfig struct {
+		Endpoint string        `yaml:"endpoint" validate:"required,url"`
+		Token    string        `yaml:"token" secret:"true" validate:"required"`
+		Workers  int           `yaml:"workers" validate:"gt=0"`
+		Ratio    float64       `yaml:"ratio" validate:"gte=0.5"`
+		Interval time.Duration `yaml:"interval" validate:"duration_min=5s"`
+		Mode     string        `yaml:"mode" validate:"required,oneof=fast safe"`
+	}
+
+	template, err := NewGenerator[ServiceConfig]().WithDeterministicOutput().GenerateTemplate()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+
+	expected := `endpoint: "<REQUIRED>"
+token: "<REQUIRED>" # secret, not written by SaveToFile
+workers: 1
+ratio: 0.5
+interval: "5s"
+mode: "fast" # one of: fast, safe
+`
+	if string(template) != expected {
+		t.Errorf("Unexpected template:\n%s\nexpected:\n%s", template, expected)
+	}
+}
+
+func TestParser_AliasLimits(t *testing.T) {
+	anchored := []byte(`
+nested: &nested
+  nested_string: shared
+  nested_int: 60
+string_field: test
+`)
+
+	t.Run("normal anchors are expanded", func(t *testing.T) {
+		var config TestConfig
+		if err := NewParser[TestConfig]().Parse(anchored, &config); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if config.NestedField.NestedString != "shared" {
+			t.Errorf("Expected nested_string 'shared', got '%s'", config.NestedField.NestedString)
+		}
+	})
+
+	t.Run("excessive aliasing is rejected", func(t *testing.T) {
+		laughs := []byte(`
+a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+string_field: [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+`)
+		var config TestConfig
+		err := NewParser[TestConfig]().Parse(laughs, &config)
+		if !errors.Is(err, ErrExcessiveAliasing) {
+			t.Errorf("Expected ErrExcessiveAliasing, got %v", err)
+		}
+	})
+
+	t.Run("anchors disallowed", func(t *testing.T) {
+		var config TestConfig
+		err := NewParser[TestConfig]().WithDisallowAnchors().Parse(anchored, &config)
+		if !errors.Is(err, ErrAnchorsDisallowed) {
+			t.Errorf("Expected ErrAnchorsDisallowed, got %v", err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Expected error to report the line, got %v", err)
+		}
+	})
+}
+
+func TestParser_WithDurationUnit(t *testing.T) {
+	type durations struct {
+		Timeout  time.Duration            `yaml:"timeout"`
+		Interval time.Duration            `yaml:"interval"`
+		Retries  int                      `yaml:"retries"`
+		Backoff  []time.Duration          `yaml:"backoff"`
+		PerHost  map[string]time.Duration `yaml:"per_host"`
+	}
+
+	data := []byte(`
+timeout: 30
+interval: 1m
+retries: 3
+backoff: [1, 2, 5s]
+per_host:
+  example.com: 10
+`)
+
+	var config durations
+	if err := NewParser[durations]().WithDurationUnit(time.Second).Parse(data, &config); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if config.Timeout != 30*time.Second {
+		t.Errorf("Expected timeout 30s, got %v", config.Timeout)
+	}
+	if config.Interval != time.Minute {
+		t.Errorf("Expected interval 1m, got %v", config.Interval)
+	}
+	if config.Retries != 3 {
+		t.Errorf("Expected retries 3, got %d", config.Retries)
+	}
+	if len(config.Backoff) != 3 || config.Backoff[0] != time.Second || config.Backoff[2] != 5*time.Second {
+		t.Errorf("Expected backoff [1s 2s 5s], got %v", config.Backoff)
+	}
+	if config.PerHost["example.com"] != 10*time.Second {
+		t.Errorf("Expected per host 10s, got %v", config.PerHost["example.com"])
+	}
+
+	if err := NewParser[durations]().Parse([]byte("timeout: 30"), &config); err == nil {
+		t.Error("Expected bare integer duration to fail without a unit")
+	}
+}
+
+func TestParser_TypeMismatchReport(t *testing.T) {
+	data := []byte(`
+string_field: test
+int_field: "8080"
+bool_field: "yes please"
+nested:
+  nested_int: [1, 2]
+`)
+
+	var config TestConfig
+	err := NewParser[TestConfig]().Parse(data, &config)
+
+	var coercionErr *CoercionError
+	if !errors.As(err, &coercionErr) {
+		t.Fatalf("Expected CoercionError, got %v", err)
+	}
+	if len(coercionErr.Mismatches) != 3 {
+		t.Fatalf("Expected 3 mismatches, got %v", coercionErr.Mismatches)
+	}
+
+	port := coercionErr.Mismatches[0]
+	if port.Path != "int_field" || port.Line != 3 || port.YAMLType != "!!str" || port.GoType != "int" || !port.Coercible {
+		t.Errorf("Unexpected int_field mismatch: %+v", port)
+	}
+	if coercionErr.Mismatches[1].Coercible {
+		t.Errorf("Expected bool_field mismatch not to be coercible: %+v", coercionErr.Mismatches[1])
+	}
+	if nested := coercionErr.Mismatches[2]; nested.Path != "nested.nested_int" || nested.YAMLType != "sequence" {
+		t.Errorf("Unexpected nested mismatch: %+v", nested)
+	}
+
+	lenient := []byte(`
+int_field: "8080"
+bool_field: "false"
+duration_field: 5s
+`)
+	if err := NewParser[TestConfig]().WithLenientTypes().Parse(lenient, &config); err != nil {
+		t.Fatalf("Expected lenient parse to succeed, got %v", err)
+	}
+	if config.IntField != 8080 || config.BoolField {
+		t.Errorf("Expected coerced values 8080 and false, got %d and %v", config.IntField, config.BoolField)
+	}
+}
+
+func TestParser_WithFlexibleKeys(t *testing.T) {
+	data := []byte(`
+String-Field: flexible
+intField: 7
+nested:
+  NESTED_STRING: inner
+`)
+
+	var config TestConfig
+	if err := NewParser[TestConfig]().WithFlexibleKeys().Parse(data, &config); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if config.StringField != "flexible" || config.IntField != 7 || config.NestedField.NestedString != "inner" {
+		t.Errorf("Expected flexible keys to map to fields, got %+v", config)
+	}
+
+	config = TestConfig{}
+	if err := NewParser[TestConfig]().Parse(data, &config); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if config.StringField != "" {
+		t.Error("Expected keys to match exactly without flexible keys")
+	}
+}
+
+func TestMarshal_OmitsSecrets(t *testing.T) {
+	type Database struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password" default:"changeme" secret:"true"`
+	}
+	type SecretConfig struct {
+		Database Database            `yaml:"database"`
+		Replicas map[string]Database `yaml:"replicas"`
+		APIKey   string              `yaml:"api_key" secret:"true"`
+	}
+
+	source := SecretConfig{
+		Database: Database{Host: "db", Password: "hunter2"},
+		Replicas: map[string]Database{"eu": {Host: "db-eu", Password: "hunter3"}},
+		APIKey:   "sk-live",
+	}
+
+	data, err := Marshal(&source)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "hunter3", "sk-live", "password", "api_key"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be omitted, got:\n%s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "db-eu") {
+		t.Errorf("Expected non-secret fields to be kept, got:\n%s", data)
+	}
+
+	template, err := NewGenerator[SecretConfig]().WithDeterministicOutput().GenerateTemplate()
+	if err != nil {
+		t.Fatalf("GenerateTemplate failed: %v", err)
+	}
+	if strings.Contains(string(template), "changeme") || !strings.Contains(string(template), "password: \"example_value\" # secret") {
+		t.Errorf("Expected secret default to be left out of the template, got:\n%s", template)
+	}
+}
+
+type testSink interface {
+	Target() string
+}
+
+type testS3Sink struct {
+	Bucket string `yaml:"bucket"`
+}
+
+func (s testS3Sink) Target() string { return "s3://" + s.Bucket }
+
+type testFileSink struct {
+	Path     string   `yaml:"path"`
+	Fallback testSink `yaml:"fallback"`
+}
+
+func (s *testFileSink) Target() string { return "file://" + s.Path }
+
+func TestParser_Kinds(t *testing.T) {
+	RegisterKind[testS3Sink]("s3")
+	RegisterKind[testFileSink]("file")
+
+	type KindConfig struct {
+		Primary testSink            `yaml:"primary"`
+		Sinks   []testSink          `yaml:"sinks"`
+		Named   map[string]testSink `yaml:"named"`
+	}
+
+	data := []byte(`
+primary:
+  kind: s3
+  bucket: logs
+sinks:
+  - kind: file
+    path: /var/log/app.log
+    fallback:
+      kind: s3
+      bucket: backup
+  - &archive
+    kind: s3
+    bucket: archive
+named:
+  archive: *archive
+`)
+	var cfg KindConfig
+	if err := Parse(data, &cfg); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if cfg.Primary == nil || cfg.Primary.Target() != "s3://logs" {
+		t.Errorf("Expected s3 primary, got %#v", cfg.Primary)
+	}
+	file, ok := cfg.Sinks[0].(*testFileSink)
+	if !ok || file.Path != "/var/log/app.log" || file.Fallback == nil || file.Fallback.Target() != "s3://backup" {
+		t.Errorf("Expected file sink with s3 fallback, got %#v", cfg.Sinks[0])
+	}
+	if len(cfg.Sinks) != 2 || cfg.Sinks[1].Target() != "s3://archive" {
+		t.Errorf("Expected archive sink, got %#v", cfg.Sinks)
+	}
+	if cfg.Named["archive"] == nil || cfg.Named["archive"].Target() != "s3://archive" {
+		t.Errorf("Expected aliased map entry, got %#v", cfg.Named)
+	}
+
+	out, err := Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var roundTrip KindConfig
+	if err := Parse(out, &roundTrip); err != nil {
+		t.Fatalf("Parse of marshaled config failed: %v\n%s", err, out)
+	}
+	if roundTrip.Sinks[0].Target() != "file:///var/log/app.log" || roundTrip.Primary.Target() != "s3://logs" {
+		t.Errorf("Expected kinds to round trip, got %s", out)
+	}
+
+	for _, doc := range []string{"primary:\n  kind: ftp\n", "primary:\n  bucket: logs\n"} {
+		err := Parse([]byte(doc), &KindConfig{})
+		if !errors.Is(err, ErrUnknownKind) || !strings.Contains(err.Error(), "primary (line 2)") {
+			t.Errorf("Expected ErrUnknownKind at primary for %q, got %v", doc, err)
+		}
+	}
+}
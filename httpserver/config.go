@@ -0,0 +1,59 @@
+package httpserver
+
+import "time"
+
+// Config describes an HTTP server. Zero values are replaced by the defaults in the tags,
+// so the struct can be loaded with the config package or filled in by hand
+type Config struct {
+	Addr              string        `json:"addr" yaml:"addr" default:":8080" validate:"required"`
+	ReadTimeout       time.Duration `json:"read_timeout" yaml:"read_timeout" default:"15s"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout" yaml:"read_header_timeout" default:"5s"`
+	WriteTimeout      time.Duration `json:"write_timeout" yaml:"write_timeout" default:"30s"`
+	IdleTimeout       time.Duration `json:"idle_timeout" yaml:"idle_timeout" default:"60s"`
+	ShutdownTimeout   time.Duration `json:"shutdown_timeout" yaml:"shutdown_timeout" default:"30s"`
+	MaxHeaderBytes    int           `json:"max_header_bytes" yaml:"max_header_bytes" default:"1048576"`
+	// H2C enables HTTP/2 over cleartext, for servers behind a TLS-terminating proxy
+	H2C bool      `json:"h2c" yaml:"h2c" default:"false"`
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+}
+
+// TLSConfig enables TLS when both certificate and key files are set
+type TLSConfig struct {
+	CertFile   string `json:"cert_file" yaml:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile    string `json:"key_file" yaml:"key_file" validate:"required_with=CertFile"`
+	MinVersion string `json:"min_version" yaml:"min_version" default:"1.2" validate:"omitempty,oneof=1.2 1.3"`
+}
+
+// Enabled reports whether TLS is configured
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// withDefaults returns a copy of the config with zero values replaced by defaults
+func (c Config) withDefaults() Config {
+	if c.Addr == "" {
+		c.Addr = ":8080"
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = 15 * time.Second
+	}
+	if c.ReadHeaderTimeout == 0 {
+		c.ReadHeaderTimeout = 5 * time.Second
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = 30 * time.Second
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = 60 * time.Second
+	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = 30 * time.Second
+	}
+	if c.MaxHeaderBytes == 0 {
+		c.MaxHeaderBytes = 1 << 20
+	}
+	if c.TLS.MinVersion == "" {
+		c.TLS.MinVersion = "1.2"
+	}
+	return c
+}
@@ -0,0 +1,218 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	NoOpLogger
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...any)  { l.record(msg) }
+func (l *recordingLogger) Error(msg string, keysAndValues ...any) { l.record(msg) }
+
+func (l *recordingLogger) record(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+func (l *recordingLogger) has(msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, m := range l.messages {
+		if m == msg {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_Chain(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), mw("first"), mw("second"))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(order) != 3 || order[0] != "first" || order[1] != "second" || order[2] != "handler" {
+		t.Errorf("Expected first, second, handler, got %v", order)
+	}
+}
+
+func Test_RequestID(t *testing.T) {
+	var seen string
+	handler := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if seen == "" || rec.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("Expected generated request ID to be echoed, got context %q header %q", seen, rec.Header().Get(RequestIDHeader))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "incoming")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "incoming" {
+		t.Errorf("Expected incoming request ID to be propagated, got %q", seen)
+	}
+}
+
+func Test_RecoveryAndLogging(t *testing.T) {
+	logger := &recordingLogger{}
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), Logging(logger), Recovery(logger))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	if !logger.has("Panic in HTTP handler") || !logger.has("HTTP request") {
+		t.Errorf("Expected panic and request to be logged, got %v", logger.messages)
+	}
+}
+
+func Test_NewDefaults(t *testing.T) {
+	s, err := New(Config{H2C: true}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	srv := s.HTTPServer()
+	if srv.Addr != ":8080" || srv.ReadHeaderTimeout != 5*time.Second || srv.IdleTimeout != 60*time.Second {
+		t.Errorf("Expected defaults to be applied, got addr %q read header %v idle %v", srv.Addr, srv.ReadHeaderTimeout, srv.IdleTimeout)
+	}
+	if srv.Protocols == nil || !srv.Protocols.UnencryptedHTTP2() {
+		t.Error("Expected h2c to be enabled")
+	}
+}
+
+func Test_ServerStartStop(t *testing.T) {
+	s, err := New(Config{Addr: "127.0.0.1:0"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}), WithName("api"))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if s.Name() != "api" {
+		t.Errorf("Expected name 'api', got '%s'", s.Name())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start(ctx) }()
+
+	select {
+	case <-s.Ready():
+	case err := <-done:
+		t.Fatalf("Server exited before becoming ready: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("Server did not become ready")
+	}
+
+	resp, err := http.Get("http://" + s.Addr())
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" || resp.Header.Get(RequestIDHeader) == "" {
+		t.Errorf("Expected body 'ok' with request ID, got %q", body)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Server did not shut down")
+	}
+}
+
+func Test_ServerRestart(t *testing.T) {
+	s, err := New(Config{Addr: "127.0.0.1:0"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	s.HTTPServer().MaxHeaderBytes = 4096
+
+	// The first run is stopped by its context, the second by Stop
+	for run := range 2 {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- s.Start(ctx) }()
+
+		select {
+		case <-s.Ready():
+		case err := <-done:
+			t.Fatalf("Run %d exited before becoming ready: %v", run, err)
+		case <-time.After(time.Second):
+			t.Fatalf("Run %d did not become ready", run)
+		}
+		if err := s.Start(ctx); err == nil {
+			t.Errorf("Run %d: expected a second Start to fail while running", run)
+		}
+
+		resp, err := http.Get("http://" + s.Addr())
+		if err != nil {
+			t.Fatalf("Run %d: request failed: %v", run, err)
+		}
+		resp.Body.Close()
+		if s.HTTPServer().MaxHeaderBytes != 4096 {
+			t.Errorf("Run %d: expected the customized settings, got %d", run, s.HTTPServer().MaxHeaderBytes)
+		}
+
+		if run == 0 {
+			cancel()
+		} else if err := s.Stop(context.Background()); err != nil {
+			t.Errorf("Stop failed: %v", err)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run %d: expected clean shutdown, got %v", run, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Run %d did not shut down", run)
+		}
+		cancel()
+	}
+}
+
+func Test_NewInvalidTLS(t *testing.T) {
+	if _, err := New(Config{TLS: TLSConfig{CertFile: "cert.pem"}}, http.NotFoundHandler()); err == nil {
+		t.Error("Expected error for cert without key")
+	}
+	if _, err := New(Config{TLS: TLSConfig{MinVersion: "1.0"}}, http.NotFoundHandler()); err == nil {
+		t.Error("Expected error for unsupported min version")
+	}
+}
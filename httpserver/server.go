@@ -0,0 +1,240 @@
+// Package httpserver builds production-ready HTTP servers from a config struct,
+// with middleware chaining and a service adapter for the service manager
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithName sets the service name, "http" by default
+func WithName(name string) Option {
+	return func(s *Server) {
+		s.name = name
+	}
+}
+
+// WithLogger sets the logger used by the server and the default middleware
+func WithLogger(logger Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithMiddleware appends middleware applied inside the default recovery, request ID and logging middleware
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// WithoutDefaultMiddleware disables the default recovery, request ID and logging middleware
+func WithoutDefaultMiddleware() Option {
+	return func(s *Server) {
+		s.noDefaults = true
+	}
+}
+
+// Server is an HTTP server that can be run directly or registered with the service manager
+type Server struct {
+	name       string
+	config     Config
+	logger     Logger
+	middleware []Middleware
+	noDefaults bool
+	server     *http.Server
+
+	// running rejects a second Start while the server runs
+	running atomic.Bool
+
+	mu sync.Mutex
+	// listener is set while the server runs
+	listener net.Listener
+	ready    chan struct{}
+}
+
+// New creates a server for the handler from the configuration
+func New(config Config, handler http.Handler, options ...Option) (*Server, error) {
+	s := &Server{
+		name:   "http",
+		config: config.withDefaults(),
+		logger: NoOpLogger{},
+		ready:  make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if (s.config.TLS.CertFile == "") != (s.config.TLS.KeyFile == "") {
+		return nil, fmt.Errorf("TLS requires both cert_file and key_file")
+	}
+	if s.config.TLS.MinVersion != "1.2" && s.config.TLS.MinVersion != "1.3" {
+		return nil, fmt.Errorf("unsupported TLS min version '%s'", s.config.TLS.MinVersion)
+	}
+
+	middleware := s.middleware
+	if !s.noDefaults {
+		middleware = append([]Middleware{Recovery(s.logger), RequestID(), Logging(s.logger)}, middleware...)
+	}
+
+	s.server = &http.Server{
+		Addr:              s.config.Addr,
+		Handler:           Chain(handler, middleware...),
+		ReadTimeout:       s.config.ReadTimeout,
+		ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		IdleTimeout:       s.config.IdleTimeout,
+		MaxHeaderBytes:    s.config.MaxHeaderBytes,
+	}
+
+	if s.config.TLS.Enabled() {
+		minVersion := uint16(tls.VersionTLS12)
+		if s.config.TLS.MinVersion == "1.3" {
+			minVersion = tls.VersionTLS13
+		}
+		s.server.TLSConfig = &tls.Config{MinVersion: minVersion}
+	} else if s.config.H2C {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.server.Protocols = protocols
+	}
+
+	return s, nil
+}
+
+// HTTPServer returns the underlying *http.Server for further customization before Start. A shut down
+// *http.Server can't serve again, so each run after the first serves a copy of its settings
+func (s *Server) HTTPServer() *http.Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.server
+}
+
+// Addr returns the address the server is listening on, or the configured address before Start
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr().String()
+	}
+	return s.config.Addr
+}
+
+// Name returns the service name
+func (s *Server) Name() string {
+	return s.name
+}
+
+// Ready returns a channel closed once the server is listening. Every run gets a new channel
+func (s *Server) Ready() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+// Start listens on the configured address and serves until the context is cancelled or Stop is called.
+// The server can be started again once Start returned, e.g. when the service manager restarts
+func (s *Server) Start(ctx context.Context) error {
+	if !s.running.CompareAndSwap(false, true) {
+		return fmt.Errorf("HTTP server '%s' is already running", s.name)
+	}
+	defer s.running.Store(false)
+	defer s.renew()
+
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Addr, err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	server, ready := s.server, s.ready
+	s.mu.Unlock()
+	close(ready)
+
+	s.logger.Info("HTTP server listening", "service", s.name, "addr", listener.Addr().String(), "tls", s.config.TLS.Enabled())
+
+	// Shut down when the service context is cancelled
+	stop := context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	})
+	defer stop()
+
+	if s.config.TLS.Enabled() {
+		err = server.ServeTLS(listener, s.config.TLS.CertFile, s.config.TLS.KeyFile)
+	} else {
+		err = server.Serve(listener)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop gracefully shuts the server down, waiting for active requests until the context is done.
+// It does nothing when the server isn't running
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	server, running := s.server, s.listener != nil
+	s.mu.Unlock()
+	if !running {
+		return nil
+	}
+
+	s.logger.Info("HTTP server shutting down", "service", s.name)
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	return nil
+}
+
+// renew prepares the next run after Start returned: a new ready channel and a copy of the shut down
+// *http.Server
+func (s *Server) renew() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listener = nil
+	s.ready = make(chan struct{})
+	s.server = cloneServer(s.server)
+}
+
+// cloneServer returns an *http.Server with the settings of the server
+func cloneServer(server *http.Server) *http.Server {
+	clone := &http.Server{
+		Addr:                         server.Addr,
+		Handler:                      server.Handler,
+		DisableGeneralOptionsHandler: server.DisableGeneralOptionsHandler,
+		ReadTimeout:                  server.ReadTimeout,
+		ReadHeaderTimeout:            server.ReadHeaderTimeout,
+		WriteTimeout:                 server.WriteTimeout,
+		IdleTimeout:                  server.IdleTimeout,
+		MaxHeaderBytes:               server.MaxHeaderBytes,
+		TLSNextProto:                 server.TLSNextProto,
+		ConnState:                    server.ConnState,
+		ErrorLog:                     server.ErrorLog,
+		BaseContext:                  server.BaseContext,
+		ConnContext:                  server.ConnContext,
+		HTTP2:                        server.HTTP2,
+		Protocols:                    server.Protocols,
+	}
+	if server.TLSConfig != nil {
+		clone.TLSConfig = server.TLSConfig.Clone()
+	}
+	return clone
+}
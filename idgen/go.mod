@@ -0,0 +1,3 @@
+module github.com/btchead/go-reusables/idgen
+
+go 1.24.5
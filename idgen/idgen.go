@@ -0,0 +1,28 @@
+// Package idgen generates sortable unique identifiers: monotonic ULIDs, UUIDv7 values
+// and node-aware snowflake IDs
+package idgen
+
+var (
+	defaultULID   = NewULIDGenerator()
+	defaultUUIDv7 = NewUUIDv7Generator()
+)
+
+// NewULID returns a ULID from the package-level monotonic generator.
+// It panics if the system entropy source fails
+func NewULID() ULID {
+	id, err := defaultULID.New()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// NewUUIDv7 returns a UUIDv7 from the package-level monotonic generator.
+// It panics if the system entropy source fails
+func NewUUIDv7() UUID {
+	id, err := defaultUUIDv7.New()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
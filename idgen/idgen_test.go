@@ -0,0 +1,122 @@
+package idgen
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fixedClock(t time.Time) func() time.Time {
+	return func() time.Time { return t }
+}
+
+func TestULIDGenerator_Monotonic(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	generator := NewULIDGenerator(WithClock(fixedClock(now)))
+
+	ids, err := generator.NewBatch(1000)
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+
+	for i := 1; i < len(ids); i++ {
+		if bytes.Compare(ids[i-1][:], ids[i][:]) >= 0 {
+			t.Fatalf("ULIDs not strictly increasing at %d", i)
+		}
+		if ids[i-1].String() >= ids[i].String() {
+			t.Fatalf("ULID strings not sortable at %d", i)
+		}
+	}
+
+	if !ids[0].Time().Equal(now) {
+		t.Errorf("Expected timestamp %v, got %v", now, ids[0].Time())
+	}
+}
+
+func TestParseULID(t *testing.T) {
+	id := NewULID()
+
+	parsed, err := ParseULID(id.String())
+	if err != nil {
+		t.Fatalf("ParseULID failed: %v", err)
+	}
+	if parsed != id {
+		t.Errorf("Round trip mismatch: %s != %s", parsed, id)
+	}
+
+	if _, err := ParseULID(strings.ToLower(id.String())); err != nil {
+		t.Errorf("Lowercase ULID should parse: %v", err)
+	}
+
+	if _, err := ParseULID("not-a-ulid"); err == nil {
+		t.Error("Expected error for invalid ULID")
+	}
+}
+
+func TestUUIDv7Generator(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	generator := NewUUIDv7Generator(WithClock(fixedClock(now)))
+
+	ids, err := generator.NewBatch(5000)
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+
+	for i, id := range ids {
+		if id.Version() != 7 {
+			t.Fatalf("Expected version 7, got %d", id.Version())
+		}
+		if id[8]&0xC0 != 0x80 {
+			t.Fatalf("Expected RFC 9562 variant, got %x", id[8])
+		}
+		if i > 0 && bytes.Compare(ids[i-1][:], id[:]) >= 0 {
+			t.Fatalf("UUIDs not strictly increasing at %d", i)
+		}
+	}
+
+	parsed, err := ParseUUID(ids[0].String())
+	if err != nil {
+		t.Fatalf("ParseUUID failed: %v", err)
+	}
+	if parsed != ids[0] {
+		t.Errorf("Round trip mismatch: %s != %s", parsed, ids[0])
+	}
+	if !ids[0].Time().Equal(now) {
+		t.Errorf("Expected timestamp %v, got %v", now, ids[0].Time())
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	generator, err := NewSnowflake(SnowflakeConfig{NodeID: 42})
+	if err != nil {
+		t.Fatalf("NewSnowflake failed: %v", err)
+	}
+
+	ids, err := generator.NextBatch(10000)
+	if err != nil {
+		t.Fatalf("NextBatch failed: %v", err)
+	}
+
+	if !sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] }) {
+		t.Error("Snowflake IDs should be increasing")
+	}
+
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("Duplicate snowflake ID %d", id)
+		}
+		seen[id] = true
+	}
+
+	_, node, _ := generator.Decompose(ids[0])
+	if node != 42 {
+		t.Errorf("Expected node 42, got %d", node)
+	}
+
+	if _, err := NewSnowflake(SnowflakeConfig{NodeID: 2048}); err == nil {
+		t.Error("Expected error for out of range node ID")
+	}
+}
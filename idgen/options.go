@@ -0,0 +1,37 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"io"
+	"time"
+)
+
+// Option configures a ULID or UUIDv7 generator
+type Option func(*options)
+
+type options struct {
+	entropy io.Reader
+	clock   func() time.Time
+}
+
+// WithEntropy sets the source of randomness, crypto/rand by default
+func WithEntropy(entropy io.Reader) Option {
+	return func(o *options) {
+		o.entropy = entropy
+	}
+}
+
+// WithClock sets the time source, time.Now by default
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// defaultOptions returns the default generator options
+func defaultOptions() *options {
+	return &options{
+		entropy: rand.Reader,
+		clock:   time.Now,
+	}
+}
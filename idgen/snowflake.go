@@ -0,0 +1,161 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	maxNode      = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+// DefaultEpoch is the snowflake epoch used when none is configured
+var DefaultEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ErrClockBackwards is returned when the clock moved backwards further than the configured tolerance
+var ErrClockBackwards = errors.New("clock moved backwards")
+
+// SnowflakeConfig configures a snowflake generator. It can be loaded with the config package
+type SnowflakeConfig struct {
+	// NodeID identifies this generator, unique per process generating IDs concurrently
+	NodeID int64 `json:"node_id" yaml:"node_id" validate:"min=0,max=1023"`
+	// NodeIDFromHostname derives the node ID from a hash of the hostname instead of NodeID
+	NodeIDFromHostname bool `json:"node_id_from_hostname" yaml:"node_id_from_hostname" default:"false"`
+	// Epoch is the start of the 41-bit millisecond timestamp, DefaultEpoch if zero
+	Epoch time.Time `json:"epoch" yaml:"epoch"`
+	// MaxClockBackwards is how long Next waits for the clock to catch up before failing
+	MaxClockBackwards time.Duration `json:"max_clock_backwards" yaml:"max_clock_backwards" default:"10ms"`
+}
+
+// Snowflake generates 63-bit time-ordered IDs composed of 41 bits of milliseconds since the epoch,
+// 10 bits of node ID and 12 bits of per-millisecond sequence. It is safe for concurrent use
+type Snowflake struct {
+	node        int64
+	epoch       time.Time
+	maxBackward time.Duration
+	clock       func() time.Time
+
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflake creates a snowflake generator from the configuration
+func NewSnowflake(config SnowflakeConfig, opts ...Option) (*Snowflake, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	node := config.NodeID
+	if config.NodeIDFromHostname {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive node ID from hostname: %w", err)
+		}
+		hash := fnv.New32a()
+		hash.Write([]byte(hostname))
+		node = int64(hash.Sum32() % (maxNode + 1))
+	}
+	if node < 0 || node > maxNode {
+		return nil, fmt.Errorf("node ID %d out of range [0, %d]", node, maxNode)
+	}
+
+	epoch := config.Epoch
+	if epoch.IsZero() {
+		epoch = DefaultEpoch
+	}
+	if epoch.After(options.clock()) {
+		return nil, fmt.Errorf("epoch %v is in the future", epoch)
+	}
+
+	return &Snowflake{
+		node:        node,
+		epoch:       epoch,
+		maxBackward: config.MaxClockBackwards,
+		clock:       options.clock,
+		lastMs:      -1,
+	}, nil
+}
+
+// Node returns the node ID of the generator
+func (s *Snowflake) Node() int64 {
+	return s.node
+}
+
+// Next returns the next ID
+func (s *Snowflake) Next() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next()
+}
+
+// NextBatch returns n IDs in ascending order
+func (s *Snowflake) NextBatch(n int) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, n)
+	for i := range ids {
+		id, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// Decompose splits an ID into its timestamp, node ID and sequence
+func (s *Snowflake) Decompose(id int64) (time.Time, int64, int64) {
+	ms := id >> (nodeBits + sequenceBits)
+	node := (id >> sequenceBits) & maxNode
+	sequence := id & maxSequence
+	return s.epoch.Add(time.Duration(ms) * time.Millisecond), node, sequence
+}
+
+// next generates the next ID (assumes lock is held)
+func (s *Snowflake) next() (int64, error) {
+	ms := s.elapsed()
+
+	if ms < s.lastMs {
+		// Wait for the clock to catch up if it moved back only slightly
+		behind := time.Duration(s.lastMs-ms) * time.Millisecond
+		if behind > s.maxBackward {
+			return 0, fmt.Errorf("%w by %v", ErrClockBackwards, behind)
+		}
+		time.Sleep(behind)
+		ms = s.elapsed()
+		if ms < s.lastMs {
+			return 0, fmt.Errorf("%w by %v", ErrClockBackwards, behind)
+		}
+	}
+
+	if ms == s.lastMs {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			// Sequence exhausted, wait for the next millisecond
+			for ms <= s.lastMs {
+				time.Sleep(100 * time.Microsecond)
+				ms = s.elapsed()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+
+	s.lastMs = ms
+	return ms<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence, nil
+}
+
+// elapsed returns the milliseconds since the epoch
+func (s *Snowflake) elapsed() int64 {
+	return s.clock().Sub(s.epoch).Milliseconds()
+}
@@ -0,0 +1,191 @@
+package idgen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID is a 128-bit lexicographically sortable identifier: 48 bits of unix milliseconds
+// followed by 80 bits of randomness
+type ULID [16]byte
+
+// String returns the canonical 26 character Crockford base32 representation
+func (u ULID) String() string {
+	var dst [26]byte
+
+	// 10 characters of timestamp
+	dst[0] = crockford[(u[0]&224)>>5]
+	dst[1] = crockford[u[0]&31]
+	dst[2] = crockford[(u[1]&248)>>3]
+	dst[3] = crockford[((u[1]&7)<<2)|((u[2]&192)>>6)]
+	dst[4] = crockford[(u[2]&62)>>1]
+	dst[5] = crockford[((u[2]&1)<<4)|((u[3]&240)>>4)]
+	dst[6] = crockford[((u[3]&15)<<1)|((u[4]&128)>>7)]
+	dst[7] = crockford[(u[4]&124)>>2]
+	dst[8] = crockford[((u[4]&3)<<3)|((u[5]&224)>>5)]
+	dst[9] = crockford[u[5]&31]
+
+	// 16 characters of entropy
+	dst[10] = crockford[(u[6]&248)>>3]
+	dst[11] = crockford[((u[6]&7)<<2)|((u[7]&192)>>6)]
+	dst[12] = crockford[(u[7]&62)>>1]
+	dst[13] = crockford[((u[7]&1)<<4)|((u[8]&240)>>4)]
+	dst[14] = crockford[((u[8]&15)<<1)|((u[9]&128)>>7)]
+	dst[15] = crockford[(u[9]&124)>>2]
+	dst[16] = crockford[((u[9]&3)<<3)|((u[10]&224)>>5)]
+	dst[17] = crockford[u[10]&31]
+	dst[18] = crockford[(u[11]&248)>>3]
+	dst[19] = crockford[((u[11]&7)<<2)|((u[12]&192)>>6)]
+	dst[20] = crockford[(u[12]&62)>>1]
+	dst[21] = crockford[((u[12]&1)<<4)|((u[13]&240)>>4)]
+	dst[22] = crockford[((u[13]&15)<<1)|((u[14]&128)>>7)]
+	dst[23] = crockford[(u[14]&124)>>2]
+	dst[24] = crockford[((u[14]&3)<<3)|((u[15]&224)>>5)]
+	dst[25] = crockford[u[15]&31]
+
+	return string(dst[:])
+}
+
+// Time returns the timestamp encoded in the ULID
+func (u ULID) Time() time.Time {
+	ms := uint64(u[5]) | uint64(u[4])<<8 | uint64(u[3])<<16 |
+		uint64(u[2])<<24 | uint64(u[1])<<32 | uint64(u[0])<<40
+	return time.UnixMilli(int64(ms))
+}
+
+// ParseULID parses the canonical string representation of a ULID
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, fmt.Errorf("invalid ULID length %d", len(s))
+	}
+	if s[0] > '7' {
+		return u, errors.New("invalid ULID: timestamp overflow")
+	}
+
+	// Decode into a 130-bit accumulator, the two leading bits are always zero
+	var hi, lo uint64
+	for i := 0; i < len(s); i++ {
+		v := decodeCrockford(s[i])
+		if v < 0 {
+			return u, fmt.Errorf("invalid ULID character %q", s[i])
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+
+	for i := 0; i < 8; i++ {
+		u[i] = byte(hi >> (56 - 8*i))
+		u[8+i] = byte(lo >> (56 - 8*i))
+	}
+	return u, nil
+}
+
+// decodeCrockford returns the value of a Crockford base32 character, or -1 if invalid
+func decodeCrockford(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		c -= 'a' - 'A'
+	}
+	switch c {
+	case 'O':
+		return 0
+	case 'I', 'L':
+		return 1
+	}
+	for i := 10; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// ULIDGenerator generates monotonic ULIDs. IDs generated within the same millisecond
+// increment the random part, so they sort in generation order. It is safe for concurrent use
+type ULIDGenerator struct {
+	options *options
+	mu      sync.Mutex
+	lastMs  uint64
+	last    ULID
+}
+
+// NewULIDGenerator creates a new monotonic ULID generator
+func NewULIDGenerator(opts ...Option) *ULIDGenerator {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &ULIDGenerator{options: options}
+}
+
+// New returns the next ULID
+func (g *ULIDGenerator) New() (ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.next()
+}
+
+// NewBatch returns n ULIDs in ascending order
+func (g *ULIDGenerator) NewBatch(n int) ([]ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := make([]ULID, n)
+	for i := range ids {
+		id, err := g.next()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// next generates the next ULID (assumes lock is held)
+func (g *ULIDGenerator) next() (ULID, error) {
+	ms := uint64(g.options.clock().UnixMilli())
+
+	if ms <= g.lastMs {
+		// Same millisecond or clock moved backwards: increment the previous entropy
+		if incrementEntropy(&g.last) {
+			return g.last, nil
+		}
+		// Entropy exhausted within this millisecond, move on to the next one
+		ms = g.lastMs + 1
+	}
+
+	var id ULID
+	id[0] = byte(ms >> 40)
+	id[1] = byte(ms >> 32)
+	id[2] = byte(ms >> 24)
+	id[3] = byte(ms >> 16)
+	id[4] = byte(ms >> 8)
+	id[5] = byte(ms)
+	if _, err := io.ReadFull(g.options.entropy, id[6:]); err != nil {
+		return ULID{}, fmt.Errorf("failed to read entropy: %w", err)
+	}
+
+	g.lastMs = ms
+	g.last = id
+	return id, nil
+}
+
+// incrementEntropy adds one to the 80-bit entropy part, reporting false on overflow
+func incrementEntropy(id *ULID) bool {
+	for i := len(id) - 1; i >= 6; i-- {
+		id[i]++
+		if id[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package idgen
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// UUID is a 128-bit RFC 9562 universally unique identifier
+type UUID [16]byte
+
+// String returns the canonical 8-4-4-4-12 hexadecimal representation
+func (u UUID) String() string {
+	var dst [36]byte
+	hex.Encode(dst[0:8], u[0:4])
+	dst[8] = '-'
+	hex.Encode(dst[9:13], u[4:6])
+	dst[13] = '-'
+	hex.Encode(dst[14:18], u[6:8])
+	dst[18] = '-'
+	hex.Encode(dst[19:23], u[8:10])
+	dst[23] = '-'
+	hex.Encode(dst[24:], u[10:])
+	return string(dst[:])
+}
+
+// Version returns the UUID version number
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time returns the timestamp encoded in a version 7 UUID
+func (u UUID) Time() time.Time {
+	ms := uint64(u[5]) | uint64(u[4])<<8 | uint64(u[3])<<16 |
+		uint64(u[2])<<24 | uint64(u[1])<<32 | uint64(u[0])<<40
+	return time.UnixMilli(int64(ms))
+}
+
+// ParseUUID parses the canonical string representation of a UUID
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID format %q", s)
+	}
+
+	groups := [][2]int{{0, 8}, {9, 13}, {14, 18}, {19, 23}, {24, 36}}
+	offset := 0
+	for _, group := range groups {
+		n, err := hex.Decode(u[offset:], []byte(s[group[0]:group[1]]))
+		if err != nil {
+			return UUID{}, fmt.Errorf("invalid UUID %q: %w", s, err)
+		}
+		offset += n
+	}
+	return u, nil
+}
+
+// UUIDv7Generator generates monotonic version 7 UUIDs. The 12-bit rand_a field is used as a
+// counter within the same millisecond (RFC 9562 method 1). It is safe for concurrent use
+type UUIDv7Generator struct {
+	options *options
+	mu      sync.Mutex
+	lastMs  uint64
+	counter uint16
+}
+
+// NewUUIDv7Generator creates a new monotonic UUIDv7 generator
+func NewUUIDv7Generator(opts ...Option) *UUIDv7Generator {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &UUIDv7Generator{options: options}
+}
+
+// New returns the next UUIDv7
+func (g *UUIDv7Generator) New() (UUID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.next()
+}
+
+// NewBatch returns n UUIDv7 values in ascending order
+func (g *UUIDv7Generator) NewBatch(n int) ([]UUID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := make([]UUID, n)
+	for i := range ids {
+		id, err := g.next()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// next generates the next UUIDv7 (assumes lock is held)
+func (g *UUIDv7Generator) next() (UUID, error) {
+	var id UUID
+	if _, err := io.ReadFull(g.options.entropy, id[6:]); err != nil {
+		return UUID{}, fmt.Errorf("failed to read entropy: %w", err)
+	}
+
+	ms := uint64(g.options.clock().UnixMilli())
+	if ms <= g.lastMs {
+		// Same millisecond or clock moved backwards: increment the counter
+		ms = g.lastMs
+		g.counter++
+		if g.counter > 0xFFF {
+			// Counter exhausted, borrow the next millisecond
+			ms++
+			g.counter = 0
+		}
+	} else {
+		// Seed the counter randomly, leaving headroom for increments
+		g.counter = (uint16(id[6])<<8 | uint16(id[7])) & 0x7FF
+	}
+	g.lastMs = ms
+
+	id[0] = byte(ms >> 40)
+	id[1] = byte(ms >> 32)
+	id[2] = byte(ms >> 24)
+	id[3] = byte(ms >> 16)
+	id[4] = byte(ms >> 8)
+	id[5] = byte(ms)
+	id[6] = 0x70 | byte(g.counter>>8)
+	id[7] = byte(g.counter)
+	id[8] = (id[8] & 0x3F) | 0x80 // RFC 9562 variant
+	return id, nil
+}
@@ -0,0 +1,91 @@
+package log
+
+import "fmt"
+
+// causesSuffix is appended to the key of an error field to name the field listing its causes
+const causesSuffix = ".causes"
+
+// maxErrorCauses bounds the causes listed for a single error
+const maxErrorCauses = 32
+
+// errorCause is an error wrapped by a logged error, written in the causes field
+type errorCause struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// WithErrorCauses adds a field listing the type and message of every error wrapped by a logged error,
+// named after the error's key, e.g. error.causes, so the root cause of wrapped errors can be found in
+// aggregated logs. Errors joined with errors.Join are listed depth first
+func WithErrorCauses() Option {
+	return func(o *options) {
+		o.errorCauses = true
+	}
+}
+
+// errorCauses returns the errors wrapped by err, depth first, without err itself
+func errorCauses(err error) []errorCause {
+	var causes []errorCause
+	var walk func(err error)
+	walk = func(err error) {
+		for err != nil && len(causes) < maxErrorCauses {
+			var next error
+			switch wrapper := err.(type) {
+			case interface{ Unwrap() error }:
+				next = wrapper.Unwrap()
+			case interface{ Unwrap() []error }:
+				for _, joined := range wrapper.Unwrap() {
+					if joined != nil && len(causes) < maxErrorCauses {
+						causes = append(causes, errorCause{Type: fmt.Sprintf("%T", joined), Message: joined.Error()})
+						walk(joined)
+					}
+				}
+			}
+			if next != nil {
+				causes = append(causes, errorCause{Type: fmt.Sprintf("%T", next), Message: next.Error()})
+			}
+			err = next
+		}
+	}
+	walk(err)
+	return causes
+}
+
+// appendErrorCauses returns the key/value pairs followed by a causes field for every error value
+// wrapping others. The input is not modified
+func appendErrorCauses(keysAndValues []any) []any {
+	extended := keysAndValues
+	for i := 1; i < len(keysAndValues); i += 2 {
+		err, ok := keysAndValues[i].(error)
+		if !ok {
+			continue
+		}
+		key, _ := keysAndValues[i-1].(string)
+		if causes := errorCauses(err); len(causes) > 0 {
+			if len(extended) == len(keysAndValues) {
+				extended = append(make([]any, 0, len(keysAndValues)+2), keysAndValues...)
+			}
+			extended = append(extended, key+causesSuffix, causes)
+		}
+	}
+	return extended
+}
+
+// appendTypedErrorCauses returns the fields followed by a causes field for every error field wrapping
+// others. The input is not modified
+func appendTypedErrorCauses(fields []Field) []Field {
+	extended := fields
+	for _, field := range fields {
+		err, ok := field.val.(error)
+		if field.kind != errorField || !ok {
+			continue
+		}
+		if causes := errorCauses(err); len(causes) > 0 {
+			if len(extended) == len(fields) {
+				extended = append(make([]Field, 0, len(fields)+1), fields...)
+			}
+			extended = append(extended, Any(field.Key+causesSuffix, causes))
+		}
+	}
+	return extended
+}
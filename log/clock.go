@@ -0,0 +1,55 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// clockHandler stamps slog records with the time of the clock instead of the wall clock
+type clockHandler struct {
+	slog.Handler
+	now func() time.Time
+}
+
+// withClock wraps the handler when the options set a clock
+func withClock(handler slog.Handler, options *options) slog.Handler {
+	if options == nil || options.clock == nil {
+		return handler
+	}
+	return &clockHandler{Handler: handler, now: options.clock}
+}
+
+func (o *clockHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.Time = o.now()
+	return o.Handler.Handle(ctx, r)
+}
+
+func (o *clockHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &clockHandler{Handler: o.Handler.WithAttrs(attrs), now: o.now}
+}
+
+func (o *clockHandler) WithGroup(name string) slog.Handler {
+	return &clockHandler{Handler: o.Handler.WithGroup(name), now: o.now}
+}
+
+// clockHook adds the time of the clock, the wall clock when nil, to zerolog events, replacing the context
+// timestamp. A layout formats the time instead of zerolog.TimeFieldFormat
+type clockHook struct {
+	now    func() time.Time
+	layout string
+}
+
+func (h clockHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	now := time.Now()
+	if h.now != nil {
+		now = h.now()
+	}
+	if h.layout != "" {
+		e.Str(zerolog.TimestampFieldName, now.Format(h.layout))
+		return
+	}
+	e.Time(zerolog.TimestampFieldName, now)
+}
@@ -0,0 +1,145 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// colorTheme holds the SGR parameters of the colored parts of console records, empty for plain text
+type colorTheme struct {
+	// levels are keyed by lowercase level name
+	levels map[string]string
+	time   string
+}
+
+// colorThemes are the themes selectable with Config.Theme
+var colorThemes = map[string]colorTheme{
+	"dark": {
+		levels: map[string]string{
+			"trace": "34", "debug": "36", "info": "32", "warn": "33", "error": "31", "fatal": "1;31", "panic": "1;31",
+		},
+		time: "90",
+	},
+	"light": {
+		levels: map[string]string{
+			"trace": "90", "debug": "34", "info": "32", "warn": "35", "error": "31", "fatal": "1;31", "panic": "1;31",
+		},
+		time: "90",
+	},
+	"monochrome": {
+		levels: map[string]string{
+			"trace": "2", "debug": "2", "warn": "1", "error": "1", "fatal": "1;7", "panic": "1;7",
+		},
+		time: "2",
+	},
+}
+
+// themeFor returns the named theme, dark when the name is empty or unknown
+func themeFor(name string) colorTheme {
+	if theme, ok := colorThemes[name]; ok {
+		return theme
+	}
+	return colorThemes["dark"]
+}
+
+// paint wraps text in the SGR parameters, returning it unchanged when there are none
+func paint(code, text string) string {
+	if code == "" {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+// colorEnabled reports whether console records written to w are colored. Color always or never wins;
+// otherwise a non-empty NO_COLOR disables and FORCE_COLOR enables colors, and auto colors terminals only
+func colorEnabled(config Config, w io.Writer) bool {
+	switch config.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	if config.Color == "auto" {
+		return isTerminal(w)
+	}
+	return config.Colored
+}
+
+// withResolvedColor fixes the color decision for w in the configuration, for handlers writing elsewhere first
+func withResolvedColor(config Config, w io.Writer) Config {
+	config.Color = "never"
+	if colorEnabled(config, w) {
+		config.Color = "always"
+	}
+	return config
+}
+
+// isTerminal reports whether w, unwrapped from the write error handler, is a terminal
+func isTerminal(w io.Writer) bool {
+	if wrapped, ok := w.(*writeErrorWriter); ok {
+		w = wrapped.out
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatZerologLevel is the ConsoleWriter level formatter of the theme
+func (t colorTheme) formatZerologLevel(i any) string {
+	name, _ := i.(string)
+	text := strings.ToUpper(name[:min(len(name), 3)])
+	if level, err := zerolog.ParseLevel(name); err == nil {
+		if formatted, ok := zerolog.FormattedLevels[level]; ok {
+			text = formatted
+		}
+	}
+	if text == "" {
+		text = "???"
+	}
+	return paint(t.levels[name], text)
+}
+
+// formatZerologTimestamp is the ConsoleWriter timestamp formatter of the theme, showing local
+// times in the kitchen format like the default formatter
+func (t colorTheme) formatZerologTimestamp(i any) string {
+	text := fmt.Sprint(i)
+	switch value := i.(type) {
+	case string:
+		if ts, err := time.ParseInLocation(zerolog.TimeFieldFormat, value, time.Local); err == nil {
+			text = ts.Local().Format(time.Kitchen)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			text = zerologUnixTime(n).Local().Format(time.Kitchen)
+		}
+	}
+	return paint(t.time, text)
+}
+
+// zerologUnixTime converts a numeric timestamp in the unit of zerolog.TimeFieldFormat
+func zerologUnixTime(n int64) time.Time {
+	switch zerolog.TimeFieldFormat {
+	case zerolog.TimeFormatUnixNano:
+		return time.Unix(0, n)
+	case zerolog.TimeFormatUnixMicro:
+		return time.UnixMicro(n)
+	case zerolog.TimeFormatUnixMs:
+		return time.UnixMilli(n)
+	}
+	return time.Unix(n, 0)
+}
@@ -1,7 +1,17 @@
 package log
 
 type Config struct {
-	Level   string `json:"level" yaml:"level" default:"info" validate:"required,oneof=debug warn info error"`
-	Format  string `json:"format" yaml:"format" default:"json" validate:"required,oneof=json console"`
+	Level   string `json:"level" yaml:"level" default:"info" validate:"required,oneof=trace debug warn info error"`
+	Format  string `json:"format" yaml:"format" default:"json" validate:"required,oneof=json console logfmt"`
 	Colored bool   `json:"colored" yaml:"colored" default:"false"`
+	// Color selects when console records are colored: auto colors terminals, always and never ignore
+	// NO_COLOR and FORCE_COLOR. Empty uses Colored, still honoring the environment
+	Color string `json:"color" yaml:"color" validate:"omitempty,oneof=auto always never"`
+	// Theme is the console color theme: dark, light or monochrome
+	Theme string `json:"theme" yaml:"theme" default:"dark" validate:"omitempty,oneof=dark light monochrome"`
+	// StacktraceLevel attaches a stack trace to records at or above the level: error, fatal or off
+	StacktraceLevel string `json:"stacktrace_level" yaml:"stacktrace_level" default:"off" validate:"omitempty,oneof=error fatal off"`
+	// TimeFormat is the Go time layout of record timestamps in every format, e.g. time.RFC3339Nano.
+	// Empty keeps the default of each output
+	TimeFormat string `json:"time_format" yaml:"time_format"`
 }
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the trace context fields added by WithContext
+const (
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+// ContextExtractor returns key/value pairs extracted from a context, e.g. a tenant or locale.
+// It should return nil when the context carries nothing of interest
+type ContextExtractor func(ctx context.Context) []any
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor registers an extractor whose fields are added by WithContext on every logger.
+// Extractors run in registration order and are typically registered during program initialization
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	extractors = append(extractors, extractor)
+}
+
+// contextFields returns the trace context of the span in the context, followed by the fields
+// of the registered extractors
+func contextFields(ctx context.Context) []any {
+	if ctx == nil {
+		return nil
+	}
+
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	fields := traceFields(ctx)
+	for _, extractor := range extractors {
+		fields = append(fields, extractor(ctx)...)
+	}
+	return fields
+}
+
+// traceFields returns the W3C trace and span IDs of the OpenTelemetry span in the context, so records
+// correlate with traces. Contexts without a valid span context add nothing
+func traceFields(ctx context.Context) []any {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []any{traceIDKey, spanContext.TraceID().String(), spanIDKey, spanContext.SpanID().String()}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// panicStackKey is the field holding the stack of a recovered panic
+const panicStackKey = "panic_stacktrace"
+
+// Syncer is implemented by loggers and writers that buffer output
+type Syncer interface {
+	Sync() error
+}
+
+// fatalLogger is implemented by the adapters to log at fatal level without exiting
+type fatalLogger interface {
+	logFatal(msg string, keysAndValues []any)
+}
+
+// syncWriter flushes the writer if it supports it
+func syncWriter(w io.Writer) error {
+	if s, ok := w.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// CrashOption configures the crash handler
+type CrashOption func(*crashOptions)
+
+type crashOptions struct {
+	repanic  bool
+	exitCode int
+	exit     func(code int)
+}
+
+// WithRepanic re-raises the panic after logging instead of exiting
+func WithRepanic() CrashOption {
+	return func(o *crashOptions) {
+		o.repanic = true
+	}
+}
+
+// WithExitCode sets the exit code used after logging a panic, 2 by default like an unrecovered panic
+func WithExitCode(code int) CrashOption {
+	return func(o *crashOptions) {
+		o.exitCode = code
+	}
+}
+
+// InstallCrashHandler returns a function to defer at the top of main or a goroutine.
+// It recovers a panic, logs it with its stack at fatal level, syncs the logger's writer
+// and then exits or re-raises the panic, so buffered records are not lost on crashes:
+//
+//	defer log.InstallCrashHandler(logger)()
+func InstallCrashHandler(logger Logger, opts ...CrashOption) func() {
+	options := crashOptions{exitCode: 2, exit: Exit}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		// Errors are logged as such, so WithErrorCauses lists what they wrap
+		var panicValue any = fmt.Sprint(recovered)
+		if err, ok := recovered.(error); ok {
+			panicValue = err
+		}
+		keysAndValues := []any{"panic", panicValue, panicStackKey, string(debug.Stack())}
+		if fatal, ok := logger.(fatalLogger); ok {
+			fatal.logFatal("Panic recovered", keysAndValues)
+		} else {
+			logger.Error("Panic recovered", keysAndValues...)
+		}
+
+		if syncer, ok := logger.(Syncer); ok {
+			syncer.Sync()
+		}
+
+		if options.repanic {
+			panic(recovered)
+		}
+		options.exit(options.exitCode)
+	}
+}
+
+// Go runs fn in a new goroutine protected by the crash handler
+func Go(logger Logger, fn func(), opts ...CrashOption) {
+	handler := InstallCrashHandler(logger, opts...)
+	go func() {
+		defer handler()
+		fn()
+	}()
+}
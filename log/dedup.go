@@ -0,0 +1,118 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// repeatedKey is the field of the record summarizing identical records suppressed by deduplication
+const repeatedKey = "repeated"
+
+// WithDeduplication collapses bursts of identical consecutive records, e.g. an error logged thousands
+// of times per second, keeping incident logs readable. The first record is written, identical records
+// following it within the window are counted, and one more record with a repeated=N field is written
+// when the window ends, a different record is logged or the logger is synced. Records are identical
+// when their level, message and fields, including the fields of the logger, are equal. Fatal and
+// panic records are never suppressed
+func WithDeduplication(window time.Duration) Option {
+	return func(o *options) {
+		o.dedupWindow = window
+	}
+}
+
+// deduplicator tracks the last record logged through the loggers of an adapter and the identical
+// records suppressed since
+type deduplicator struct {
+	window time.Duration
+
+	mu  sync.Mutex
+	key string
+	// until is the end of the window of the last record
+	until time.Time
+	// repeated is the number of records suppressed and summary writes the record reporting them
+	repeated int
+	summary  func(repeated int)
+	timer    *time.Timer
+}
+
+// newDeduplicator returns a deduplicator for the options, nil if deduplication is disabled
+func newDeduplicator(o *options) *deduplicator {
+	if o == nil || o.dedupWindow <= 0 {
+		return nil
+	}
+	return &deduplicator{window: o.dedupWindow}
+}
+
+// allow reports whether the record with the key should be written. A suppressed record is counted, and
+// the summary of the records suppressed before a different record is written first. The summary
+// function writes the record of the key with the repeated field, should it be suppressed later
+func (d *deduplicator) allow(key string, summary func(repeated int)) bool {
+	d.mu.Lock()
+	now := time.Now()
+	if key == d.key && now.Before(d.until) {
+		d.repeated++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.until.Sub(now), d.flush)
+		}
+		d.mu.Unlock()
+		return false
+	}
+
+	pending := d.take()
+	d.key, d.until, d.summary = key, now.Add(d.window), summary
+	d.mu.Unlock()
+
+	pending()
+	return true
+}
+
+// flush writes the summary of the suppressed records, if any
+func (d *deduplicator) flush() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	pending := d.take()
+	d.mu.Unlock()
+	pending()
+}
+
+// take returns a function writing the summary of the suppressed records and resets the count.
+// It must be called with the mutex held
+func (d *deduplicator) take() func() {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	repeated, summary := d.repeated, d.summary
+	d.repeated = 0
+	if repeated == 0 {
+		return func() {}
+	}
+	return func() { summary(repeated) }
+}
+
+// recordKey identifies a record by its level, message, the fields and groups of its logger and its own
+// key/value pairs and typed fields
+func recordKey(level int, msg string, scope []scopeStep, keysAndValues []any, fields []Field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d\x00%s", level, msg)
+	for _, step := range scope {
+		if step.group != "" {
+			fmt.Fprintf(&b, "\x00[%s]", step.group)
+		}
+		for _, value := range step.fields {
+			fmt.Fprintf(&b, "\x00%v", value)
+		}
+	}
+	b.WriteString("\x00|")
+	for _, value := range keysAndValues {
+		fmt.Fprintf(&b, "\x00%v", value)
+	}
+	for _, field := range fields {
+		fmt.Fprintf(&b, "\x00%s=%v", field.Key, field.val)
+	}
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// LevelEscalation configures the automatic escalation of the log level during incidents
+type LevelEscalation struct {
+	// Threshold is the number of error records within Window that starts an escalation
+	Threshold int
+	// Window is the period the errors are counted in, one minute when zero
+	Window time.Duration
+	// Level is the level logged at during an escalation: trace, debug or info, debug when empty
+	Level string
+	// Duration bounds an escalation, after which the configured level applies again. Five minutes when zero
+	Duration time.Duration
+	// Cooldown is how long after an escalation ended errors can't start another one
+	Cooldown time.Duration
+}
+
+// WithLevelEscalation lowers the level of all loggers of the adapter, e.g. to debug, when error records
+// are frequent, so postmortems get the detail of an incident without someone changing levels while it
+// happens. The escalation ends after its duration and is announced with a warning record. A zero
+// threshold disables escalation
+func WithLevelEscalation(escalation LevelEscalation) Option {
+	return func(o *options) {
+		o.levelEscalation = escalation
+	}
+}
+
+// escalator counts the error records of the loggers of an adapter and escalates their level
+type escalator struct {
+	config LevelEscalation
+	// until is the unix nanoseconds the current escalation ends at, 0 if there was none
+	until atomic.Int64
+
+	mu sync.Mutex
+	// errors is a ring of the times of the last Threshold error records, next is the oldest
+	errors        []time.Time
+	next          int
+	cooldownUntil time.Time
+}
+
+// newEscalator returns an escalator for the options, nil if escalation is disabled
+func newEscalator(o *options) *escalator {
+	if o == nil || o.levelEscalation.Threshold <= 0 {
+		return nil
+	}
+
+	config := o.levelEscalation
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Duration <= 0 {
+		config.Duration = 5 * time.Minute
+	}
+	if _, ok := parseSlogLevel(config.Level); !ok || config.Level == "panic" {
+		config.Level = "debug"
+	}
+	return &escalator{config: config, errors: make([]time.Time, 0, config.Threshold)}
+}
+
+// active reports whether an escalation is in progress
+func (e *escalator) active() bool {
+	return e != nil && time.Now().UnixNano() < e.until.Load()
+}
+
+// slogLevel returns the slog level of an escalation in progress
+func (e *escalator) slogLevel() (slog.Level, bool) {
+	if !e.active() {
+		return 0, false
+	}
+	return parseSlogLevel(e.config.Level)
+}
+
+// zerologLevel returns the zerolog level of an escalation in progress
+func (e *escalator) zerologLevel() (zerolog.Level, bool) {
+	if !e.active() {
+		return zerolog.NoLevel, false
+	}
+	return parseZerologLevel(e.config.Level)
+}
+
+// recordError counts an error record and reports whether it started an escalation. Errors logged
+// during an escalation or its cooldown are not counted
+func (e *escalator) recordError() bool {
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if now.UnixNano() < e.until.Load() || now.Before(e.cooldownUntil) {
+		return false
+	}
+	if len(e.errors) < e.config.Threshold {
+		e.errors = append(e.errors, now)
+		if len(e.errors) < e.config.Threshold {
+			return false
+		}
+	} else {
+		e.errors[e.next] = now
+		e.next = (e.next + 1) % e.config.Threshold
+	}
+
+	// The oldest of the last Threshold errors is the next one to be replaced
+	if now.Sub(e.errors[e.next]) > e.config.Window {
+		return false
+	}
+	until := now.Add(e.config.Duration)
+	e.until.Store(until.UnixNano())
+	e.cooldownUntil = until.Add(e.config.Cooldown)
+	e.errors, e.next = e.errors[:0], 0
+	return true
+}
+
+// escalationFields are the fields of the record announcing an escalation
+func (e *escalator) escalationFields() []any {
+	return []any{"escalated_level", e.config.Level, "errors", e.config.Threshold, "window", e.config.Window.String(),
+		"duration", e.config.Duration.String()}
+}
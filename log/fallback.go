@@ -0,0 +1,152 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// RetryPolicy computes the delay before the next attempt to write to a failed primary sink.
+// Attempts count from 0 after the first failure, as in the retrier package, whose policies satisfy it,
+// e.g. retrier.NewExponentialBackoffPolicy
+type RetryPolicy interface {
+	NextDelay(attempt int) time.Duration
+}
+
+// exponentialRetry doubles the delay after every failed attempt up to a maximum
+type exponentialRetry struct {
+	base time.Duration
+	max  time.Duration
+}
+
+func (p exponentialRetry) NextDelay(attempt int) time.Duration {
+	delay := p.base
+	for i := 0; i < attempt && delay < p.max; i++ {
+		delay *= 2
+	}
+	return min(delay, p.max)
+}
+
+// FallbackStats counts the writes of a FallbackWriter
+type FallbackStats struct {
+	// WriteFailures is the number of failed writes to the primary sink
+	WriteFailures uint64
+	// FallbackWrites is the number of records written to the fallback instead of the primary
+	FallbackWrites uint64
+	// Recoveries is the number of times the primary sink recovered after failing
+	Recoveries uint64
+	// Failing reports whether records currently go to the fallback
+	Failing bool
+}
+
+// FallbackOption configures a FallbackWriter
+type FallbackOption func(*FallbackWriter)
+
+// WithFallback sets the writer used while the primary sink fails, os.Stderr by default
+func WithFallback(w io.Writer) FallbackOption {
+	return func(o *FallbackWriter) {
+		o.fallback = w
+	}
+}
+
+// WithRetryPolicy sets when the primary sink is retried after failing,
+// by default after 1s doubling up to 1m
+func WithRetryPolicy(policy RetryPolicy) FallbackOption {
+	return func(o *FallbackWriter) {
+		o.policy = policy
+	}
+}
+
+// FallbackWriter writes to a primary sink such as a file or network connection and switches to
+// a fallback writer with a warning when a write fails, instead of dropping records. The primary
+// is retried according to the retry policy and used again once a write succeeds
+type FallbackWriter struct {
+	primary  io.Writer
+	fallback io.Writer
+	policy   RetryPolicy
+	now      func() time.Time
+
+	mu       sync.Mutex
+	failing  bool
+	attempts int
+	retryAt  time.Time
+	stats    FallbackStats
+}
+
+// NewFallbackWriter wraps the primary sink, to be passed as the writer of NewLogger
+func NewFallbackWriter(primary io.Writer, opts ...FallbackOption) *FallbackWriter {
+	w := &FallbackWriter{
+		primary:  primary,
+		fallback: os.Stderr,
+		policy:   exponentialRetry{base: time.Second, max: time.Minute},
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	trackSink(w, 0)
+	return w
+}
+
+// Write writes the record to the primary sink, or to the fallback while the primary is failing
+func (o *FallbackWriter) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.failing && o.now().Before(o.retryAt) {
+		return o.writeFallback(p)
+	}
+
+	n, err := o.primary.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		if o.failing {
+			o.failing = false
+			o.attempts = 0
+			o.stats.Recoveries++
+			fmt.Fprintf(o.fallback, "log: primary sink recovered after %d failed writes\n", o.stats.WriteFailures)
+		}
+		return n, nil
+	}
+
+	o.stats.WriteFailures++
+	if !o.failing {
+		o.failing = true
+		fmt.Fprintf(o.fallback, "log: primary sink write failed, falling back: %v\n", err)
+	}
+	o.retryAt = o.now().Add(o.policy.NextDelay(o.attempts))
+	o.attempts++
+	return o.writeFallback(p)
+}
+
+// writeFallback writes the record to the fallback. It is used with mu held
+func (o *FallbackWriter) writeFallback(p []byte) (int, error) {
+	o.stats.FallbackWrites++
+	return o.fallback.Write(p)
+}
+
+// Stats returns the write counters
+func (o *FallbackWriter) Stats() FallbackStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stats := o.stats
+	stats.Failing = o.failing
+	return stats
+}
+
+// Sync flushes both writers if they support it
+func (o *FallbackWriter) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	primaryErr := syncWriter(o.primary)
+	if err := syncWriter(o.fallback); err != nil {
+		return err
+	}
+	return primaryErr
+}
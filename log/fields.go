@@ -0,0 +1,184 @@
+package log
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fieldKind is the type of a Field value
+type fieldKind uint8
+
+const (
+	stringField fieldKind = iota
+	intField
+	uintField
+	floatField
+	boolField
+	durationField
+	timeField
+	errorField
+	anyField
+)
+
+// Field is a typed key/value pair created by String, Int, Err and the other constructors. Fields are
+// written by FieldLogger without reflection or type switches on interface values
+type Field struct {
+	Key  string
+	kind fieldKind
+	str  string
+	// num holds integers, durations and booleans and the bits of unsigned integers and floats
+	num int64
+	tm  time.Time
+	val any
+}
+
+// String creates a string field
+func String(key, value string) Field {
+	return Field{Key: key, kind: stringField, str: value}
+}
+
+// Int creates an integer field
+func Int(key string, value int) Field {
+	return Field{Key: key, kind: intField, num: int64(value)}
+}
+
+// Int64 creates a 64-bit integer field
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: intField, num: value}
+}
+
+// Uint64 creates an unsigned integer field
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, kind: uintField, num: int64(value)}
+}
+
+// Float64 creates a floating-point field
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: floatField, num: int64(math.Float64bits(value))}
+}
+
+// Bool creates a boolean field
+func Bool(key string, value bool) Field {
+	f := Field{Key: key, kind: boolField}
+	if value {
+		f.num = 1
+	}
+	return f
+}
+
+// Duration creates a duration field, written like durations passed as key/value pairs
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: durationField, num: int64(value)}
+}
+
+// Time creates a timestamp field
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, kind: timeField, tm: value}
+}
+
+// Err creates an "error" field with the error message. Nil errors are omitted
+func Err(err error) Field {
+	return Field{Key: "error", kind: errorField, val: err}
+}
+
+// Any creates a field of any type, written like values passed as key/value pairs
+func Any(key string, value any) Field {
+	return Field{Key: key, kind: anyField, val: value}
+}
+
+// FieldLogger is a type-safe alternative to the key/value API of Logger: keys and values are checked
+// by the compiler and written without reflection. Loggers created by NewLogger implement it
+type FieldLogger interface {
+	Logger
+	DebugF(msg string, fields ...Field)
+	InfoF(msg string, fields ...Field)
+	WarnF(msg string, fields ...Field)
+	ErrorF(msg string, fields ...Field)
+}
+
+// truncateTypedFields is truncateFields for typed fields, cutting long string values
+func truncateTypedFields(fields []Field, max int) []Field {
+	if max <= 0 {
+		return fields
+	}
+
+	var truncated []Field
+	for i, field := range fields {
+		if field.kind != stringField {
+			continue
+		}
+		value, ok := truncateValue(field.str, max)
+		if !ok {
+			continue
+		}
+		if truncated == nil {
+			truncated = append(make([]Field, 0, len(fields)+1), fields...)
+		}
+		truncated[i].str = value
+	}
+
+	if truncated == nil {
+		return fields
+	}
+	return append(truncated, Bool(truncatedKey, true))
+}
+
+// slogAttr converts the field to a slog attribute
+func (f Field) slogAttr() slog.Attr {
+	switch f.kind {
+	case stringField:
+		return slog.String(f.Key, f.str)
+	case intField:
+		return slog.Int64(f.Key, f.num)
+	case uintField:
+		return slog.Uint64(f.Key, uint64(f.num))
+	case floatField:
+		return slog.Float64(f.Key, math.Float64frombits(uint64(f.num)))
+	case boolField:
+		return slog.Bool(f.Key, f.num == 1)
+	case durationField:
+		return slog.Duration(f.Key, time.Duration(f.num))
+	case timeField:
+		return slog.Time(f.Key, f.tm)
+	case errorField:
+		if f.val == nil {
+			// Empty attributes are ignored by handlers
+			return slog.Attr{}
+		}
+		return slog.Any(f.Key, f.val)
+	default:
+		return slog.Any(f.Key, f.val)
+	}
+}
+
+// addTypedFields adds the fields to a zerolog event or dictionary
+func addTypedFields(event *zerolog.Event, fields []Field) *zerolog.Event {
+	for _, f := range fields {
+		switch f.kind {
+		case stringField:
+			event = event.Str(f.Key, f.str)
+		case intField:
+			event = event.Int64(f.Key, f.num)
+		case uintField:
+			event = event.Uint64(f.Key, uint64(f.num))
+		case floatField:
+			event = event.Float64(f.Key, math.Float64frombits(uint64(f.num)))
+		case boolField:
+			event = event.Bool(f.Key, f.num == 1)
+		case durationField:
+			// Match durations passed as key/value pairs, written as integer nanoseconds
+			event = event.Int64(f.Key, f.num)
+		case timeField:
+			event = event.Time(f.Key, f.tm)
+		case errorField:
+			err, _ := f.val.(error)
+			event = event.AnErr(f.Key, err)
+		default:
+			event = addField(event, f.Key, f.val)
+		}
+	}
+	return event
+}
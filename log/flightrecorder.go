@@ -0,0 +1,135 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// flightRecorder keeps the last records suppressed by the level filter in a ring buffer,
+// so they can be written out when an error shows what led up to it
+type flightRecorder struct {
+	mu      sync.Mutex
+	records [][]byte
+	// next is the index the next record is stored at
+	next int
+	full bool
+}
+
+func newFlightRecorder(n int) *flightRecorder {
+	return &flightRecorder{records: make([][]byte, n)}
+}
+
+// Write stores a copy of a formatted record, replacing the oldest one when the buffer is full
+func (o *flightRecorder) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.records[o.next] = append(o.records[o.next][:0], p...)
+	o.next = (o.next + 1) % len(o.records)
+	if o.next == 0 {
+		o.full = true
+	}
+	return len(p), nil
+}
+
+// flush writes the stored records to w, oldest first, and empties the buffer
+func (o *flightRecorder) flush(w io.Writer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	start, count := 0, o.next
+	if o.full {
+		start, count = o.next, len(o.records)
+	}
+
+	var firstErr error
+	for i := range count {
+		record := o.records[(start+i)%len(o.records)]
+		if _, err := w.Write(record); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	o.next, o.full = 0, false
+	return firstErr
+}
+
+// flightRecorderHandler records the records the primary handler's level filter suppresses
+// with a handler accepting all levels, and flushes them to the sink before error records
+type flightRecorderHandler struct {
+	primary  slog.Handler
+	record   slog.Handler
+	recorder *flightRecorder
+	sink     io.Writer
+}
+
+func newFlightRecorderHandler(config Config, writer io.Writer, n int) *flightRecorderHandler {
+	// Suppressed records are formatted for the sink, not the recorder's buffer
+	config = withResolvedColor(config, writer)
+	recordConfig := config
+	recordConfig.Level = "trace"
+
+	recorder := newFlightRecorder(n)
+	return &flightRecorderHandler{
+		primary:  NewSlogHandler(config, writer),
+		record:   NewSlogHandler(recordConfig, recorder),
+		recorder: recorder,
+		sink:     writer,
+	}
+}
+
+func (o *flightRecorderHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return o.primary.Enabled(ctx, level) || o.record.Enabled(ctx, level)
+}
+
+func (o *flightRecorderHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !o.primary.Enabled(ctx, r.Level) {
+		return o.record.Handle(ctx, r)
+	}
+
+	if r.Level >= slog.LevelError {
+		o.recorder.flush(o.sink)
+	}
+	return o.primary.Handle(ctx, r)
+}
+
+func (o *flightRecorderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *o
+	handler.primary = o.primary.WithAttrs(attrs)
+	handler.record = o.record.WithAttrs(attrs)
+	return &handler
+}
+
+func (o *flightRecorderHandler) WithGroup(name string) slog.Handler {
+	handler := *o
+	handler.primary = o.primary.WithGroup(name)
+	handler.record = o.record.WithGroup(name)
+	return &handler
+}
+
+// flightRecorderWriter is the zerolog output when a flight recorder is enabled. The logger accepts
+// all levels and records below level are stored as JSON, to be written through out before errors
+type flightRecorderWriter struct {
+	out      io.Writer
+	level    zerolog.Level
+	recorder *flightRecorder
+}
+
+func (o *flightRecorderWriter) Write(p []byte) (int, error) {
+	return o.out.Write(p)
+}
+
+func (o *flightRecorderWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < o.level {
+		return o.recorder.Write(p)
+	}
+
+	if level >= zerolog.ErrorLevel && level < zerolog.NoLevel {
+		o.recorder.flush(o.out)
+	}
+	return o.out.Write(p)
+}
@@ -2,10 +2,15 @@ module github.com/btchead/go-reusables/log
 
 go 1.24.5
 
-require github.com/rs/zerolog v1.34.0
+require (
+	github.com/rs/zerolog v1.34.0
+	go.opentelemetry.io/otel/trace v1.40.0
+)
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
+	go.opentelemetry.io/otel v1.40.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 )
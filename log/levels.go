@@ -0,0 +1,96 @@
+package log
+
+import (
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// ExtendedLogger adds trace and panic levels to Logger. Loggers created by NewLogger implement it
+type ExtendedLogger interface {
+	Logger
+	// Trace logs below debug level, for very verbose diagnostics
+	Trace(msg string, keysAndValues ...any)
+	// Panic logs between error and fatal level, syncs the writer and panics with the message
+	Panic(msg string, keysAndValues ...any)
+}
+
+// Levels used by the slog backend for trace and panic records, so slog handlers can filter on them
+const (
+	SlogLevelTrace = slog.LevelDebug - 4
+	SlogLevelPanic = slog.LevelError + 2
+)
+
+// Trace logs at trace level if the logger supports it, and at debug level otherwise
+func Trace(logger Logger, msg string, keysAndValues ...any) {
+	if extended, ok := logger.(ExtendedLogger); ok {
+		extended.Trace(msg, keysAndValues...)
+		return
+	}
+	logger.Debug(msg, keysAndValues...)
+}
+
+// Panic logs at panic level if the logger supports it, and at error level otherwise, then panics
+func Panic(logger Logger, msg string, keysAndValues ...any) {
+	if extended, ok := logger.(ExtendedLogger); ok {
+		extended.Panic(msg, keysAndValues...)
+		return
+	}
+	logger.Error(msg, keysAndValues...)
+	panic(msg)
+}
+
+// slogLevelNames names the custom slog levels, which slog would print as DEBUG-4 and ERROR+2
+var slogLevelNames = map[slog.Level]string{
+	SlogLevelTrace: "TRACE",
+	SlogLevelPanic: "PANIC",
+}
+
+// slogLevelName returns the name of a slog level including the custom levels
+func slogLevelName(level slog.Level) string {
+	if name, ok := slogLevelNames[level]; ok {
+		return name
+	}
+	return level.String()
+}
+
+// replaceLevelName writes the names of the custom levels in the handlers' level attribute
+func replaceLevelName(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(slogLevelName(level))
+		}
+	}
+	return a
+}
+
+// parseSlogLevel returns the slog level of a level name
+func parseSlogLevel(name string) (slog.Level, bool) {
+	switch name {
+	case "trace":
+		return SlogLevelTrace, true
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	case "panic":
+		return SlogLevelPanic, true
+	default:
+		return 0, false
+	}
+}
+
+// parseZerologLevel returns the zerolog level of a level name
+func parseZerologLevel(name string) (zerolog.Level, bool) {
+	switch name {
+	case "trace", "debug", "info", "warn", "error", "panic":
+		level, err := zerolog.ParseLevel(name)
+		return level, err == nil
+	default:
+		return zerolog.NoLevel, false
+	}
+}
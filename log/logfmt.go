@@ -0,0 +1,107 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// logfmtWriter converts the JSON records written by zerolog to logfmt lines,
+// flattening nested dictionaries from groups into dotted keys like the slog text handler
+type logfmtWriter struct {
+	out io.Writer
+}
+
+// logfmtField is a key and its already formatted value
+type logfmtField struct {
+	key   string
+	value string
+}
+
+func (w *logfmtWriter) Write(p []byte) (int, error) {
+	fields, err := logfmtFields(nil, "", p)
+	if err != nil {
+		// Not a JSON record, pass it through unchanged
+		return w.out.Write(p)
+	}
+
+	var buf strings.Builder
+	// Match the slog text handler layout: time, level and msg first
+	for _, key := range []string{zerologTimeKey, zerologLevelKey, zerologMessageKey} {
+		for _, field := range fields {
+			if field.key == key {
+				appendLogfmtField(&buf, field)
+			}
+		}
+	}
+	for _, field := range fields {
+		switch field.key {
+		case zerologTimeKey, zerologLevelKey, zerologMessageKey:
+		default:
+			appendLogfmtField(&buf, field)
+		}
+	}
+	buf.WriteString("\n")
+
+	if _, err := io.WriteString(w.out, buf.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Keys of the fields written by zerolog for every record
+const (
+	zerologTimeKey    = "time"
+	zerologLevelKey   = "level"
+	zerologMessageKey = "message"
+)
+
+func appendLogfmtField(buf *strings.Builder, field logfmtField) {
+	if buf.Len() > 0 {
+		buf.WriteString(" ")
+	}
+	key := field.key
+	if key == zerologMessageKey {
+		key = "msg"
+	}
+	buf.WriteString(key + "=" + field.value)
+}
+
+// logfmtFields decodes a JSON object into fields in document order, prefixing nested keys
+func logfmtFields(fields []logfmtField, prefix string, data []byte) ([]logfmtField, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		switch raw[0] {
+		case '{':
+			if fields, err = logfmtFields(fields, prefix+key+".", raw); err != nil {
+				return nil, err
+			}
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, err
+			}
+			fields = append(fields, logfmtField{key: prefix + key, value: quoteIfNeeded(s)})
+		default:
+			// Numbers, booleans, null and arrays keep their JSON form
+			fields = append(fields, logfmtField{key: prefix + key, value: quoteIfNeeded(string(raw))})
+		}
+	}
+	return fields, nil
+}
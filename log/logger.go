@@ -12,7 +12,14 @@ type Logger interface {
 	Error(msg string, keysAndValues ...any)
 	Fatal(msg string, keysAndValues ...any)
 	With(keysAndValues ...any) Logger
+	WithGroup(name string) Logger
 	WithContext(ctx context.Context) Logger
+	// Without returns a child logger without the inherited fields named by keys,
+	// e.g. to drop a large payload field added upstream
+	Without(keys ...string) Logger
+	// ForTenant returns a child logger adding the tenant ID as tenant_id, logging at the tenant's
+	// level override if one is set in the table passed to WithTenantLevels
+	ForTenant(id string) Logger
 }
 
 type logger struct {
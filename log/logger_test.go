@@ -1,10 +1,30 @@
 package log_test
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/slogtest"
+	"time"
 
 	"github.com/btchead/go-reusables/log"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func Test_Logger(t *testing.T) {
@@ -63,3 +83,1183 @@ func Test_Logger(t *testing.T) {
 		zerologLogger.Info("Info message")
 	})
 }
+
+func Test_LoggerWithGroup(t *testing.T) {
+	config := log.Config{
+		Level:  "info",
+		Format: "json",
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, config, &buf)
+
+			logger.WithGroup("http").With("method", "GET").Info("request", "status", 200)
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("Failed to decode record %q: %v", buf.String(), err)
+			}
+
+			group, ok := record["http"].(map[string]any)
+			if !ok {
+				t.Fatalf("Expected nested 'http' object, got %q", buf.String())
+			}
+
+			if group["method"] != "GET" || group["status"] != float64(200) {
+				t.Errorf("Unexpected group contents: %v", group)
+			}
+		})
+	}
+}
+
+func Test_LoggerWithout(t *testing.T) {
+	config := log.Config{
+		Level:  "info",
+		Format: "json",
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, config, &buf, log.WithAppName("api")).
+				With("request_id", "42", "payload", strings.Repeat("x", 100)).
+				WithGroup("http").
+				With("method", "GET", "payload", "body")
+
+			logger.Without("payload").Info("request", "status", 200)
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("Failed to decode record %q: %v", buf.String(), err)
+			}
+			group, ok := record["http"].(map[string]any)
+			if !ok {
+				t.Fatalf("Expected nested 'http' object, got %q", buf.String())
+			}
+			if _, ok := record["payload"]; ok || group["payload"] != nil {
+				t.Errorf("Expected payload removed at every level, got %q", buf.String())
+			}
+			if record["request_id"] != "42" || record["appName"] != "api" || group["method"] != "GET" || group["status"] != float64(200) {
+				t.Errorf("Expected the other fields kept, got %q", buf.String())
+			}
+		})
+	}
+}
+
+func Test_LoggerStacktraceLevel(t *testing.T) {
+	config := log.Config{
+		Level:           "info",
+		Format:          "json",
+		StacktraceLevel: "error",
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, config, &buf)
+
+			logger.Info("info message")
+			logger.Error("error message")
+
+			lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 records, got %d", len(lines))
+			}
+
+			var info, failure map[string]any
+			if err := json.Unmarshal(lines[0], &info); err != nil {
+				t.Fatalf("Failed to decode record: %v", err)
+			}
+			if err := json.Unmarshal(lines[1], &failure); err != nil {
+				t.Fatalf("Failed to decode record: %v", err)
+			}
+
+			if _, ok := info["stacktrace"]; ok {
+				t.Error("Info record should not carry a stack trace")
+			}
+
+			stack, _ := failure["stacktrace"].(string)
+			if !strings.Contains(stack, "Test_LoggerStacktraceLevel") {
+				t.Errorf("Expected stack trace starting at the caller, got %q", stack)
+			}
+			if strings.Contains(stack, "zerologLogger") || strings.Contains(stack, "slogLogger") {
+				t.Errorf("Stack trace should not include adapter frames, got %q", stack)
+			}
+		})
+	}
+}
+
+func Test_SlogHandlerCompliance(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := log.NewSlogHandler(log.Config{Level: "info", Format: "json"}, &buf)
+
+		slogtest.Run(t, func(t *testing.T) slog.Handler {
+			buf.Reset()
+			return handler
+		}, func(t *testing.T) map[string]any {
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("Failed to parse JSON output %q: %v", buf.String(), err)
+			}
+			return record
+		})
+	})
+
+	t.Run("colored console", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler := log.NewSlogHandler(log.Config{Level: "info", Format: "console", Colored: true}, &buf)
+
+		slogtest.Run(t, func(t *testing.T) slog.Handler {
+			buf.Reset()
+			return handler
+		}, func(t *testing.T) map[string]any {
+			return parseColoredLine(t, buf.String())
+		})
+	})
+}
+
+func Test_ColoredConsoleKeepsWithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.SlogType, log.Config{Level: "info", Format: "console", Colored: true}, &buf, log.WithAppName("test"))
+
+	logger.With("request_id", "abc").WithGroup("http").Info("request", "status", 200)
+
+	output := buf.String()
+	for _, want := range []string{"appName=test", "request_id=abc", "http.status=200", `msg="request"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, output)
+		}
+	}
+}
+
+var coloredFieldPattern = regexp.MustCompile(`([^\s=]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+
+// parseColoredLine parses colored key=value output into nested maps, dotted keys becoming groups
+func parseColoredLine(t *testing.T, line string) map[string]any {
+	line = regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(strings.TrimSpace(line), "")
+
+	record := map[string]any{}
+	for _, match := range coloredFieldPattern.FindAllStringSubmatch(line, -1) {
+		value := match[2]
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				t.Fatalf("Failed to unquote %s in %q: %v", value, line, err)
+			}
+			value = unquoted
+		}
+
+		keys := strings.Split(match[1], ".")
+		group := record
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := group[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				group[key] = next
+			}
+			group = next
+		}
+		group[keys[len(keys)-1]] = value
+	}
+	return record
+}
+
+type tenantKey struct{}
+
+func Test_ContextExtractor(t *testing.T) {
+	log.RegisterContextExtractor(func(ctx context.Context) []any {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []any{"tenant", tenant}
+		}
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	for _, loggerType := range []log.LoggerType{log.ZeroLogType, log.SlogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf)
+
+			logger.WithContext(ctx).Info("request")
+			logger.WithContext(context.Background()).Info("background")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 log lines, got %d", len(lines))
+			}
+
+			var withTenant, without map[string]any
+			json.Unmarshal([]byte(lines[0]), &withTenant)
+			json.Unmarshal([]byte(lines[1]), &without)
+
+			if withTenant["tenant"] != "acme" {
+				t.Errorf("Expected tenant 'acme', got %v", withTenant["tenant"])
+			}
+			if _, ok := without["tenant"]; ok {
+				t.Error("Expected no tenant field for a context without tenant")
+			}
+		})
+	}
+}
+
+// syncBuffer records whether it was synced
+type syncBuffer struct {
+	bytes.Buffer
+	synced bool
+}
+
+func (b *syncBuffer) Sync() error {
+	b.synced = true
+	return nil
+}
+
+func Test_CrashHandler(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.ZeroLogType, log.SlogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf syncBuffer
+			logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf)
+
+			recovered := func() (recovered any) {
+				defer func() { recovered = recover() }()
+				defer log.InstallCrashHandler(logger, log.WithRepanic())()
+				panic("boom")
+			}()
+
+			if recovered != "boom" {
+				t.Errorf("Expected panic to be re-raised, got %v", recovered)
+			}
+			if !buf.synced {
+				t.Error("Expected writer to be synced")
+			}
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("Failed to parse output %q: %v", buf.String(), err)
+			}
+			if record["panic"] != "boom" {
+				t.Errorf("Expected panic field 'boom', got %v", record["panic"])
+			}
+			if stack, _ := record["panic_stacktrace"].(string); !strings.Contains(stack, "Test_CrashHandler") {
+				t.Errorf("Expected stack of the panicking goroutine, got %q", stack)
+			}
+		})
+	}
+}
+
+func Test_MaxFieldBytes(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.ZeroLogType, log.SlogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf, log.WithMaxFieldBytes(8))
+
+			logger.Info("query", "sql", "SELECT * FROM users", "body", []byte("héllo wörld"), "short", "ok")
+			logger.Info("small", "short", "ok")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			var large, small map[string]any
+			json.Unmarshal([]byte(lines[0]), &large)
+			json.Unmarshal([]byte(lines[1]), &small)
+
+			if large["sql"] != "SELECT *…" {
+				t.Errorf("Expected truncated sql 'SELECT *…', got %v", large["sql"])
+			}
+			if large["body"] != "héllo w…" {
+				t.Errorf("Expected body truncated on a rune boundary, got %v", large["body"])
+			}
+			if large["short"] != "ok" || large["truncated"] != true {
+				t.Errorf("Expected short value kept and truncated=true, got %v", large)
+			}
+			if _, ok := small["truncated"]; ok {
+				t.Error("Expected no truncated field when nothing was cut")
+			}
+		})
+	}
+}
+
+type flakyWriter struct {
+	fail bool
+	buf  bytes.Buffer
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("disk full")
+	}
+	return w.buf.Write(p)
+}
+
+type fixedDelay time.Duration
+
+func (d fixedDelay) NextDelay(attempt int) time.Duration { return time.Duration(d) }
+
+func Test_FallbackWriter(t *testing.T) {
+	primary := &flakyWriter{fail: true}
+	var fallback bytes.Buffer
+	writer := log.NewFallbackWriter(primary, log.WithFallback(&fallback), log.WithRetryPolicy(fixedDelay(20*time.Millisecond)))
+	logger := log.NewLogger(log.SlogType, log.Config{Level: "info", Format: "json"}, writer)
+
+	logger.Info("first")
+	logger.Info("second")
+
+	stats := writer.Stats()
+	if !stats.Failing || stats.WriteFailures != 1 || stats.FallbackWrites != 2 {
+		t.Errorf("Expected one failure and two fallback writes, got %+v", stats)
+	}
+	if !strings.Contains(fallback.String(), "falling back: disk full") || !strings.Contains(fallback.String(), `"second"`) {
+		t.Errorf("Expected warning and records on fallback, got %q", fallback.String())
+	}
+
+	primary.fail = false
+	time.Sleep(30 * time.Millisecond)
+	logger.Info("third")
+
+	stats = writer.Stats()
+	if stats.Failing || stats.Recoveries != 1 {
+		t.Errorf("Expected primary to recover, got %+v", stats)
+	}
+	if !strings.Contains(primary.buf.String(), `"third"`) {
+		t.Errorf("Expected record on primary after recovery, got %q", primary.buf.String())
+	}
+}
+
+// attemptRecorder records the attempts it is asked for, without delaying
+type attemptRecorder struct {
+	attempts []int
+}
+
+func (o *attemptRecorder) NextDelay(attempt int) time.Duration {
+	o.attempts = append(o.attempts, attempt)
+	return 0
+}
+
+func Test_FallbackWriterAttempts(t *testing.T) {
+	policy := &attemptRecorder{}
+	writer := log.NewFallbackWriter(&flakyWriter{fail: true}, log.WithFallback(io.Discard), log.WithRetryPolicy(policy))
+
+	for range 3 {
+		writer.Write([]byte("record\n"))
+	}
+
+	if !slices.Equal(policy.attempts, []int{0, 1, 2}) {
+		t.Errorf("Expected attempts counted from 0 like the retrier package, got %v", policy.attempts)
+	}
+}
+
+func Test_LogfmtFormat(t *testing.T) {
+	config := log.Config{Level: "info", Format: "logfmt"}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, config, &buf).WithGroup("http")
+			logger.Info("request handled", "path", "/users list", "status", 200)
+
+			line := strings.TrimSpace(buf.String())
+			if !strings.HasPrefix(line, "time=") {
+				t.Errorf("Expected line to start with time, got %q", line)
+			}
+			for _, want := range []string{`level=`, `msg="request handled"`, `http.path="/users list"`, `http.status=200`} {
+				if !strings.Contains(line, want) {
+					t.Errorf("Expected %s in %q", want, line)
+				}
+			}
+		})
+	}
+}
+
+func Test_TraceAndPanicLevels(t *testing.T) {
+	config := log.Config{Level: "trace", Format: "json"}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, config, &buf)
+
+			log.Trace(logger, "verbose")
+			func() {
+				defer func() {
+					if recovered := recover(); recovered != "boom" {
+						t.Errorf("Expected panic with message, got %v", recovered)
+					}
+				}()
+				log.Panic(logger, "boom")
+			}()
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 records, got %q", buf.String())
+			}
+			for i, want := range []string{"trace", "panic"} {
+				var record map[string]any
+				if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+					t.Fatalf("Invalid JSON: %v", err)
+				}
+				if got := strings.ToLower(record["level"].(string)); got != want {
+					t.Errorf("Expected level %s, got %s", want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("mapping", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := log.NewLogger(log.SlogType, log.Config{Level: "info", Format: "json"}, &buf,
+			log.WithLevelMapping(map[string]string{"trace": "info"}))
+
+		log.Trace(logger, "promoted")
+		if !strings.Contains(buf.String(), `"level":"INFO"`) {
+			t.Errorf("Expected trace record emitted at info, got %q", buf.String())
+		}
+	})
+}
+
+func BenchmarkLogger(b *testing.B) {
+	config := log.Config{Level: "info", Format: "json"}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		b.Run(string(loggerType), func(b *testing.B) {
+			logger := log.NewLogger(loggerType, config, io.Discard)
+			b.ReportAllocs()
+			for b.Loop() {
+				logger.Info("request handled", "path", "/users", "status", 200, "cached", true, "latency", 1500*time.Microsecond)
+			}
+		})
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		b.Run(string(loggerType)+"-fields", func(b *testing.B) {
+			logger := log.NewLogger(loggerType, config, io.Discard).(log.FieldLogger)
+			b.ReportAllocs()
+			for b.Loop() {
+				logger.InfoF("request handled", log.String("path", "/users"), log.Int("status", 200), log.Bool("cached", true), log.Duration("latency", 1500*time.Microsecond))
+			}
+		})
+	}
+
+	b.Run("zerolog-direct", func(b *testing.B) {
+		logger := zerolog.New(io.Discard).With().Timestamp().Logger()
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Info().Str("path", "/users").Int("status", 200).Bool("cached", true).Dur("latency", 1500*time.Microsecond).Msg("request handled")
+		}
+	})
+
+	b.Run("disabled", func(b *testing.B) {
+		logger := log.NewLogger(log.SlogType, config, io.Discard)
+		b.ReportAllocs()
+		for b.Loop() {
+			logger.Debug("request handled", "path", "/users", "status", 200)
+		}
+	})
+}
+
+func Test_FlightRecorder(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.NewLogger(loggerType, log.Config{Level: "warn", Format: "json"}, &buf, log.WithFlightRecorder(2))
+
+			logger.Debug("first")
+			logger.Info("second", "step", 2)
+			logger.With("request", "r1").Debug("third")
+			logger.Warn("warning")
+			if got := buf.String(); strings.Contains(got, "second") || !strings.Contains(got, "warning") {
+				t.Fatalf("Expected only the warning before an error, got %q", got)
+			}
+
+			logger.Error("failed")
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 4 {
+				t.Fatalf("Expected 4 records, got %d: %q", len(lines), buf.String())
+			}
+			for i, want := range []string{"warning", "second", "third", "failed"} {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("Expected record %d to be %q, got %q", i, want, lines[i])
+				}
+			}
+			if !strings.Contains(lines[2], `"request":"r1"`) {
+				t.Errorf("Expected recorded fields to be kept, got %q", lines[2])
+			}
+
+			// The buffer is emptied by the flush
+			buf.Reset()
+			logger.Error("failed again")
+			if strings.Count(buf.String(), "\n") != 1 {
+				t.Errorf("Expected only the error record, got %q", buf.String())
+			}
+		})
+	}
+}
+
+func Test_NetworkWriter(t *testing.T) {
+	t.Run("tcp gelf", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		defer listener.Close()
+
+		received := make(chan []byte, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			message, _ := bufio.NewReader(conn).ReadBytes(0)
+			received <- message
+		}()
+
+		writer := log.NewNetworkWriter("tcp", listener.Addr().String(), log.WithGELF("web-1"))
+		logger := log.NewLogger(log.ZeroLogType, log.Config{Level: "info", Format: "json"}, writer)
+		logger.Error("payment failed", "order", 42, "id", "abc")
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		var message map[string]any
+		select {
+		case data := <-received:
+			if err := json.Unmarshal(bytes.TrimRight(data, "\x00"), &message); err != nil {
+				t.Fatalf("Invalid GELF message %q: %v", data, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("No message received")
+		}
+
+		expected := map[string]any{
+			"version":       "1.1",
+			"host":          "web-1",
+			"short_message": "payment failed",
+			"level":         float64(3),
+			"_order":        float64(42),
+			"_id_":          "abc",
+		}
+		for key, want := range expected {
+			if message[key] != want {
+				t.Errorf("Expected %s to be %v, got %v", key, want, message[key])
+			}
+		}
+		if stats := writer.Stats(); stats.Written != 1 || stats.Dropped != 0 {
+			t.Errorf("Unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("drops when unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		address := listener.Addr().String()
+		listener.Close()
+
+		writer := log.NewNetworkWriter("tcp", address,
+			log.WithBufferSize(1),
+			log.WithFlushTimeout(10*time.Millisecond),
+		)
+		for range 10 {
+			writer.Write([]byte("record\n"))
+		}
+		if err := writer.Sync(); !errors.Is(err, log.ErrSyncTimeout) {
+			t.Errorf("Expected ErrSyncTimeout, got %v", err)
+		}
+		writer.Close()
+
+		if stats := writer.Stats(); stats.Dropped != 10 || stats.WriteErrors == 0 {
+			t.Errorf("Expected all records dropped after write errors, got %+v", stats)
+		}
+		if _, err := writer.Write([]byte("late\n")); !errors.Is(err, log.ErrSinkClosed) {
+			t.Errorf("Expected ErrSinkClosed, got %v", err)
+		}
+	})
+
+	t.Run("counts reconnect attempts from 0", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		address := listener.Addr().String()
+		listener.Close()
+
+		policy := &attemptRecorder{}
+		writer := log.NewNetworkWriter("tcp", address, log.WithReconnectPolicy(policy), log.WithFlushTimeout(20*time.Millisecond))
+		writer.Write([]byte("record\n"))
+		writer.Close()
+
+		if len(policy.attempts) < 2 || policy.attempts[0] != 0 || policy.attempts[1] != 1 {
+			t.Errorf("Expected attempts counted from 0 like the retrier package, got %v", policy.attempts)
+		}
+	})
+
+	t.Run("reports unframeable records", func(t *testing.T) {
+		reported := make(chan error, 1)
+		handler := func(err error, lost []byte) { reported <- err }
+
+		writer := log.NewNetworkWriter("udp", "127.0.0.1:9", log.WithGELF("web-1"))
+		defer writer.Close()
+		logger := log.NewLogger(log.SlogType, log.Config{Level: "info", Format: "json"}, writer, log.WithWriteErrorHandler(handler))
+
+		// Too large for the 128 chunks GELF allows over UDP
+		logger.Info(strings.Repeat("x", 2<<20))
+		if err := writer.Sync(); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+
+		select {
+		case err := <-reported:
+			if !strings.Contains(err.Error(), "exceeds 128 chunks") {
+				t.Errorf("Unexpected error %v", err)
+			}
+		default:
+			t.Fatal("Expected the dropped record to be reported")
+		}
+		if stats := writer.Stats(); stats.Dropped != 1 || stats.Written != 0 {
+			t.Errorf("Expected the record dropped, got %+v", stats)
+		}
+	})
+}
+
+func Test_FieldLogger(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var typed, untyped bytes.Buffer
+			config := log.Config{Level: "debug", Format: "json"}
+			fieldLogger := log.NewLogger(loggerType, config, &typed, log.WithMaxFieldBytes(8)).WithGroup("http").(log.FieldLogger)
+			logger := log.NewLogger(loggerType, config, &untyped, log.WithMaxFieldBytes(8)).WithGroup("http")
+
+			fieldLogger.InfoF("request",
+				log.String("path", "/users/1234567890"),
+				log.Int("status", 200),
+				log.Uint64("bytes", 512),
+				log.Float64("ratio", 0.5),
+				log.Bool("cached", true),
+				log.Duration("latency", 1500*time.Microsecond),
+				log.Err(errors.New("boom")),
+				log.Err(nil),
+				log.Any("tags", []string{"a"}),
+			)
+			logger.Info("request",
+				"path", "/users/1234567890",
+				"status", 200,
+				"bytes", uint64(512),
+				"ratio", 0.5,
+				"cached", true,
+				"latency", 1500*time.Microsecond,
+				"error", errors.New("boom"),
+				"tags", []string{"a"},
+			)
+
+			// Typed fields are written like the equivalent key/value pairs, apart from the timestamp
+			var got, want map[string]any
+			if err := json.Unmarshal(typed.Bytes(), &got); err != nil {
+				t.Fatalf("Invalid JSON %q: %v", typed.String(), err)
+			}
+			if err := json.Unmarshal(untyped.Bytes(), &want); err != nil {
+				t.Fatalf("Invalid JSON %q: %v", untyped.String(), err)
+			}
+			delete(got, "time")
+			delete(want, "time")
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+// syncCounter is a sink counting its syncs
+type syncCounter struct {
+	bytes.Buffer
+	mu    sync.Mutex
+	syncs int
+}
+
+func (o *syncCounter) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.Buffer.Write(p)
+}
+
+func (o *syncCounter) Sync() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.syncs++
+	return nil
+}
+
+func (o *syncCounter) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.syncs
+}
+
+func Test_SyncAll(t *testing.T) {
+	slogSink, zerologSink := &syncCounter{}, &syncCounter{}
+	log.NewLogger(log.SlogType, log.Config{Level: "info", Format: "json"}, slogSink).Info("hello")
+	log.NewLogger(log.ZeroLogType, log.Config{Level: "info", Format: "json"}, zerologSink).Info("hello")
+
+	if err := log.SyncAll(); err != nil {
+		t.Fatalf("SyncAll failed: %v", err)
+	}
+	if slogSink.count() != 1 || zerologSink.count() != 1 {
+		t.Errorf("Expected each sink synced once, got %d and %d", slogSink.count(), zerologSink.count())
+	}
+
+	autoSink := &syncCounter{}
+	log.NewLogger(log.ZeroLogType, log.Config{Level: "info"}, autoSink, log.WithAutoSync(5*time.Millisecond))
+	deadline := time.Now().Add(time.Second)
+	for autoSink.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if autoSink.count() < 2 {
+		t.Errorf("Expected periodic syncs, got %d", autoSink.count())
+	}
+}
+
+func Test_LoggerClose(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			sink := &syncCounter{}
+			logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, sink, log.WithAutoSync(time.Millisecond))
+			shared := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, sink)
+			deadline := time.Now().Add(time.Second)
+			for sink.count() < 1 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+
+			// The writer stays tracked while another logger uses it
+			if err := logger.(io.Closer).Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			before := sink.count()
+			log.SyncAll()
+			if sink.count() == before {
+				t.Error("Expected the writer of the open logger to be synced")
+			}
+
+			if err := shared.(io.Closer).Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			before = sink.count()
+			time.Sleep(10 * time.Millisecond)
+			log.SyncAll()
+			if sink.count() != before {
+				t.Errorf("Expected no syncs after the last logger closed, got %d", sink.count()-before)
+			}
+		})
+	}
+}
+
+func Test_WriteErrorHandler(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		t.Run(string(loggerType), func(t *testing.T) {
+			var errs []error
+			var lost []string
+			handler := func(err error, p []byte) {
+				errs = append(errs, err)
+				lost = append(lost, string(p))
+			}
+			sink := &flakyWriter{fail: true}
+			logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, sink, log.WithWriteErrorHandler(handler))
+
+			logger.Info("dropped")
+			sink.fail = false
+			logger.Info("written")
+
+			if len(errs) != 1 || errs[0].Error() != "disk full" {
+				t.Fatalf("Expected one disk full error, got %v", errs)
+			}
+			if !strings.Contains(lost[0], `"dropped"`) {
+				t.Errorf("Expected lost record, got %q", lost[0])
+			}
+			if !strings.Contains(sink.buf.String(), `"written"`) {
+				t.Errorf("Expected record after recovery, got %q", sink.buf.String())
+			}
+		})
+	}
+}
+
+func Test_WithClock(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := log.WithClock(func() time.Time { return fixed })
+
+	for _, tc := range []struct {
+		loggerType log.LoggerType
+		config     log.Config
+		want       string
+	}{
+		{log.SlogType, log.Config{Level: "info", Format: "json"}, `"time":"2024-01-02T03:04:05Z"`},
+		{log.SlogType, log.Config{Level: "info", Format: "console", Colored: true}, "time=2024-01-02T03:04:05Z"},
+		{log.ZeroLogType, log.Config{Level: "info", Format: "json"}, `"time":"2024-01-02T03:04:05Z"`},
+	} {
+		var buf bytes.Buffer
+		logger := log.NewLogger(tc.loggerType, tc.config, &buf, clock)
+		logger.With("request_id", "abc").Info("hello")
+
+		out := buf.String()
+		if !strings.Contains(out, tc.want) || strings.Count(out, "time") != 1 {
+			t.Errorf("%s %s: expected a single timestamp %s, got %q", tc.loggerType, tc.config.Format, tc.want, out)
+		}
+	}
+}
+
+func Test_TimeFormat(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	clock := log.WithClock(func() time.Time { return fixed })
+
+	for _, tc := range []struct {
+		loggerType log.LoggerType
+		config     log.Config
+		want       string
+	}{
+		{log.SlogType, log.Config{Level: "info", Format: "console", Colored: true}, "time=2024-01-02T03:04:05Z"},
+		{log.SlogType, log.Config{Level: "info", Format: "console", Colored: true, TimeFormat: time.StampMilli}, "time=Jan  2 03:04:05.678"},
+		{log.SlogType, log.Config{Level: "info", Format: "console", TimeFormat: time.StampMilli}, `time="Jan  2 03:04:05.678"`},
+		{log.SlogType, log.Config{Level: "info", Format: "json", TimeFormat: time.DateTime}, `"time":"2024-01-02 03:04:05"`},
+		{log.ZeroLogType, log.Config{Level: "info", Format: "json", TimeFormat: time.DateTime}, `"time":"2024-01-02 03:04:05"`},
+	} {
+		var buf bytes.Buffer
+		logger := log.NewLogger(tc.loggerType, tc.config, &buf, clock)
+		logger.With("request_id", "abc").Info("hello")
+
+		out := buf.String()
+		if !strings.Contains(out, tc.want) || strings.Count(out, "time") != 1 {
+			t.Errorf("%s %s %q: expected a single timestamp %s, got %q", tc.loggerType, tc.config.Format, tc.config.TimeFormat, tc.want, out)
+		}
+	}
+}
+
+func Test_LogStartupInfo(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf bytes.Buffer
+		logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf, log.WithAppName("api"), log.WithAppVersion("1.2.3"))
+		log.LogStartupInfo(logger)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%s: expected a single JSON record, got %q", loggerType, buf.String())
+		}
+		want := map[string]any{
+			"go_version": runtime.Version(), "os": runtime.GOOS, "arch": runtime.GOARCH,
+			"pid": float64(os.Getpid()), "appName": "api", "appVersion": "1.2.3",
+		}
+		for key, value := range want {
+			if record[key] != value {
+				t.Errorf("%s: expected %s=%v, got %v", loggerType, key, value, record[key])
+			}
+		}
+		if _, ok := record["hostname"]; !ok {
+			t.Errorf("%s: expected hostname, got %v", loggerType, record)
+		}
+	}
+}
+
+func Test_WithContextTraceFields(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf bytes.Buffer
+		logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf)
+		logger.WithContext(ctx).Info("traced")
+		logger.WithContext(context.Background()).Info("untraced")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if !strings.Contains(lines[0], `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) || !strings.Contains(lines[0], `"span_id":"00f067aa0ba902b7"`) {
+			t.Errorf("%s: expected trace context fields, got %s", loggerType, lines[0])
+		}
+		if strings.Contains(lines[1], "trace_id") {
+			t.Errorf("%s: expected no trace fields without a span, got %s", loggerType, lines[1])
+		}
+	}
+}
+
+func Test_ForTenant(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf bytes.Buffer
+		levels := log.NewTenantLevels()
+		logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf, log.WithTenantLevels(levels))
+
+		acme := logger.ForTenant("acme")
+		other := logger.ForTenant("other")
+
+		acme.Debug("before override")
+		levels.Set("acme", "debug")
+		acme.With("request_id", "abc").Debug("acme debug")
+		other.Debug("other debug")
+		levels.Set("other", "error")
+		other.Warn("other warn")
+		levels.Delete("acme")
+		acme.Debug("after delete")
+		acme.Info("acme info")
+
+		out := buf.String()
+		for _, want := range []string{`"acme debug"`, `"request_id":"abc"`, `"tenant_id":"acme"`, `"acme info"`} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: expected %s, got %q", loggerType, want, out)
+			}
+		}
+		for _, unwanted := range []string{"before override", "other debug", "other warn", "after delete"} {
+			if strings.Contains(out, unwanted) {
+				t.Errorf("%s: expected %q to be filtered, got %q", loggerType, unwanted, out)
+			}
+		}
+	}
+}
+
+func Test_Stack(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf bytes.Buffer
+		logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf)
+
+		logger.Debug("filtered", "stack", log.Stack())
+		if buf.Len() != 0 {
+			t.Errorf("%s: expected the debug record to be filtered, got %q", loggerType, buf.String())
+		}
+
+		logger.Info("slow path", "stack", log.Stack())
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%s: expected a JSON record, got %q", loggerType, buf.String())
+		}
+		stack, _ := record["stack"].(string)
+		if !strings.HasPrefix(stack, "github.com/btchead/go-reusables/log_test.Test_Stack") {
+			t.Errorf("%s: expected the stack to start at the caller, got %q", loggerType, stack)
+		}
+	}
+}
+
+func Test_ConsoleColors(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	write := func(loggerType log.LoggerType, config log.Config) string {
+		var buf bytes.Buffer
+		config.Level, config.Format = "info", "console"
+		log.NewLogger(loggerType, config, &buf).Warn("careful")
+		return buf.String()
+	}
+
+	for _, tc := range []struct {
+		loggerType log.LoggerType
+		theme      string
+		want       string
+	}{
+		{log.SlogType, "", "\033[33mWARN\033[0m"},
+		{log.SlogType, "light", "\033[35mWARN\033[0m"},
+		{log.SlogType, "monochrome", "\033[1mWARN\033[0m"},
+		{log.ZeroLogType, "", "\033[33mWRN\033[0m"},
+		{log.ZeroLogType, "monochrome", "\033[1mWRN\033[0m"},
+	} {
+		if out := write(tc.loggerType, log.Config{Color: "always", Theme: tc.theme}); !strings.Contains(out, tc.want) {
+			t.Errorf("%s %q: expected %q in %q", tc.loggerType, tc.theme, tc.want, out)
+		}
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		if out := write(loggerType, log.Config{Color: "auto"}); strings.Contains(out, "\033[") {
+			t.Errorf("%s: expected no colors when not writing to a terminal, got %q", loggerType, out)
+		}
+
+		t.Setenv("NO_COLOR", "1")
+		if out := write(loggerType, log.Config{Colored: true}); strings.Contains(out, "\033[") {
+			t.Errorf("%s: expected NO_COLOR to disable colors, got %q", loggerType, out)
+		}
+		if out := write(loggerType, log.Config{Color: "always"}); !strings.Contains(out, "\033[") {
+			t.Errorf("%s: expected color always to ignore NO_COLOR, got %q", loggerType, out)
+		}
+
+		t.Setenv("NO_COLOR", "")
+		t.Setenv("FORCE_COLOR", "1")
+		if out := write(loggerType, log.Config{Color: "auto"}); !strings.Contains(out, "\033[") {
+			t.Errorf("%s: expected FORCE_COLOR to enable colors, got %q", loggerType, out)
+		}
+		if out := write(loggerType, log.Config{Color: "never"}); strings.Contains(out, "\033[") {
+			t.Errorf("%s: expected color never to ignore FORCE_COLOR, got %q", loggerType, out)
+		}
+		t.Setenv("FORCE_COLOR", "")
+	}
+}
+
+func Test_WithErrorCauses(t *testing.T) {
+	root := os.ErrNotExist
+	err := fmt.Errorf("load config: %w", errors.Join(errors.New("read failed"), &os.PathError{Op: "open", Path: "app.yaml", Err: root}))
+	wantCauses := []any{
+		map[string]any{"type": "*errors.joinError", "message": "read failed\nopen app.yaml: file does not exist"},
+		map[string]any{"type": "*errors.errorString", "message": "read failed"},
+		map[string]any{"type": "*fs.PathError", "message": "open app.yaml: file does not exist"},
+		map[string]any{"type": "*errors.errorString", "message": "file does not exist"},
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf syncBuffer
+		logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf, log.WithErrorCauses())
+
+		records := func() []map[string]any {
+			var records []map[string]any
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var record map[string]any
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("%s: failed to parse output %q: %v", loggerType, line, err)
+				}
+				records = append(records, record)
+			}
+			buf.Reset()
+			return records
+		}
+
+		logger.Error("failed", "error", err, "plain", errors.New("no causes"))
+		logger.With("cause", err).Info("inherited")
+		logger.(log.FieldLogger).ErrorF("typed", log.Err(err))
+		for i, record := range records() {
+			key := []string{"error", "cause", "error"}[i]
+			if got := record[key+".causes"]; !reflect.DeepEqual(got, wantCauses) {
+				t.Errorf("%s: expected causes %v in record %d, got %v", loggerType, wantCauses, i, got)
+			}
+			if _, ok := record["plain.causes"]; ok {
+				t.Errorf("%s: expected no causes for an error wrapping nothing", loggerType)
+			}
+		}
+
+		func() {
+			defer func() { recover() }()
+			defer log.InstallCrashHandler(logger, log.WithRepanic())()
+			panic(err)
+		}()
+		record := records()[0]
+		if record["panic"] != err.Error() || !reflect.DeepEqual(record["panic.causes"], wantCauses) {
+			t.Errorf("%s: expected the panic error with its causes, got %v", loggerType, record)
+		}
+	}
+}
+
+// lockedBuffer is a sink safe for records written by timers
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *lockedBuffer) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+// take returns the records written since the last call
+func (o *lockedBuffer) take() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	defer o.buf.Reset()
+	return o.buf.String()
+}
+
+// recordMessage returns the message of a JSON record of either adapter
+func recordMessage(record map[string]any) any {
+	if msg, ok := record["msg"]; ok {
+		return msg
+	}
+	return record["message"]
+}
+
+func Test_WithDeduplication(t *testing.T) {
+	records := func(loggerType log.LoggerType, output string) []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("%s: failed to parse output %q: %v", loggerType, line, err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf lockedBuffer
+		logger := log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf, log.WithDeduplication(time.Hour))
+
+		for range 1000 {
+			logger.Error("query failed", "table", "orders")
+		}
+		logger.Error("query failed", "table", "users")
+		logger.With("shard", 2).Error("query failed", "table", "users")
+		logger.(log.Syncer).Sync()
+
+		got := records(loggerType, buf.take())
+		if len(got) != 4 {
+			t.Fatalf("%s: expected 4 records, got %d: %v", loggerType, len(got), got)
+		}
+		if _, ok := got[0]["repeated"]; ok || got[0]["table"] != "orders" {
+			t.Errorf("%s: expected the first record to be written as is, got %v", loggerType, got[0])
+		}
+		if got[1]["repeated"] != float64(999) || got[1]["table"] != "orders" || recordMessage(got[1]) != "query failed" {
+			t.Errorf("%s: expected a summary with repeated=999, got %v", loggerType, got[1])
+		}
+		if got[2]["table"] != "users" || got[3]["shard"] != float64(2) {
+			t.Errorf("%s: expected records differing in fields to be written, got %v", loggerType, got[2:])
+		}
+
+		// The summary is written once the window ends, without another record
+		logger = log.NewLogger(loggerType, log.Config{Level: "info", Format: "json"}, &buf, log.WithDeduplication(20*time.Millisecond))
+		for range 3 {
+			logger.Warn("retrying")
+		}
+		var output string
+		deadline := time.Now().Add(time.Second)
+		for strings.Count(output, "\n") < 2 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+			output += buf.take()
+		}
+		got = records(loggerType, output)
+		if len(got) != 2 || got[1]["repeated"] != float64(2) {
+			t.Errorf("%s: expected a summary after the window, got %v", loggerType, got)
+		}
+	}
+}
+
+func Test_WithLevelEscalation(t *testing.T) {
+	for _, loggerType := range []log.LoggerType{log.SlogType, log.ZeroLogType} {
+		var buf lockedBuffer
+		logger := log.NewLogger(loggerType, log.Config{Level: "warn", Format: "json"}, &buf, log.WithLevelEscalation(log.LevelEscalation{
+			Threshold: 3,
+			Window:    time.Minute,
+			Duration:  50 * time.Millisecond,
+			Cooldown:  time.Hour,
+		}))
+
+		logger.Debug("before")
+		logger.Error("failed")
+		logger.Error("failed")
+		if output := buf.take(); strings.Contains(output, "before") || strings.Count(output, "failed") != 2 {
+			t.Errorf("%s: expected the configured level before the threshold, got %s", loggerType, output)
+		}
+
+		logger.Error("failed")
+		logger.Debug("during", "attempt", 1)
+		logger.With("request", "r1").Info("during")
+		output := buf.take()
+		if !strings.Contains(output, "Log level escalated") || !strings.Contains(output, `"escalated_level":"debug"`) {
+			t.Errorf("%s: expected the escalation to be announced, got %s", loggerType, output)
+		}
+		if strings.Count(output, "during") != 2 {
+			t.Errorf("%s: expected debug and info records during the escalation, got %s", loggerType, output)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		logger.Debug("after")
+		for range 5 {
+			logger.Error("failed")
+		}
+		logger.Debug("cooldown")
+		if output := buf.take(); strings.Contains(output, "after") || strings.Contains(output, "cooldown") || strings.Contains(output, "escalated") {
+			t.Errorf("%s: expected the escalation to end and no new one during the cooldown, got %s", loggerType, output)
+		}
+	}
+}
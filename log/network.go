@@ -0,0 +1,455 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSinkClosed is returned when writing to a closed NetworkWriter
+var ErrSinkClosed = errors.New("log sink closed")
+
+// ErrSyncTimeout is returned by Sync when the buffered records are not shipped within the flush timeout
+var ErrSyncTimeout = errors.New("log sink sync timed out")
+
+// NetworkStats counts the records of a NetworkWriter
+type NetworkStats struct {
+	// Written is the number of records shipped
+	Written uint64
+	// Dropped is the number of records discarded because the buffer was full or the writer closed
+	Dropped uint64
+	// WriteErrors is the number of failed connection attempts and writes
+	WriteErrors uint64
+	// Reconnects is the number of connections established after the first one
+	Reconnects uint64
+}
+
+// NetworkOption configures a NetworkWriter
+type NetworkOption func(*NetworkWriter)
+
+// WithBufferSize sets how many records are buffered while the sink is slow or unreachable,
+// 1024 by default. Records written to a full buffer are dropped and counted
+func WithBufferSize(n int) NetworkOption {
+	return func(o *NetworkWriter) {
+		o.bufferSize = n
+	}
+}
+
+// WithReconnectPolicy sets the delays between connection attempts after a failure,
+// by default 1s doubling up to 1m. The retrier package's policies satisfy RetryPolicy
+func WithReconnectPolicy(policy RetryPolicy) NetworkOption {
+	return func(o *NetworkWriter) {
+		o.policy = policy
+	}
+}
+
+// WithDialTimeout sets the timeout of each connection attempt, 5s by default
+func WithDialTimeout(timeout time.Duration) NetworkOption {
+	return func(o *NetworkWriter) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithFlushTimeout sets how long Sync and Close wait for buffered records to be shipped, 5s by default
+func WithFlushTimeout(timeout time.Duration) NetworkOption {
+	return func(o *NetworkWriter) {
+		o.flushTimeout = timeout
+	}
+}
+
+// WithGELF converts JSON records to GELF 1.1 messages for Graylog, null-delimited over TCP and
+// chunked over UDP. The host defaults to the hostname
+func WithGELF(host string) NetworkOption {
+	return func(o *NetworkWriter) {
+		if host == "" {
+			host, _ = os.Hostname()
+		}
+		o.gelfHost = host
+		o.gelf = true
+	}
+}
+
+// networkRecord is a buffered record, or a flush marker closed once the records before it are shipped
+type networkRecord struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+// NetworkWriter ships log records over TCP or UDP, for setups sending logs directly instead of via files.
+// Writes never block: records are buffered and sent by a background goroutine, which reconnects
+// according to the reconnect policy when the connection fails
+type NetworkWriter struct {
+	network      string
+	address      string
+	bufferSize   int
+	policy       RetryPolicy
+	dialTimeout  time.Duration
+	flushTimeout time.Duration
+	gelf         bool
+	gelfHost     string
+
+	queue     chan networkRecord
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+
+	mu    sync.Mutex
+	stats NetworkStats
+	// writeErrorHandler is the logger's write error handler, called for records dropped in the background
+	writeErrorHandler func(err error, lost []byte)
+	// connected is set after the first connection, used by the run goroutine only
+	connected bool
+}
+
+// NewNetworkWriter connects to the address on the network, "tcp" or "udp", in the background
+func NewNetworkWriter(network, address string, opts ...NetworkOption) *NetworkWriter {
+	w := &NetworkWriter{
+		network:      network,
+		address:      address,
+		bufferSize:   1024,
+		policy:       exponentialRetry{base: time.Second, max: time.Minute},
+		dialTimeout:  5 * time.Second,
+		flushTimeout: 5 * time.Second,
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	w.queue = make(chan networkRecord, max(w.bufferSize, 1))
+	go w.run()
+	trackSink(w, 0)
+	return w
+}
+
+// Write buffers a copy of the record, dropping it if the buffer is full
+func (o *NetworkWriter) Write(p []byte) (int, error) {
+	select {
+	case <-o.done:
+		o.count(func(s *NetworkStats) { s.Dropped++ })
+		return 0, ErrSinkClosed
+	default:
+	}
+
+	select {
+	case o.queue <- networkRecord{data: append([]byte(nil), p...)}:
+	default:
+		o.count(func(s *NetworkStats) { s.Dropped++ })
+	}
+	return len(p), nil
+}
+
+// Sync waits until the records written before it are shipped, up to the flush timeout
+func (o *NetworkWriter) Sync() error {
+	marker := networkRecord{flushed: make(chan struct{})}
+	timeout := time.NewTimer(o.flushTimeout)
+	defer timeout.Stop()
+
+	select {
+	case o.queue <- marker:
+	case <-o.done:
+		return ErrSinkClosed
+	case <-timeout.C:
+		return ErrSyncTimeout
+	}
+
+	select {
+	case <-marker.flushed:
+		return nil
+	case <-o.stopped:
+		return ErrSinkClosed
+	case <-timeout.C:
+		return ErrSyncTimeout
+	}
+}
+
+// Close ships the buffered records, up to the flush timeout, and closes the connection.
+// Records still buffered afterwards are dropped
+func (o *NetworkWriter) Close() error {
+	err := o.Sync()
+	if errors.Is(err, ErrSinkClosed) {
+		err = nil
+	}
+	o.closeOnce.Do(func() {
+		untrackSink(o)
+		close(o.done)
+	})
+	<-o.stopped
+	return err
+}
+
+// Stats returns the record counters
+func (o *NetworkWriter) Stats() NetworkStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stats
+}
+
+// setWriteErrorHandler reports records that can't be sent to the handler, since their Write already returned
+func (o *NetworkWriter) setWriteErrorHandler(handler func(err error, lost []byte)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.writeErrorHandler = handler
+}
+
+// count updates the counters with mu held
+func (o *NetworkWriter) count(update func(*NetworkStats)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	update(&o.stats)
+}
+
+// run ships buffered records until the writer is closed
+func (o *NetworkWriter) run() {
+	defer close(o.stopped)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+		// Account for the records left behind
+		for {
+			select {
+			case record := <-o.queue:
+				if record.flushed == nil {
+					o.count(func(s *NetworkStats) { s.Dropped++ })
+				}
+			default:
+				return
+			}
+		}
+	}()
+
+	for {
+		var record networkRecord
+		select {
+		case <-o.done:
+			return
+		case record = <-o.queue:
+		}
+
+		if record.flushed != nil {
+			close(record.flushed)
+			continue
+		}
+
+		var ok bool
+		if conn, ok = o.send(conn, record.data); !ok {
+			return
+		}
+	}
+}
+
+// send writes a record, reconnecting until it succeeds or the writer is closed, reported as not ok
+func (o *NetworkWriter) send(conn net.Conn, data []byte) (net.Conn, bool) {
+	frames, err := o.frames(data)
+	if err != nil {
+		o.mu.Lock()
+		o.stats.Dropped++
+		handler := o.writeErrorHandler
+		o.mu.Unlock()
+
+		if handler != nil {
+			handler(fmt.Errorf("log sink '%s': %w", o.address, err), data)
+		}
+		return conn, true
+	}
+
+	for attempt := 0; ; attempt++ {
+		if conn == nil {
+			conn, err = o.dial()
+		}
+		if err == nil {
+			err = writeFrames(conn, frames)
+		}
+		if err == nil {
+			o.count(func(s *NetworkStats) { s.Written++ })
+			return conn, true
+		}
+
+		o.count(func(s *NetworkStats) { s.WriteErrors++ })
+		if conn != nil {
+			conn.Close()
+			conn = nil
+		}
+
+		timer := time.NewTimer(o.policy.NextDelay(attempt))
+		select {
+		case <-o.done:
+			timer.Stop()
+			o.count(func(s *NetworkStats) { s.Dropped++ })
+			return nil, false
+		case <-timer.C:
+		}
+	}
+}
+
+// dial connects to the sink, counting connections after the first one as reconnects
+func (o *NetworkWriter) dial() (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.dialTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, o.network, o.address)
+	if err != nil {
+		return nil, err
+	}
+
+	o.count(func(s *NetworkStats) {
+		if o.connected {
+			s.Reconnects++
+		}
+	})
+	o.connected = true
+	return conn, nil
+}
+
+// writeFrames writes each frame in a single write, so datagrams are not split
+func writeFrames(conn net.Conn, frames [][]byte) error {
+	for _, frame := range frames {
+		if _, err := conn.Write(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frames returns the writes a record is sent as
+func (o *NetworkWriter) frames(data []byte) ([][]byte, error) {
+	if !o.gelf {
+		return [][]byte{data}, nil
+	}
+
+	message, err := gelfMessage(data, o.gelfHost)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(o.network, "udp") {
+		return gelfChunks(message)
+	}
+	// GELF over TCP is null-delimited
+	return [][]byte{append(message, 0)}, nil
+}
+
+// gelfFieldName matches the characters GELF allows in additional field names
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+// gelfMessage converts a JSON record of either backend to a GELF 1.1 message. Other records are sent
+// as the short message. Nested values become JSON strings, since GELF fields are flat
+func gelfMessage(data []byte, host string) ([]byte, error) {
+	line := strings.TrimRight(string(data), "\n")
+	message := map[string]any{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": line,
+		"timestamp":     float64(time.Now().UnixMicro()) / 1e6,
+		"level":         6,
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(data, &record); err != nil {
+		return json.Marshal(message)
+	}
+
+	for key, value := range record {
+		switch key {
+		case "message", "msg":
+			message["short_message"] = fmt.Sprint(value)
+			continue
+		case "level":
+			message["level"] = syslogLevel(fmt.Sprint(value))
+			continue
+		case "time":
+			if s, ok := value.(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					message["timestamp"] = float64(t.UnixMicro()) / 1e6
+					continue
+				}
+			}
+		}
+
+		name := "_" + gelfFieldName.ReplaceAllString(key, "_")
+		if name == "_id" {
+			// _id is reserved by Graylog
+			name = "_id_"
+		}
+		switch v := value.(type) {
+		case string, float64:
+			message[name] = v
+		case bool:
+			message[name] = fmt.Sprint(v)
+		default:
+			encoded, _ := json.Marshal(v)
+			message[name] = string(encoded)
+		}
+	}
+	return json.Marshal(message)
+}
+
+// syslogLevel returns the syslog severity GELF uses for a level name of either backend
+func syslogLevel(level string) int {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return 7
+	case "info":
+		return 6
+	case "warn", "warning":
+		return 4
+	case "error":
+		return 3
+	case "fatal":
+		return 2
+	case "panic":
+		return 1
+	default:
+		return 6
+	}
+}
+
+const (
+	// gelfChunkSize is the datagram size GELF recommends for chunked messages
+	gelfChunkSize = 8192
+	// gelfChunkHeader is the size of the magic bytes, message id, sequence number and count
+	gelfChunkHeader = 12
+	// gelfMaxChunks is the most chunks Graylog accepts for a message
+	gelfMaxChunks = 128
+)
+
+// gelfChunks splits a GELF message into datagrams if it doesn't fit into a single one
+func gelfChunks(message []byte) ([][]byte, error) {
+	if len(message) <= gelfChunkSize {
+		return [][]byte{message}, nil
+	}
+
+	payload := gelfChunkSize - gelfChunkHeader
+	count := int(math.Ceil(float64(len(message)) / float64(payload)))
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("GELF message of %d bytes exceeds %d chunks", len(message), gelfMaxChunks)
+	}
+
+	var id [8]byte
+	rand.Read(id[:])
+
+	chunks := make([][]byte, 0, count)
+	for i := range count {
+		end := min((i+1)*payload, len(message))
+		chunk := make([]byte, 0, gelfChunkHeader+end-i*payload)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, message[i*payload:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
@@ -1,8 +1,28 @@
 package log
 
+import "time"
+
 type options struct {
-	appName    string
-	appVersion string
+	appName       string
+	appVersion    string
+	maxFieldBytes int
+	levelMapping  map[string]string
+	// flightRecorder is the number of suppressed records kept, 0 disables the flight recorder
+	flightRecorder int
+	// autoSync is the interval the sink is synced at, 0 disables periodic syncing
+	autoSync time.Duration
+	// writeErrorHandler is called when the sink rejects a write
+	writeErrorHandler func(err error, lost []byte)
+	// clock returns the time records are stamped with, nil for the wall clock
+	clock func() time.Time
+	// tenantLevels holds the per-tenant level overrides of loggers returned by ForTenant
+	tenantLevels *TenantLevels
+	// errorCauses adds the causes of wrapped errors to records
+	errorCauses bool
+	// dedupWindow is how long identical consecutive records are collapsed, 0 disables deduplication
+	dedupWindow time.Duration
+	// levelEscalation lowers the level while errors are frequent, a zero threshold disables it
+	levelEscalation LevelEscalation
 }
 
 type Option func(*options)
@@ -20,3 +40,55 @@ func WithAppVersion(version string) Option {
 		o.appVersion = version
 	}
 }
+
+// WithMaxFieldBytes truncates string and []byte field values longer than n bytes with an ellipsis
+// and adds a truncated=true field, protecting log pipelines from huge records
+func WithMaxFieldBytes(n int) Option {
+	return func(o *options) {
+		o.maxFieldBytes = n
+	}
+}
+
+// WithLevelMapping emits trace and panic records at another level, for pipelines whose severities
+// lack them, e.g. {"trace": "debug", "panic": "error"} for cloud logging services.
+// Keys are trace or panic, values are trace, debug, info, warn, error or panic; others are ignored
+func WithLevelMapping(mapping map[string]string) Option {
+	return func(o *options) {
+		o.levelMapping = mapping
+	}
+}
+
+// WithFlightRecorder keeps the last n records suppressed by the level filter, such as debug and info
+// records in production, and writes them to the sink before the next error, fatal or panic record.
+// Suppressed records are still formatted, so this costs as much as logging them
+func WithFlightRecorder(n int) Option {
+	return func(o *options) {
+		o.flightRecorder = n
+	}
+}
+
+// WithAutoSync syncs the sink every interval, so records buffered by file or network sinks reach
+// their destination even when the process is killed. Sinks without a Sync method are not affected
+func WithAutoSync(interval time.Duration) Option {
+	return func(o *options) {
+		o.autoSync = interval
+	}
+}
+
+// WithWriteErrorHandler calls the handler when the sink rejects a write, e.g. when the disk is full or
+// a pipe is closed, with the bytes of the records that were lost. The adapters drop these errors otherwise
+// A NetworkWriter sink also reports the records it drops because they can't be framed, e.g. GELF
+// messages too large to chunk
+func WithWriteErrorHandler(handler func(err error, lost []byte)) Option {
+	return func(o *options) {
+		o.writeErrorHandler = handler
+	}
+}
+
+// WithClock stamps records with the time returned by now instead of the wall clock, for deterministic
+// timestamps in tests and replay tooling
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.clock = now
+	}
+}
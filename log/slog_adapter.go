@@ -2,11 +2,10 @@ package log
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"log/slog"
 	"os"
-	"strings"
+	"slices"
 	"time"
 )
 
@@ -18,35 +17,17 @@ func (o *SlogAdapter) New(config Config, writer io.Writer) Logger {
 	if writer == nil {
 		writer = os.Stdout
 	}
-
-	// Set log level
-	level := slog.LevelInfo
-	switch config.Level {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	}
+	release := trackLoggerSink(writer, o.options)
+	sink := writer
+	writer = withWriteErrorHandler(writer, o.options)
 
 	var handler slog.Handler
-	handlerOpts := &slog.HandlerOptions{
-		Level: level,
-	}
-
-	if config.Format == "console" {
-		if config.Colored {
-			handler = newColoredTextHandler(writer, handlerOpts)
-		} else {
-			handler = slog.NewTextHandler(writer, handlerOpts)
-		}
+	if o.options != nil && o.options.flightRecorder > 0 {
+		handler = newFlightRecorderHandler(config, writer, o.options.flightRecorder)
 	} else {
-		handler = slog.NewJSONHandler(writer, handlerOpts)
+		handler = NewSlogHandler(config, writer)
 	}
-
+	handler = withClock(handler, o.options)
 	logger := slog.New(handler)
 
 	// Add app metadata if provided
@@ -63,121 +44,303 @@ func (o *SlogAdapter) New(config Config, writer io.Writer) Logger {
 		}
 	}
 
-	return &slogLogger{logger: logger}
+	settings := &slogSettings{sink: sink, release: release, traceLevel: SlogLevelTrace, panicLevel: SlogLevelPanic}
+	if o.options != nil {
+		settings.maxFieldBytes = o.options.maxFieldBytes
+		settings.tenantLevels = o.options.tenantLevels
+		settings.errorCauses = o.options.errorCauses
+		settings.dedup = newDeduplicator(o.options)
+		settings.escalation = newEscalator(o.options)
+		if level, ok := parseSlogLevel(o.options.levelMapping["trace"]); ok {
+			settings.traceLevel = level
+		}
+		if level, ok := parseSlogLevel(o.options.levelMapping["panic"]); ok {
+			settings.panicLevel = level
+		}
+	}
+	switch config.StacktraceLevel {
+	case "error":
+		settings.stacktrace, settings.stacktraceLevel = true, slog.LevelError
+	case "fatal":
+		settings.stacktrace, settings.stacktraceLevel = true, levelFatal
+	}
+
+	settings.root = logger
+	return &slogLogger{logger: logger, settings: settings}
+}
+
+// smallRecordAttrs is the number of attributes, including a stack trace, built without allocating
+const smallRecordAttrs = 8
+
+// levelFatal orders Fatal records above errors. They are emitted at slog.LevelError
+const levelFatal = slog.LevelError + 4
+
+// slogSettings holds adapter settings shared by a logger and its children
+type slogSettings struct {
+	stacktrace      bool
+	stacktraceLevel slog.Level
+	sink            io.Writer
+	maxFieldBytes   int
+	// errorCauses adds the causes of wrapped errors to records
+	errorCauses bool
+	// dedup collapses identical consecutive records, nil if disabled
+	dedup *deduplicator
+	// escalation lowers the level while errors are frequent, nil if disabled
+	escalation *escalator
+	// traceLevel and panicLevel are the levels trace and panic records are emitted at
+	traceLevel slog.Level
+	panicLevel slog.Level
+	// root is the logger before any fields or groups were added, for Without
+	root *slog.Logger
+	// tenantLevels holds the per-tenant level overrides and tenant is set for loggers returned by ForTenant
+	tenantLevels *TenantLevels
+	tenant       *tenantScope
+	// release stops tracking the sink for SyncAll and auto sync, see Close
+	release func()
 }
 
 // slogLogger wraps slog.Logger to implement our Logger interface
 type slogLogger struct {
-	logger *slog.Logger
+	logger   *slog.Logger
+	scope    []scopeStep
+	settings *slogSettings
+}
+
+func (o *slogLogger) Trace(msg string, keysAndValues ...any) {
+	o.log(SlogLevelTrace, msg, keysAndValues)
 }
 
 func (o *slogLogger) Debug(msg string, keysAndValues ...any) {
-	attrs := make([]slog.Attr, len(keysAndValues)/2)
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			attrs[i/2] = slog.Any(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	o.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs[:len(keysAndValues)/2]...)
+	o.log(slog.LevelDebug, msg, keysAndValues)
 }
 
 func (o *slogLogger) Info(msg string, keysAndValues ...any) {
-	attrs := make([]slog.Attr, len(keysAndValues)/2)
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			attrs[i/2] = slog.Any(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	o.logger.LogAttrs(context.Background(), slog.LevelInfo, msg, attrs[:len(keysAndValues)/2]...)
+	o.log(slog.LevelInfo, msg, keysAndValues)
 }
 
 func (o *slogLogger) Warn(msg string, keysAndValues ...any) {
-	attrs := make([]slog.Attr, len(keysAndValues)/2)
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			attrs[i/2] = slog.Any(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	o.logger.LogAttrs(context.Background(), slog.LevelWarn, msg, attrs[:len(keysAndValues)/2]...)
+	o.log(slog.LevelWarn, msg, keysAndValues)
 }
 
 func (o *slogLogger) Error(msg string, keysAndValues ...any) {
-	attrs := make([]slog.Attr, len(keysAndValues)/2)
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			attrs[i/2] = slog.Any(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	o.logger.LogAttrs(context.Background(), slog.LevelError, msg, attrs[:len(keysAndValues)/2]...)
+	o.log(slog.LevelError, msg, keysAndValues)
+}
+
+func (o *slogLogger) DebugF(msg string, fields ...Field) {
+	o.logFields(slog.LevelDebug, msg, fields)
+}
+
+func (o *slogLogger) InfoF(msg string, fields ...Field) {
+	o.logFields(slog.LevelInfo, msg, fields)
+}
+
+func (o *slogLogger) WarnF(msg string, fields ...Field) {
+	o.logFields(slog.LevelWarn, msg, fields)
+}
+
+func (o *slogLogger) ErrorF(msg string, fields ...Field) {
+	o.logFields(slog.LevelError, msg, fields)
+}
+
+func (o *slogLogger) Panic(msg string, keysAndValues ...any) {
+	o.log(SlogLevelPanic, msg, keysAndValues)
+	o.Sync()
+	panic(msg)
 }
 
 func (o *slogLogger) Fatal(msg string, keysAndValues ...any) {
-	attrs := make([]slog.Attr, len(keysAndValues)/2)
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			attrs[i/2] = slog.Any(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	o.logger.LogAttrs(context.Background(), slog.LevelError, msg, attrs[:len(keysAndValues)/2]...)
-	os.Exit(1)
+	o.log(levelFatal, msg, keysAndValues)
+	o.Sync()
+	Exit(1)
+}
+
+// Sync flushes the underlying writer if it buffers output
+func (o *slogLogger) Sync() error {
+	o.settings.dedup.flush()
+	return syncWriter(o.settings.sink)
+}
+
+// Close syncs the logger and stops tracking its writer for SyncAll, ending its WithAutoSync goroutine
+// once no other logger uses the writer. The writer itself is not closed. Loggers derived with With or
+// WithGroup share the tracking, so close the logger returned by NewLogger once none of them is used
+func (o *slogLogger) Close() error {
+	err := o.Sync()
+	o.settings.release()
+	return err
+}
+
+// logFatal logs at fatal level without exiting
+func (o *slogLogger) logFatal(msg string, keysAndValues []any) {
+	o.log(levelFatal, msg, keysAndValues)
 }
 
 func (o *slogLogger) With(keysAndValues ...any) Logger {
-	return &slogLogger{logger: o.logger.With(keysAndValues...)}
+	if o.settings.errorCauses {
+		keysAndValues = appendErrorCauses(keysAndValues)
+	}
+	keysAndValues = truncateFields(keysAndValues, o.settings.maxFieldBytes)
+	return &slogLogger{
+		logger:   o.logger.With(keysAndValues...),
+		scope:    appendScope(o.scope, scopeStep{fields: keysAndValues}),
+		settings: o.settings,
+	}
+}
+
+func (o *slogLogger) WithGroup(name string) Logger {
+	return &slogLogger{
+		logger:   o.logger.WithGroup(name),
+		scope:    appendScope(o.scope, scopeStep{group: name}),
+		settings: o.settings,
+	}
 }
 
 func (o *slogLogger) WithContext(ctx context.Context) Logger {
-	return &slogLogger{logger: o.logger.With()}
+	fields := contextFields(ctx)
+	return &slogLogger{
+		logger:   o.logger.With(fields...),
+		scope:    appendScope(o.scope, scopeStep{fields: fields}),
+		settings: o.settings,
+	}
+}
+
+// Without returns a child logger without the inherited fields named by keys, rebuilt from the root logger
+func (o *slogLogger) Without(keys ...string) Logger {
+	scope := withoutKeys(o.scope, keys)
+	logger := o.settings.root
+	for _, step := range scope {
+		if step.group != "" {
+			logger = logger.WithGroup(step.group)
+		} else {
+			logger = logger.With(step.fields...)
+		}
+	}
+	return &slogLogger{logger: logger, scope: scope, settings: o.settings}
 }
 
-// coloredTextHandler is a custom handler that adds colors to text output
-type coloredTextHandler struct {
-	*slog.TextHandler
-	writer io.Writer
+// ForTenant returns a child logger adding the tenant ID, logging at the tenant's level override if any
+func (o *slogLogger) ForTenant(id string) Logger {
+	settings := *o.settings
+	settings.tenant = &tenantScope{id: id, levels: o.settings.tenantLevels}
+	child := &slogLogger{logger: o.logger, scope: o.scope, settings: &settings}
+	return child.With(TenantKey, id)
 }
 
-func newColoredTextHandler(w io.Writer, opts *slog.HandlerOptions) *coloredTextHandler {
-	return &coloredTextHandler{
-		TextHandler: slog.NewTextHandler(w, opts),
-		writer:      w,
+// log converts the key/value pairs to attributes and emits the record
+func (o *slogLogger) log(level slog.Level, msg string, keysAndValues []any) {
+	emitLevel, ok := o.enabled(level)
+	if !ok {
+		return
+	}
+
+	if o.settings.errorCauses {
+		keysAndValues = appendErrorCauses(keysAndValues)
+	}
+	keysAndValues = truncateFields(keysAndValues, o.settings.maxFieldBytes)
+	if o.settings.dedup != nil && !o.deduplicate(level, emitLevel, msg, keysAndValues, nil) {
+		return
 	}
+
+	// Records with few fields build their attributes in a stack buffer instead of allocating
+	var buf [smallRecordAttrs]slog.Attr
+	attrs := buf[:0]
+	if n := len(keysAndValues)/2 + 1; n > len(buf) {
+		attrs = make([]slog.Attr, 0, n)
+	}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		attrs = append(attrs, slog.Any(keysAndValues[i].(string), keysAndValues[i+1]))
+	}
+
+	o.emit(level, emitLevel, msg, attrs)
 }
 
-func (o *coloredTextHandler) Handle(ctx context.Context, r slog.Record) error {
-	// Get level color
-	levelColor := getLevelColor(r.Level)
+// logFields converts the typed fields to attributes and emits the record
+func (o *slogLogger) logFields(level slog.Level, msg string, fields []Field) {
+	emitLevel, ok := o.enabled(level)
+	if !ok {
+		return
+	}
+
+	if o.settings.errorCauses {
+		fields = appendTypedErrorCauses(fields)
+	}
+	fields = truncateTypedFields(fields, o.settings.maxFieldBytes)
+	if o.settings.dedup != nil && !o.deduplicate(level, emitLevel, msg, nil, fields) {
+		return
+	}
 
-	// Format time
-	timeStr := r.Time.Format(time.RFC3339)
+	var buf [smallRecordAttrs]slog.Attr
+	attrs := buf[:0]
+	if n := len(fields) + 1; n > len(buf) {
+		attrs = make([]slog.Attr, 0, n)
+	}
+	for _, field := range fields {
+		attrs = append(attrs, field.slogAttr())
+	}
 
-	// Build colored output
-	var buf strings.Builder
-	buf.WriteString(fmt.Sprintf("time=%s level=%s%s\033[0m msg=\"%s\"",
-		timeStr, levelColor, r.Level.String(), r.Message))
+	o.emit(level, emitLevel, msg, attrs)
+}
 
-	// Add attributes
-	r.Attrs(func(a slog.Attr) bool {
-		buf.WriteString(fmt.Sprintf(" %s=%v", a.Key, a.Value))
+// deduplicate reports whether the record should be emitted, registering how to emit its summary if
+// identical records are suppressed later. Fatal and panic records are always emitted, after the summary
+// of suppressed records
+func (o *slogLogger) deduplicate(level, emitLevel slog.Level, msg string, keysAndValues []any, fields []Field) bool {
+	if level == levelFatal || level == SlogLevelPanic {
+		o.settings.dedup.flush()
 		return true
+	}
+
+	key := recordKey(int(level), msg, o.scope, keysAndValues, fields)
+	keysAndValues, fields = slices.Clone(keysAndValues), slices.Clone(fields)
+	return o.settings.dedup.allow(key, func(repeated int) {
+		attrs := make([]slog.Attr, 0, len(keysAndValues)/2+len(fields)+2)
+		for i := 0; i+1 < len(keysAndValues); i += 2 {
+			attrs = append(attrs, slog.Any(keysAndValues[i].(string), keysAndValues[i+1]))
+		}
+		for _, field := range fields {
+			attrs = append(attrs, field.slogAttr())
+		}
+		o.emit(level, emitLevel, msg, append(attrs, slog.Int(repeatedKey, repeated)))
 	})
+}
 
-	buf.WriteString("\n")
+// enabled returns the level records of the given level are emitted at, and whether it is enabled
+func (o *slogLogger) enabled(level slog.Level) (slog.Level, bool) {
+	emitLevel := level
+	switch level {
+	case levelFatal:
+		emitLevel = slog.LevelError
+	case SlogLevelTrace:
+		emitLevel = o.settings.traceLevel
+	case SlogLevelPanic:
+		emitLevel = o.settings.panicLevel
+	}
 
-	_, err := o.writer.Write([]byte(buf.String()))
-	return err
+	if tenantLevel, ok := o.settings.tenant.slogLevel(); ok {
+		return emitLevel, emitLevel >= tenantLevel
+	}
+	if escalatedLevel, ok := o.settings.escalation.slogLevel(); ok && emitLevel >= escalatedLevel {
+		return emitLevel, true
+	}
+	return emitLevel, o.logger.Enabled(context.Background(), emitLevel)
 }
 
-func getLevelColor(level slog.Level) string {
-	switch level {
-	case slog.LevelDebug:
-		return "\033[36m" // Cyan
-	case slog.LevelInfo:
-		return "\033[32m" // Green
-	case slog.LevelWarn:
-		return "\033[33m" // Yellow
-	case slog.LevelError:
-		return "\033[31m" // Red
-	default:
-		return ""
+// emit adds the stack trace if configured and emits the record
+func (o *slogLogger) emit(level, emitLevel slog.Level, msg string, attrs []slog.Attr) {
+	if o.settings.stacktrace && level >= o.settings.stacktraceLevel {
+		// Skip emit, log and the level method
+		attrs = append(attrs, slog.String(stacktraceKey, captureStack(3)))
+	}
+
+	if o.settings.tenant != nil || o.settings.escalation.active() {
+		// The tenant's or the escalated level may enable records the handler filters out, so bypass its level check
+		record := slog.NewRecord(time.Now(), emitLevel, msg, 0)
+		record.AddAttrs(attrs...)
+		_ = o.logger.Handler().Handle(context.Background(), record)
+	} else {
+		o.logger.LogAttrs(context.Background(), emitLevel, msg, attrs...)
+	}
+
+	if emitLevel >= slog.LevelError && o.settings.escalation != nil && o.settings.escalation.recordError() {
+		o.log(slog.LevelWarn, "Log level escalated", o.settings.escalation.escalationFields())
 	}
 }
@@ -0,0 +1,183 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// NewSlogHandler returns the slog.Handler used by the slog adapter for the given configuration,
+// so it can be used with slog directly or verified with testing/slogtest
+func NewSlogHandler(config Config, writer io.Writer) slog.Handler {
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	// Set log level
+	level := slog.LevelInfo
+	switch config.Level {
+	case "trace":
+		level = SlogLevelTrace
+	case "debug":
+		level = slog.LevelDebug
+	case "info":
+		level = slog.LevelInfo
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceLevelName,
+	}
+	if config.TimeFormat != "" {
+		handlerOpts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+				a.Value = slog.StringValue(a.Value.Time().Format(config.TimeFormat))
+			}
+			return replaceLevelName(groups, a)
+		}
+	}
+
+	switch config.Format {
+	case "console":
+		if colorEnabled(config, writer) {
+			return newColoredTextHandler(writer, handlerOpts, themeFor(config.Theme), config.TimeFormat)
+		}
+		return slog.NewTextHandler(writer, handlerOpts)
+	case "logfmt":
+		// The text handler writes logfmt: key=value pairs, quoting values when needed
+		return slog.NewTextHandler(writer, handlerOpts)
+	}
+	return slog.NewJSONHandler(writer, handlerOpts)
+}
+
+// coloredTextHandler is a custom handler that adds colors to text output.
+// Attributes of groups are written with dotted keys, like slog.TextHandler
+type coloredTextHandler struct {
+	level  slog.Leveler
+	writer io.Writer
+	mu     *sync.Mutex
+	// prefix is the dotted group path applied to attributes added later
+	prefix string
+	// attrs holds the attributes added with WithAttrs, already formatted
+	attrs string
+	theme colorTheme
+	// timeFormat is the layout of the record time
+	timeFormat string
+}
+
+// newColoredTextHandler creates a colored handler writing times with the layout, RFC3339 when empty
+func newColoredTextHandler(w io.Writer, opts *slog.HandlerOptions, theme colorTheme, timeFormat string) *coloredTextHandler {
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+	if timeFormat == "" {
+		timeFormat = time.RFC3339
+	}
+
+	return &coloredTextHandler{
+		level:      level,
+		writer:     w,
+		mu:         &sync.Mutex{},
+		theme:      theme,
+		timeFormat: timeFormat,
+	}
+}
+
+func (o *coloredTextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= o.level.Level()
+}
+
+func (o *coloredTextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return o
+	}
+
+	var buf strings.Builder
+	buf.WriteString(o.attrs)
+	for _, a := range attrs {
+		appendColoredAttr(&buf, o.prefix, a)
+	}
+
+	handler := *o
+	handler.attrs = buf.String()
+	return &handler
+}
+
+func (o *coloredTextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return o
+	}
+
+	handler := *o
+	handler.prefix = o.prefix + name + "."
+	return &handler
+}
+
+func (o *coloredTextHandler) Handle(ctx context.Context, r slog.Record) error {
+	// Build colored output
+	var buf strings.Builder
+	if !r.Time.IsZero() {
+		buf.WriteString(paint(o.theme.time, "time="+r.Time.Format(o.timeFormat)) + " ")
+	}
+	name := slogLevelName(r.Level)
+	buf.WriteString("level=" + paint(o.theme.levels[strings.ToLower(name)], name) + " msg=" + strconv.Quote(r.Message))
+
+	// Add attributes
+	buf.WriteString(o.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendColoredAttr(&buf, o.prefix, a)
+		return true
+	})
+
+	buf.WriteString("\n")
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, err := io.WriteString(o.writer, buf.String())
+	return err
+}
+
+// appendColoredAttr writes the attribute as key=value, flattening groups into dotted keys
+func appendColoredAttr(buf *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, attr := range a.Value.Group() {
+			appendColoredAttr(buf, prefix, attr)
+		}
+		return
+	}
+
+	buf.WriteString(" " + prefix + a.Key + "=" + quoteIfNeeded(a.Value.String()))
+}
+
+// quoteIfNeeded quotes values that would otherwise be ambiguous in key=value output
+func quoteIfNeeded(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// stacktraceKey is the field holding captured stack traces
+const stacktraceKey = "stacktrace"
+
+// captureStack formats the stack of the calling goroutine, skipping the given number of frames
+// above the caller of captureStack
+func captureStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	return formatStack(pcs[:n])
+}
+
+// formatStack writes the function, file and line of every frame, innermost first
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	frames := runtime.CallersFrames(pcs)
+
+	var buf strings.Builder
+	for {
+		frame, more := frames.Next()
+		buf.WriteString(frame.Function)
+		buf.WriteString("\n\t")
+		buf.WriteString(frame.File)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// StackTrace is a goroutine stack captured by Stack. It is formatted when a record holding it is
+// written, so records filtered out by the level only pay for capturing the program counters
+type StackTrace struct {
+	pcs []uintptr
+}
+
+// Stack captures the stack of the calling goroutine as a field value, to attach a stack at any call
+// site, e.g. logger.Debug("cache miss", "stack", log.Stack())
+func Stack() StackTrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	return StackTrace{pcs: pcs[:n]}
+}
+
+// String formats the stack like the stacktrace field, one function and file:line per frame
+func (s StackTrace) String() string {
+	return formatStack(s.pcs)
+}
+
+// LogValue formats the stack when a slog handler writes the record
+func (s StackTrace) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalJSON formats the stack as a JSON string, for fields encoded with encoding/json
+func (s StackTrace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// startupMessage is the message of the record emitted by LogStartupInfo
+const startupMessage = "Starting"
+
+// LogStartupInfo emits a single info record describing the running binary: Go version, module version,
+// VCS revision, commit time and modified flag from the build info, GOOS/GOARCH, hostname and PID.
+// The app name and version set with WithAppName and WithAppVersion are attached like on every record.
+// Call it first thing after creating the logger, so every service starts its logs the same way
+func LogStartupInfo(logger Logger) {
+	logger.Info(startupMessage, startupFields()...)
+}
+
+// startupFields returns the key/value pairs of the startup record. Build info fields are left out when
+// the binary was built without module or VCS information
+func startupFields() []any {
+	fields := []any{
+		"go_version", runtime.Version(),
+		"os", runtime.GOOS,
+		"arch", runtime.GOARCH,
+		"pid", os.Getpid(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		fields = append(fields, "hostname", hostname)
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fields
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		fields = append(fields, "module_version", info.Main.Version)
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			fields = append(fields, "vcs_revision", setting.Value)
+		case "vcs.time":
+			fields = append(fields, "vcs_time", setting.Value)
+		case "vcs.modified":
+			fields = append(fields, "vcs_modified", setting.Value == "true")
+		}
+	}
+	return fields
+}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sinkEntry is a buffered sink tracked for SyncAll
+type sinkEntry struct {
+	syncer Syncer
+	// stop is closed when the sink is untracked, ending its auto sync goroutine
+	stop     chan struct{}
+	autoSync bool
+	// done is closed when the auto sync goroutine exited
+	done chan struct{}
+	// refs counts the loggers and package writers using the sink
+	refs int
+}
+
+// sinks tracks the buffered sinks created through the package, in creation order
+var sinks struct {
+	mu      sync.Mutex
+	entries []*sinkEntry
+	hooks   []func()
+}
+
+// trackSink records a sink passed to NewLogger or created by the package so SyncAll flushes it,
+// and starts syncing it periodically when auto sync is requested. Writers without Sync and the
+// console streams, which are unbuffered and fail to sync on terminals and pipes, are not tracked.
+// The returned function releases the sink, which is untracked once every user released it
+func trackSink(w io.Writer, autoSync time.Duration) (release func()) {
+	syncer, ok := w.(Syncer)
+	if !ok || w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) || !reflect.TypeOf(w).Comparable() {
+		return func() {}
+	}
+
+	sinks.mu.Lock()
+	defer sinks.mu.Unlock()
+
+	var entry *sinkEntry
+	for _, existing := range sinks.entries {
+		if existing.syncer == syncer {
+			entry = existing
+			break
+		}
+	}
+	if entry == nil {
+		entry = &sinkEntry{syncer: syncer, stop: make(chan struct{})}
+		sinks.entries = append(sinks.entries, entry)
+	}
+	entry.refs++
+
+	// The first logger asking for auto sync sets the interval of a shared sink
+	if autoSync > 0 && !entry.autoSync {
+		entry.autoSync = true
+		entry.done = make(chan struct{})
+		go entry.run(autoSync)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { releaseSink(entry) })
+	}
+}
+
+// trackLoggerSink tracks the writer of a new logger, syncing it periodically with WithAutoSync.
+// The returned function releases the writer when the logger is closed
+func trackLoggerSink(w io.Writer, options *options) (release func()) {
+	var interval time.Duration
+	if options != nil {
+		interval = options.autoSync
+	}
+	return trackSink(w, interval)
+}
+
+// releaseSink drops a user of the sink, untracking it when none is left
+func releaseSink(entry *sinkEntry) {
+	sinks.mu.Lock()
+	entry.refs--
+	if entry.refs > 0 {
+		sinks.mu.Unlock()
+		return
+	}
+	removeSink(entry)
+	sinks.mu.Unlock()
+
+	entry.wait()
+}
+
+// untrackSink stops tracking a sink, e.g. once it is closed
+func untrackSink(syncer Syncer) {
+	sinks.mu.Lock()
+	var removed *sinkEntry
+	for _, entry := range sinks.entries {
+		if entry.syncer == syncer {
+			removed = entry
+			removeSink(entry)
+			break
+		}
+	}
+	sinks.mu.Unlock()
+
+	if removed != nil {
+		removed.wait()
+	}
+}
+
+// removeSink ends the auto sync goroutine of the entry and removes it, unless it was removed already.
+// It must be called with sinks.mu held
+func removeSink(entry *sinkEntry) {
+	if i := slices.Index(sinks.entries, entry); i >= 0 {
+		close(entry.stop)
+		sinks.entries = slices.Delete(sinks.entries, i, i+1)
+	}
+}
+
+// wait waits until the auto sync goroutine of a removed entry exited, so the sink isn't synced anymore
+func (o *sinkEntry) wait() {
+	if o.done != nil {
+		<-o.done
+	}
+}
+
+// run syncs the sink every interval until it is untracked. Errors are dropped, the next
+// write or sync reports a failing sink
+func (o *sinkEntry) run(interval time.Duration) {
+	defer close(o.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-ticker.C:
+			o.syncer.Sync()
+		}
+	}
+}
+
+// SyncAll flushes every buffered sink created through the package: writers passed to NewLogger
+// and the writers returned by NewFallbackWriter and NewNetworkWriter. Errors are joined
+func SyncAll() error {
+	sinks.mu.Lock()
+	entries := append([]*sinkEntry(nil), sinks.entries...)
+	sinks.mu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		if err := entry.syncer.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OnExit registers a hook run by Exit before the sinks are synced, e.g. to log final statistics.
+// Hooks run in reverse registration order, like deferred calls
+func OnExit(hook func()) {
+	sinks.mu.Lock()
+	defer sinks.mu.Unlock()
+	sinks.hooks = append(sinks.hooks, hook)
+}
+
+// Exit runs the exit hooks, syncs every sink and exits with the code. Fatal and the crash handler
+// exit through it; call it instead of os.Exit so buffered records are not lost
+func Exit(code int) {
+	sinks.mu.Lock()
+	hooks := append([]func(){}, sinks.hooks...)
+	sinks.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	SyncAll()
+	os.Exit(code)
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// TenantKey is the field holding the tenant ID of loggers returned by ForTenant
+const TenantKey = "tenant_id"
+
+// TenantLevels is a table of per-tenant levels overriding the configured level for loggers returned
+// by ForTenant, e.g. to debug a single customer of a multi-tenant service. It is safe to update while
+// logging, changes apply to the next record
+type TenantLevels struct {
+	mu     sync.RWMutex
+	levels map[string]string
+}
+
+// NewTenantLevels creates an empty tenant level table
+func NewTenantLevels() *TenantLevels {
+	return &TenantLevels{levels: make(map[string]string)}
+}
+
+// Set overrides the level of the tenant. Levels are trace, debug, info, warn or error; others are ignored
+func (t *TenantLevels) Set(tenant, level string) {
+	if _, ok := parseSlogLevel(level); !ok || level == "panic" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.levels[tenant] = level
+}
+
+// Delete removes the override of the tenant, which logs at the configured level again
+func (t *TenantLevels) Delete(tenant string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.levels, tenant)
+}
+
+// Level returns the level override of the tenant
+func (t *TenantLevels) Level(tenant string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	level, ok := t.levels[tenant]
+	return level, ok
+}
+
+// WithTenantLevels applies the per-tenant level overrides of the table to loggers returned by ForTenant
+func WithTenantLevels(levels *TenantLevels) Option {
+	return func(o *options) {
+		o.tenantLevels = levels
+	}
+}
+
+// tenantScope is the tenant of a logger returned by ForTenant and the level table it is looked up in
+type tenantScope struct {
+	id     string
+	levels *TenantLevels
+}
+
+// slogLevel returns the slog level override of the tenant
+func (t *tenantScope) slogLevel() (slog.Level, bool) {
+	if t == nil {
+		return 0, false
+	}
+	name, ok := t.levels.Level(t.id)
+	if !ok {
+		return 0, false
+	}
+	return parseSlogLevel(name)
+}
+
+// zerologLevel returns the zerolog level override of the tenant
+func (t *tenantScope) zerologLevel() (zerolog.Level, bool) {
+	if t == nil {
+		return zerolog.NoLevel, false
+	}
+	name, ok := t.levels.Level(t.id)
+	if !ok {
+		return zerolog.NoLevel, false
+	}
+	return parseZerologLevel(name)
+}
@@ -0,0 +1,56 @@
+package log
+
+import "unicode/utf8"
+
+// truncatedKey is the field added to records with truncated values
+const truncatedKey = "truncated"
+
+// truncateFields returns the key/value pairs with string and []byte values longer than max bytes cut,
+// followed by truncated=true if any value was cut. The input is not modified
+func truncateFields(keysAndValues []any, max int) []any {
+	if max <= 0 {
+		return keysAndValues
+	}
+
+	var truncated []any
+	for i := 1; i < len(keysAndValues); i += 2 {
+		value, ok := truncateValue(keysAndValues[i], max)
+		if !ok {
+			continue
+		}
+		if truncated == nil {
+			truncated = append(make([]any, 0, len(keysAndValues)+2), keysAndValues...)
+		}
+		truncated[i] = value
+	}
+
+	if truncated == nil {
+		return keysAndValues
+	}
+	return append(truncated, truncatedKey, true)
+}
+
+// truncateValue cuts long strings and byte slices on a rune boundary and appends an ellipsis
+func truncateValue(value any, max int) (string, bool) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		if len(v) <= max {
+			return "", false
+		}
+		s = v[:max+1]
+	case []byte:
+		if len(v) <= max {
+			return "", false
+		}
+		s = string(v[:max+1])
+	default:
+		return "", false
+	}
+
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…", true
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"context"
+	"slices"
+)
+
+// scopeStep is a call that added fields or a group to a child logger. Loggers keep their steps so
+// Without can rebuild them from the root logger, since the backends can't remove added fields
+type scopeStep struct {
+	// group is the name of a group opened with WithGroup
+	group string
+	// fields are the key/value pairs added with With or WithContext
+	fields []any
+	// ctx is the context attached with WithContext, replayed without its fields
+	ctx context.Context
+}
+
+// appendScope returns the steps of a child logger, leaving the parent's steps unchanged
+func appendScope(scope []scopeStep, step scopeStep) []scopeStep {
+	return append(slices.Clip(scope), step)
+}
+
+// withoutKeys returns the steps with the fields named by keys removed, at any group depth
+func withoutKeys(scope []scopeStep, keys []string) []scopeStep {
+	filtered := make([]scopeStep, 0, len(scope))
+	for _, step := range scope {
+		if len(step.fields) > 0 {
+			fields := make([]any, 0, len(step.fields))
+			for i := 0; i < len(step.fields); i += 2 {
+				if key, ok := step.fields[i].(string); ok && slices.Contains(keys, key) {
+					continue
+				}
+				fields = append(fields, step.fields[i:min(i+2, len(step.fields))]...)
+			}
+			step.fields = fields
+		}
+		filtered = append(filtered, step)
+	}
+	return filtered
+}
@@ -0,0 +1,39 @@
+package log
+
+import "io"
+
+// writeErrorWriter reports failed and short writes of the sink to the handler
+type writeErrorWriter struct {
+	out     io.Writer
+	handler func(err error, lost []byte)
+}
+
+// writeErrorReporter is implemented by sinks that lose records in the background, after Write returned
+type writeErrorReporter interface {
+	setWriteErrorHandler(handler func(err error, lost []byte))
+}
+
+// withWriteErrorHandler wraps the sink when the options set a write error handler, and passes the
+// handler on to sinks reporting background losses
+func withWriteErrorHandler(w io.Writer, options *options) io.Writer {
+	if options == nil || options.writeErrorHandler == nil {
+		return w
+	}
+	if reporter, ok := w.(writeErrorReporter); ok {
+		reporter.setWriteErrorHandler(options.writeErrorHandler)
+	}
+	return &writeErrorWriter{out: w, handler: options.writeErrorHandler}
+}
+
+// Write writes to the sink and reports the lost bytes of a failed or short write. The error is
+// handled, so it is not returned to the adapter, which would otherwise print it to stderr
+func (o *writeErrorWriter) Write(p []byte) (int, error) {
+	n, err := o.out.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		o.handler(err, p[max(n, 0):])
+	}
+	return len(p), nil
+}
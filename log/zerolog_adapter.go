@@ -4,6 +4,8 @@ import (
 	"context"
 	"io"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -17,10 +19,15 @@ func (o *ZerologAdapter) New(config Config, writer io.Writer) Logger {
 	if writer == nil {
 		writer = os.Stdout
 	}
+	release := trackLoggerSink(writer, o.options)
+	sink := writer
+	writer = withWriteErrorHandler(writer, o.options)
 
 	// Set log level
 	level := zerolog.InfoLevel
 	switch config.Level {
+	case "trace":
+		level = zerolog.TraceLevel
 	case "debug":
 		level = zerolog.DebugLevel
 	case "info":
@@ -33,14 +40,23 @@ func (o *ZerologAdapter) New(config Config, writer io.Writer) Logger {
 
 	var zl zerolog.Logger
 	if config.Format == "console" {
+		// Colors come from the theme only, the writer's own colors stay off
 		consoleWriter := zerolog.ConsoleWriter{
 			Out:     writer,
-			NoColor: !config.Colored,
+			NoColor: true,
+		}
+		if colorEnabled(config, writer) {
+			theme := themeFor(config.Theme)
+			consoleWriter.FormatLevel = theme.formatZerologLevel
+			consoleWriter.FormatTimestamp = theme.formatZerologTimestamp
+		}
+		out, loggerLevel := o.withFlightRecorder(consoleWriter, level)
+		ctx := zerolog.New(out).
+			Level(loggerLevel).
+			With()
+		if o.clock() == nil && config.TimeFormat == "" {
+			ctx = ctx.Timestamp()
 		}
-		ctx := zerolog.New(consoleWriter).
-			Level(level).
-			With().
-			Timestamp()
 
 		if o.options != nil {
 			if o.options.appName != "" {
@@ -53,10 +69,17 @@ func (o *ZerologAdapter) New(config Config, writer io.Writer) Logger {
 
 		zl = ctx.Logger()
 	} else {
-		ctx := zerolog.New(writer).
-			Level(level).
-			With().
-			Timestamp()
+		out := writer
+		if config.Format == "logfmt" {
+			out = &logfmtWriter{out: writer}
+		}
+		out, loggerLevel := o.withFlightRecorder(out, level)
+		ctx := zerolog.New(out).
+			Level(loggerLevel).
+			With()
+		if o.clock() == nil && config.TimeFormat == "" {
+			ctx = ctx.Timestamp()
+		}
 
 		if o.options != nil {
 			if o.options.appName != "" {
@@ -70,74 +93,401 @@ func (o *ZerologAdapter) New(config Config, writer io.Writer) Logger {
 		zl = ctx.Logger()
 	}
 
-	return &zerologLogger{logger: zl}
+	if clock := o.clock(); clock != nil || config.TimeFormat != "" {
+		zl = zl.Hook(clockHook{now: clock, layout: config.TimeFormat})
+	}
+	switch config.StacktraceLevel {
+	case "error":
+		zl = zl.Hook(stacktraceHook{level: zerolog.ErrorLevel})
+	case "fatal":
+		zl = zl.Hook(stacktraceHook{level: zerolog.FatalLevel})
+	}
+
+	settings := &zerologSettings{sink: sink, release: release, traceLevel: zerolog.TraceLevel, panicLevel: zerolog.PanicLevel}
+	if o.options != nil {
+		settings.maxFieldBytes = o.options.maxFieldBytes
+		settings.tenantLevels = o.options.tenantLevels
+		settings.errorCauses = o.options.errorCauses
+		settings.dedup = newDeduplicator(o.options)
+		settings.escalation = newEscalator(o.options)
+		if level, ok := parseZerologLevel(o.options.levelMapping["trace"]); ok {
+			settings.traceLevel = level
+		}
+		if level, ok := parseZerologLevel(o.options.levelMapping["panic"]); ok {
+			settings.panicLevel = level
+		}
+	}
+
+	settings.root = zl
+	return &zerologLogger{logger: zl, settings: settings}
+}
+
+// withFlightRecorder wraps the output in a flight recorder if enabled. The logger then accepts all
+// levels and the writer applies the level filter
+func (o *ZerologAdapter) withFlightRecorder(out io.Writer, level zerolog.Level) (io.Writer, zerolog.Level) {
+	if o.options == nil || o.options.flightRecorder <= 0 {
+		return out, level
+	}
+	return &flightRecorderWriter{out: out, level: level, recorder: newFlightRecorder(o.options.flightRecorder)}, zerolog.TraceLevel
+}
+
+// clock returns the clock set with WithClock, nil for the wall clock
+func (o *ZerologAdapter) clock() func() time.Time {
+	if o.options == nil {
+		return nil
+	}
+	return o.options.clock
+}
+
+// stacktraceHook attaches the stack of the logging goroutine to records at or above level
+type stacktraceHook struct {
+	level zerolog.Level
+}
+
+func (h stacktraceHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level >= h.level && level < zerolog.NoLevel {
+		// Skip Run, the zerolog internals sending the event and the adapter methods
+		e.Str(stacktraceKey, captureStack(6))
+	}
 }
 
 // zerologLogger wraps zerolog.Logger to implement our Logger interface
 type zerologLogger struct {
 	logger zerolog.Logger
+	groups []zerologGroup
+	scope  []scopeStep
+	// settings are shared by a logger and its children
+	settings *zerologSettings
+}
+
+// zerologSettings holds adapter settings shared by a logger and its children
+type zerologSettings struct {
+	sink          io.Writer
+	maxFieldBytes int
+	// errorCauses adds the causes of wrapped errors to records
+	errorCauses bool
+	// dedup collapses identical consecutive records, nil if disabled
+	dedup *deduplicator
+	// escalation lowers the level while errors are frequent, nil if disabled
+	escalation *escalator
+	// traceLevel and panicLevel are the levels trace and panic records are emitted at
+	traceLevel zerolog.Level
+	panicLevel zerolog.Level
+	// root is the logger before any fields or groups were added, for Without
+	root zerolog.Logger
+	// tenantLevels holds the per-tenant level overrides and tenant is set for loggers returned by ForTenant
+	tenantLevels *TenantLevels
+	tenant       *tenantScope
+	// release stops tracking the sink for SyncAll and auto sync, see Close
+	release func()
+}
+
+// zerologGroup holds the fields added to a group opened with WithGroup.
+// zerolog has no native groups, so grouped fields are emitted as nested dictionaries
+type zerologGroup struct {
+	name   string
+	fields []any
+}
+
+func (l *zerologLogger) Trace(msg string, keysAndValues ...any) {
+	l.log(l.settings.traceLevel, msg, keysAndValues)
 }
 
 func (l *zerologLogger) Debug(msg string, keysAndValues ...any) {
-	event := l.logger.Debug()
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			event = event.Interface(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	event.Msg(msg)
+	l.log(zerolog.DebugLevel, msg, keysAndValues)
 }
 
 func (l *zerologLogger) Info(msg string, keysAndValues ...any) {
-	event := l.logger.Info()
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			event = event.Interface(keysAndValues[i].(string), keysAndValues[i+1])
-		}
-	}
-	event.Msg(msg)
+	l.log(zerolog.InfoLevel, msg, keysAndValues)
 }
 
 func (l *zerologLogger) Warn(msg string, keysAndValues ...any) {
-	event := l.logger.Warn()
+	l.log(zerolog.WarnLevel, msg, keysAndValues)
+}
+
+func (l *zerologLogger) Error(msg string, keysAndValues ...any) {
+	l.log(zerolog.ErrorLevel, msg, keysAndValues)
+}
+
+func (l *zerologLogger) DebugF(msg string, fields ...Field) {
+	l.logFields(zerolog.DebugLevel, msg, fields)
+}
+
+func (l *zerologLogger) InfoF(msg string, fields ...Field) {
+	l.logFields(zerolog.InfoLevel, msg, fields)
+}
+
+func (l *zerologLogger) WarnF(msg string, fields ...Field) {
+	l.logFields(zerolog.WarnLevel, msg, fields)
+}
+
+func (l *zerologLogger) ErrorF(msg string, fields ...Field) {
+	l.logFields(zerolog.ErrorLevel, msg, fields)
+}
+
+func (l *zerologLogger) Panic(msg string, keysAndValues ...any) {
+	l.critical().log(l.settings.panicLevel, msg, keysAndValues)
+	l.Sync()
+	panic(msg)
+}
+
+func (l *zerologLogger) Fatal(msg string, keysAndValues ...any) {
+	l.critical().log(zerolog.FatalLevel, msg, keysAndValues)
+	l.Sync()
+	Exit(1)
+}
+
+// Sync flushes the underlying writer if it buffers output
+func (l *zerologLogger) Sync() error {
+	l.settings.dedup.flush()
+	return syncWriter(l.settings.sink)
+}
+
+// Close syncs the logger and stops tracking its writer for SyncAll, ending its WithAutoSync goroutine
+// once no other logger uses the writer. The writer itself is not closed. Loggers derived with With or
+// WithGroup share the tracking, so close the logger returned by NewLogger once none of them is used
+func (l *zerologLogger) Close() error {
+	err := l.Sync()
+	l.settings.release()
+	return err
+}
+
+// logFatal logs at fatal level without exiting
+func (l *zerologLogger) logFatal(msg string, keysAndValues []any) {
+	l.critical().log(zerolog.FatalLevel, msg, keysAndValues)
+}
+
+func (l *zerologLogger) With(keysAndValues ...any) Logger {
+	if l.settings.errorCauses {
+		keysAndValues = appendErrorCauses(keysAndValues)
+	}
+	keysAndValues = truncateFields(keysAndValues, l.settings.maxFieldBytes)
+	if len(l.groups) > 0 {
+		// Fields belong to the innermost open group
+		groups := l.copyGroups()
+		last := &groups[len(groups)-1]
+		last.fields = append(last.fields, keysAndValues...)
+		return &zerologLogger{logger: l.logger, groups: groups, scope: l.withScope(keysAndValues), settings: l.settings}
+	}
+
+	ctx := l.logger.With()
 	for i := 0; i < len(keysAndValues); i += 2 {
 		if i+1 < len(keysAndValues) {
-			event = event.Interface(keysAndValues[i].(string), keysAndValues[i+1])
+			ctx = ctx.Interface(keysAndValues[i].(string), keysAndValues[i+1])
 		}
 	}
-	event.Msg(msg)
+	return &zerologLogger{logger: ctx.Logger(), scope: l.withScope(keysAndValues), settings: l.settings}
 }
 
-func (l *zerologLogger) Error(msg string, keysAndValues ...any) {
-	event := l.logger.Error()
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			event = event.Interface(keysAndValues[i].(string), keysAndValues[i+1])
+// withScope returns the scope of a child logger adding the fields
+func (l *zerologLogger) withScope(keysAndValues []any) []scopeStep {
+	return appendScope(l.scope, scopeStep{fields: keysAndValues})
+}
+
+func (l *zerologLogger) WithGroup(name string) Logger {
+	if name == "" {
+		return l
+	}
+	groups := append(l.copyGroups(), zerologGroup{name: name})
+	return &zerologLogger{logger: l.logger, groups: groups, scope: appendScope(l.scope, scopeStep{group: name}), settings: l.settings}
+}
+
+func (l *zerologLogger) WithContext(ctx context.Context) Logger {
+	logger := l.withCtx(ctx)
+	if fields := contextFields(ctx); len(fields) > 0 {
+		return logger.With(fields...)
+	}
+	return logger
+}
+
+// withCtx returns a child logger passing the context to hooks, without adding its fields
+func (l *zerologLogger) withCtx(ctx context.Context) *zerologLogger {
+	return &zerologLogger{
+		logger:   l.logger.With().Ctx(ctx).Logger(),
+		groups:   l.groups,
+		scope:    appendScope(l.scope, scopeStep{ctx: ctx}),
+		settings: l.settings,
+	}
+}
+
+// Without returns a child logger without the inherited fields named by keys, rebuilt from the root logger
+func (l *zerologLogger) Without(keys ...string) Logger {
+	logger := &zerologLogger{logger: l.settings.root, settings: l.settings}
+	for _, step := range withoutKeys(l.scope, keys) {
+		switch {
+		case step.ctx != nil:
+			logger = logger.withCtx(step.ctx)
+		case step.group != "":
+			logger = logger.WithGroup(step.group).(*zerologLogger)
+		default:
+			logger = logger.With(step.fields...).(*zerologLogger)
 		}
 	}
-	event.Msg(msg)
+	return logger
 }
 
-func (l *zerologLogger) Fatal(msg string, keysAndValues ...any) {
-	event := l.logger.Fatal()
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			event = event.Interface(keysAndValues[i].(string), keysAndValues[i+1])
+// ForTenant returns a child logger adding the tenant ID, logging at the tenant's level override if any
+func (l *zerologLogger) ForTenant(id string) Logger {
+	settings := *l.settings
+	settings.tenant = &tenantScope{id: id, levels: l.settings.tenantLevels}
+	child := &zerologLogger{logger: l.logger, groups: l.groups, scope: l.scope, settings: &settings}
+	return child.With(TenantKey, id)
+}
+
+// leveled returns the logger creating events, at the tenant's level override if any, otherwise at the
+// escalated level during an escalation
+func (l *zerologLogger) leveled() *zerolog.Logger {
+	if level, ok := l.settings.tenant.zerologLevel(); ok {
+		logger := l.logger.Level(level)
+		return &logger
+	}
+	if level, ok := l.settings.escalation.zerologLevel(); ok && level < l.logger.GetLevel() {
+		logger := l.logger.Level(level)
+		return &logger
+	}
+	return &l.logger
+}
+
+// countError counts records at error level and above for the level escalation, announcing an escalation
+// it starts
+func (l *zerologLogger) countError(level zerolog.Level) {
+	if level < zerolog.ErrorLevel || level >= zerolog.NoLevel || l.settings.escalation == nil {
+		return
+	}
+	if l.settings.escalation.recordError() {
+		l.log(zerolog.WarnLevel, "Log level escalated", l.settings.escalation.escalationFields())
+	}
+}
+
+// log adds the key/value pairs to an event of the level, nesting them inside the open groups, and sends it
+func (l *zerologLogger) log(level zerolog.Level, msg string, keysAndValues []any) {
+	event := l.leveled().WithLevel(level)
+	if event == nil {
+		return
+	}
+	if l.settings.errorCauses {
+		keysAndValues = appendErrorCauses(keysAndValues)
+	}
+	keysAndValues = truncateFields(keysAndValues, l.settings.maxFieldBytes)
+	if l.settings.dedup != nil && !l.deduplicate(level, msg, keysAndValues, nil) {
+		event.Discard()
+		return
+	}
+	l.send(event, msg, keysAndValues, nil)
+	l.countError(level)
+}
+
+// logFields adds the typed fields to an event of the level, nesting them inside the open groups, and sends it
+func (l *zerologLogger) logFields(level zerolog.Level, msg string, fields []Field) {
+	event := l.leveled().WithLevel(level)
+	if event == nil {
+		return
+	}
+	if l.settings.errorCauses {
+		fields = appendTypedErrorCauses(fields)
+	}
+	fields = truncateTypedFields(fields, l.settings.maxFieldBytes)
+	if l.settings.dedup != nil && !l.deduplicate(level, msg, nil, fields) {
+		event.Discard()
+		return
+	}
+	l.send(event, msg, nil, fields)
+	l.countError(level)
+}
+
+// critical returns the logger for fatal and panic records, which are never suppressed by deduplication.
+// The summary of suppressed records is sent first
+func (l *zerologLogger) critical() *zerologLogger {
+	if l.settings.dedup == nil {
+		return l
+	}
+	l.settings.dedup.flush()
+	settings := *l.settings
+	settings.dedup = nil
+	return &zerologLogger{logger: l.logger, groups: l.groups, scope: l.scope, settings: &settings}
+}
+
+// deduplicate reports whether the record should be sent, registering how to send its summary if
+// identical records are suppressed later
+func (l *zerologLogger) deduplicate(level zerolog.Level, msg string, keysAndValues []any, fields []Field) bool {
+	key := recordKey(int(level), msg, l.scope, keysAndValues, fields)
+	keysAndValues, fields = slices.Clone(keysAndValues), slices.Clone(fields)
+	return l.settings.dedup.allow(key, func(repeated int) {
+		if event := l.leveled().WithLevel(level); event != nil {
+			l.send(event.Int(repeatedKey, repeated), msg, keysAndValues, fields)
 		}
+	})
+}
+
+// send adds the key/value pairs and typed fields of the record to the event and sends it
+func (l *zerologLogger) send(event *zerolog.Event, msg string, keysAndValues []any, fields []Field) {
+	if len(l.groups) == 0 {
+		event = addTypedFields(addFields(event, keysAndValues), fields)
+		event.Msg(msg)
+		return
+	}
+
+	// Build the innermost group first and wrap it into its parents
+	last := len(l.groups) - 1
+	dict := addTypedFields(addFields(addFields(zerolog.Dict(), l.groups[last].fields), keysAndValues), fields)
+	for i := last - 1; i >= 0; i-- {
+		dict = addFields(zerolog.Dict(), l.groups[i].fields).Dict(l.groups[i+1].name, dict)
 	}
-	event.Msg(msg)
+	event.Dict(l.groups[0].name, dict).Msg(msg)
 }
 
-func (l *zerologLogger) With(keysAndValues ...any) Logger {
-	ctx := l.logger.With()
+// copyGroups returns a copy of the group chain so child loggers don't share field slices
+func (l *zerologLogger) copyGroups() []zerologGroup {
+	groups := make([]zerologGroup, len(l.groups))
+	for i, group := range l.groups {
+		groups[i] = zerologGroup{name: group.name, fields: append([]any(nil), group.fields...)}
+	}
+	return groups
+}
+
+// addFields adds key/value pairs to a zerolog event or dictionary
+func addFields(event *zerolog.Event, keysAndValues []any) *zerolog.Event {
 	for i := 0; i < len(keysAndValues); i += 2 {
 		if i+1 < len(keysAndValues) {
-			ctx = ctx.Interface(keysAndValues[i].(string), keysAndValues[i+1])
+			event = addField(event, keysAndValues[i].(string), keysAndValues[i+1])
 		}
 	}
-	return &zerologLogger{logger: ctx.Logger()}
+	return event
 }
 
-func (l *zerologLogger) WithContext(ctx context.Context) Logger {
-	return &zerologLogger{logger: l.logger.With().Ctx(ctx).Logger()}
+// addField adds a value with zerolog's typed methods, which unlike Interface don't go through
+// encoding/json and don't allocate for common types. Output matches Interface except for errors,
+// which are written as their message instead of an empty JSON object
+func addField(event *zerolog.Event, key string, value any) *zerolog.Event {
+	switch v := value.(type) {
+	case string:
+		return event.Str(key, v)
+	case int:
+		return event.Int(key, v)
+	case int64:
+		return event.Int64(key, v)
+	case int32:
+		return event.Int32(key, v)
+	case uint:
+		return event.Uint(key, v)
+	case uint64:
+		return event.Uint64(key, v)
+	case uint32:
+		return event.Uint32(key, v)
+	case float64:
+		return event.Float64(key, v)
+	case float32:
+		return event.Float32(key, v)
+	case bool:
+		return event.Bool(key, v)
+	case time.Duration:
+		// encoding/json writes durations as integer nanoseconds
+		return event.Int64(key, int64(v))
+	case error:
+		return event.AnErr(key, v)
+	case StackTrace:
+		return event.Str(key, v.String())
+	default:
+		return event.Interface(key, v)
+	}
 }
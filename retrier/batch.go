@@ -0,0 +1,168 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ItemFailure describes an item that still failed when a batch finished
+type ItemFailure[T any] struct {
+	Item     T
+	Index    int
+	Attempts int
+	Err      error
+}
+
+// BatchResult contains the result of a batch retry operation
+type BatchResult[T any] struct {
+	// Attempts holds the number of attempts made for each item, by index
+	Attempts []int
+	// Failed lists the items that failed permanently, in index order
+	Failed []ItemFailure[T]
+	// Passes is the number of passes made over the pending items
+	Passes    int
+	Duration  time.Duration
+	StartTime time.Time
+	// Err is set when the batch stopped early, on an invalid configuration or context cancellation
+	Err error
+}
+
+// IsSuccess returns true if every item succeeded
+func (o *BatchResult[T]) IsSuccess() bool {
+	return o.Err == nil && len(o.Failed) == 0
+}
+
+// Error returns the batch error and the errors of the failed items, or nil on success
+func (o *BatchResult[T]) Error() error {
+	if o.IsSuccess() {
+		return nil
+	}
+
+	errs := []error{o.Err}
+	for _, failure := range o.Failed {
+		errs = append(errs, fmt.Errorf("item %d failed after %d attempts: %w", failure.Index, failure.Attempts, failure.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// String returns a string representation of the result
+func (o *BatchResult[T]) String() string {
+	succeeded := len(o.Attempts) - len(o.Failed)
+	return fmt.Sprintf("%d of %d items succeeded after %d passes in %v", succeeded, len(o.Attempts), o.Passes, o.Duration)
+}
+
+// DoBatch calls fn for every item, then retries only the items that failed on each following pass,
+// waiting the policy delay between passes. The retry condition and policy are applied per item,
+// so items failing with non-retryable errors are not called again. The attempts limit bounds
+// the number of passes
+func DoBatch[T any](ctx context.Context, items []T, fn func(T) error, options ...Option) *BatchResult[T] {
+	cfg := newConfig(options)
+
+	result := &BatchResult[T]{
+		Attempts:  make([]int, len(items)),
+		StartTime: time.Now(),
+	}
+	defer func() {
+		result.Duration = time.Since(result.StartTime)
+	}()
+
+	if err := cfg.validate(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	pending := make([]int, len(items))
+	for i := range items {
+		pending[i] = i
+	}
+	lastErrs := make([]error, len(items))
+	failed := make([]bool, len(items))
+
+	// fail marks items as permanently failed
+	fail := func(indexes ...int) {
+		for _, index := range indexes {
+			failed[index] = true
+		}
+	}
+	defer func() {
+		for i, item := range items {
+			if failed[i] {
+				result.Failed = append(result.Failed, ItemFailure[T]{
+					Item:     item,
+					Index:    i,
+					Attempts: result.Attempts[i],
+					Err:      lastErrs[i],
+				})
+			}
+		}
+	}()
+
+	if cfg.initialDelay > 0 {
+		if err := sleep(ctx, cfg.initialDelay); err != nil {
+			result.Err = err
+			fail(pending...)
+			return result
+		}
+	}
+
+	var slept time.Duration
+	for pass := 0; pass < cfg.maxAttempts && len(pending) > 0; pass++ {
+		result.Passes = pass + 1
+
+		var retrying []int
+		var passErrs []error
+		for n, index := range pending {
+			if err := ctx.Err(); err != nil {
+				result.Err = err
+				fail(pending[n:]...)
+				return result
+			}
+
+			result.Attempts[index]++
+			err := cfg.runAttempt(ctx, result.Attempts[index], func(context.Context) error { return fn(items[index]) })
+			if err == nil {
+				continue
+			}
+
+			lastErrs[index] = err
+			if cfg.retryCondition(err) && (cfg.policy == nil || cfg.policy.ShouldRetry(pass, err)) {
+				retrying = append(retrying, index)
+				passErrs = append(passErrs, err)
+			} else {
+				fail(index)
+			}
+		}
+		pending = retrying
+
+		if len(pending) == 0 || pass == cfg.maxAttempts-1 {
+			break
+		}
+
+		passErr := errors.Join(passErrs...)
+		delay := cfg.retryDelay(pass, passErr)
+		if !cfg.withinDelayBudget(slept, delay) {
+			break
+		}
+		slept += delay
+
+		if cfg.onRetry != nil {
+			cfg.onRetry(ctx, pass+1, passErr, delay)
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			result.Err = err
+			break
+		}
+	}
+
+	fail(pending...)
+	return result
+}
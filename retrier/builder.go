@@ -0,0 +1,109 @@
+package retrier
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ExponentialBuilder builds an ExponentialBackoffPolicy from named parameters, validating them in Build.
+// The delay after the nth failed attempt (counting from 0) is Base * Multiplier^n, capped at MaxDelay
+type ExponentialBuilder struct {
+	base        time.Duration
+	multiplier  float64
+	jitter      float64
+	maxDelay    time.Duration
+	maxAttempts int
+	maxElapsed  time.Duration
+	source      rand.Source
+}
+
+// NewExponentialBuilder creates a builder with a 100ms base delay, a multiplier of 2, no jitter and no limits
+func NewExponentialBuilder() *ExponentialBuilder {
+	return &ExponentialBuilder{
+		base:       100 * time.Millisecond,
+		multiplier: 2,
+	}
+}
+
+// Base sets the delay after the first failed attempt. It must be positive
+func (b *ExponentialBuilder) Base(delay time.Duration) *ExponentialBuilder {
+	b.base = delay
+	return b
+}
+
+// Multiplier sets the factor each delay grows by, e.g. 2 doubles it. It must be at least 1
+func (b *ExponentialBuilder) Multiplier(multiplier float64) *ExponentialBuilder {
+	b.multiplier = multiplier
+	return b
+}
+
+// Jitter randomizes each delay by up to ± the factor of the delay, between 0.0 and 1.0
+func (b *ExponentialBuilder) Jitter(factor float64) *ExponentialBuilder {
+	b.jitter = factor
+	return b
+}
+
+// MaxDelay caps each delay, 0 means no cap. It must not be below the base delay
+func (b *ExponentialBuilder) MaxDelay(delay time.Duration) *ExponentialBuilder {
+	b.maxDelay = delay
+	return b
+}
+
+// MaxAttempts limits the number of retries allowed by the policy, 0 means no limit.
+// The WithMaxAttempts option still bounds the operation
+func (b *ExponentialBuilder) MaxAttempts(attempts int) *ExponentialBuilder {
+	b.maxAttempts = attempts
+	return b
+}
+
+// MaxElapsed stops retrying once the delays between attempts, without jitter, would add up to more
+// than the duration, 0 means no limit. Use WithTimeout to also bound the time spent in attempts
+func (b *ExponentialBuilder) MaxElapsed(elapsed time.Duration) *ExponentialBuilder {
+	b.maxElapsed = elapsed
+	return b
+}
+
+// RandSource sets the source of the jitter randomness instead of the global math/rand source
+func (b *ExponentialBuilder) RandSource(source rand.Source) *ExponentialBuilder {
+	b.source = source
+	return b
+}
+
+// Build validates the parameters and returns the policy, or an error wrapping ErrInvalidConfig
+func (b *ExponentialBuilder) Build() (RetryPolicy, error) {
+	var errs []error
+
+	if b.base <= 0 {
+		errs = append(errs, fmt.Errorf("base delay must be positive, got %v", b.base))
+	}
+	if b.multiplier < 1 {
+		errs = append(errs, fmt.Errorf("multiplier must be at least 1, got %v", b.multiplier))
+	}
+	if b.jitter < 0 || b.jitter > 1 {
+		errs = append(errs, fmt.Errorf("jitter factor must be between 0 and 1, got %v", b.jitter))
+	}
+	if b.maxDelay < 0 {
+		errs = append(errs, fmt.Errorf("max delay must not be negative, got %v", b.maxDelay))
+	} else if b.maxDelay > 0 && b.maxDelay < b.base {
+		errs = append(errs, fmt.Errorf("max delay %v must not be below the base delay %v", b.maxDelay, b.base))
+	}
+	if b.maxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("max attempts must not be negative, got %d", b.maxAttempts))
+	}
+	if b.maxElapsed < 0 {
+		errs = append(errs, fmt.Errorf("max elapsed must not be negative, got %v", b.maxElapsed))
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	policy := NewExponentialBackoffPolicy(b.base, b.multiplier, b.jitter, b.maxDelay).WithMaxAttempts(b.maxAttempts)
+	policy.maxElapsed = b.maxElapsed
+	if b.source != nil {
+		policy.WithRandSource(b.source)
+	}
+	return policy, nil
+}
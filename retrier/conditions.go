@@ -0,0 +1,82 @@
+package retrier
+
+import "errors"
+
+// OnErrorsIs retries errors matching any of the targets with errors.Is
+func OnErrorsIs(targets ...error) RetryCondition {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OnErrorsAs retries errors whose chain contains an error of type T, found with errors.As
+func OnErrorsAs[T error]() RetryCondition {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// ErrorCode is the type of domain error codes matched by OnCodes, such as gRPC codes, AWS error code
+// strings or application enums
+type ErrorCode interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~string
+}
+
+// OnCodes retries errors whose code, returned by extract, is one of the codes. Errors extract finds
+// no code in are not retried, e.g. for gRPC:
+//
+//	OnCodes(func(err error) (codes.Code, bool) {
+//		s, ok := status.FromError(err)
+//		return s.Code(), ok
+//	}, codes.Unavailable, codes.ResourceExhausted)
+func OnCodes[T ErrorCode](extract func(error) (T, bool), codes ...T) RetryCondition {
+	return func(err error) bool {
+		code, ok := extract(err)
+		if !ok {
+			return false
+		}
+		for _, c := range codes {
+			if code == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// And retries when all conditions hold. Conditions are evaluated in order until one fails
+func And(conditions ...RetryCondition) RetryCondition {
+	return func(err error) bool {
+		for _, condition := range conditions {
+			if !condition(err) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or retries when any condition holds. Conditions are evaluated in order until one holds
+func Or(conditions ...RetryCondition) RetryCondition {
+	return func(err error) bool {
+		for _, condition := range conditions {
+			if condition(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not retries when the condition does not hold
+func Not(condition RetryCondition) RetryCondition {
+	return func(err error) bool {
+		return !condition(err)
+	}
+}
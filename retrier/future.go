@@ -0,0 +1,48 @@
+package retrier
+
+import "context"
+
+// Future is the pending result of an operation started with DoAsync. The result is kept once the
+// operation finishes, so it can be waited for any number of times from any goroutine
+type Future struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	result *Result
+}
+
+// DoAsync executes a function with retry logic asynchronously
+func DoAsync(ctx context.Context, fn RetryableFunc, options ...Option) *Future {
+	ctx, cancel := context.WithCancel(ctx)
+	future := &Future{done: make(chan struct{}), cancel: cancel}
+
+	go func() {
+		defer cancel()
+		future.result = Do(ctx, fn, options...)
+		close(future.done)
+	}()
+
+	return future
+}
+
+// Wait returns the result once the operation finishes. When ctx ends first it returns ctx's error
+// without cancelling the operation, so Wait can be called with a deadline and called again later
+func (o *Future) Wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-o.done:
+		return o.result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Done returns a channel closed once the operation finishes
+func (o *Future) Done() <-chan struct{} {
+	return o.done
+}
+
+// Cancel stops the operation: the running attempt sees its context cancelled and no further
+// attempts are made. The result then reports the context error. Cancelling a finished
+// operation has no effect
+func (o *Future) Cancel() {
+	o.cancel()
+}
@@ -0,0 +1,32 @@
+package retrier
+
+import (
+	"context"
+	"crypto/rand"
+)
+
+// RetryableFuncWithKey is a side-effecting function retried with the same idempotency key on every attempt
+type RetryableFuncWithKey func(ctx context.Context, key string) error
+
+// WithIdempotencyKey sets the generator of the idempotency key passed to DoWithKey.
+// It is called once per operation, by default a random key from crypto/rand.Text is used
+func WithIdempotencyKey(gen func() string) Option {
+	return func(c *config) {
+		c.idempotencyKey = gen
+	}
+}
+
+// DoWithKey executes a side-effecting function with retry logic, passing the same idempotency key
+// to every attempt so servers can deduplicate retried requests, e.g. payment-style POST APIs
+// honoring an Idempotency-Key header
+func DoWithKey(ctx context.Context, fn RetryableFuncWithKey, options ...Option) *Result {
+	cfg := newConfig(options)
+
+	gen := cfg.idempotencyKey
+	if gen == nil {
+		gen = rand.Text
+	}
+	key := gen()
+
+	return do(ctx, func(ctx context.Context) error { return fn(ctx, key) }, cfg)
+}
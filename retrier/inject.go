@@ -0,0 +1,36 @@
+package retrier
+
+import "context"
+
+// FailureInjector returns the error forced for an attempt, numbered from 1, or nil to run the
+// operation normally
+type FailureInjector func(attempt int) error
+
+// WithFailureInjector fails the attempts the injector returns an error for without calling the
+// operation, to test how an application handles retries, exhaustion and its OnRetry or tracing
+// wiring. In batches, attempts are numbered per item
+func WithFailureInjector(injector FailureInjector) Option {
+	return func(c *config) {
+		c.failureInjector = injector
+	}
+}
+
+// FailFirst returns an injector failing the first n attempts with err
+func FailFirst(n int, err error) FailureInjector {
+	return func(attempt int) error {
+		if attempt <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+// runAttempt calls fn unless the failure injector forces an error for the attempt
+func (c *config) runAttempt(ctx context.Context, attempt int, fn func(ctx context.Context) error) error {
+	if c.failureInjector != nil {
+		if err := c.failureInjector(attempt); err != nil {
+			return err
+		}
+	}
+	return fn(ctx)
+}
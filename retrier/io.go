@@ -0,0 +1,74 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// IsBusyError checks if an error reports a busy or temporarily unavailable resource,
+// or an IO timeout, which usually clears up on its own
+func IsBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.ETIMEDOUT) ||
+		errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	// Check for errors reporting a timeout, such as net.Error
+	type timeout interface {
+		Timeout() bool
+	}
+
+	var te timeout
+	if errors.As(err, &te) {
+		return te.Timeout()
+	}
+
+	return false
+}
+
+// Common IO retry conditions
+var (
+	// RetryOnBusy retries on busy resources and IO timeouts
+	RetryOnBusy RetryCondition = IsBusyError
+
+	// RetryOnTemporary retries on network and other temporary errors
+	RetryOnTemporary RetryCondition = IsTemporaryError
+)
+
+// ReadFile reads the named file, retrying while the file is busy or the read times out.
+// Missing files and permission errors are not retried unless a different condition is given
+func ReadFile(ctx context.Context, path string, options ...Option) ([]byte, error) {
+	opts := append([]Option{WithRetryCondition(RetryOnBusy)}, options...)
+
+	data, result := DoValue(ctx, func() ([]byte, error) {
+		return os.ReadFile(path)
+	}, opts...)
+	if err := result.Error(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Dial connects to the address on the named network, retrying temporary network failures
+func Dial(ctx context.Context, network, addr string, options ...Option) (net.Conn, error) {
+	opts := append([]Option{WithRetryCondition(RetryOnTemporary)}, options...)
+
+	var dialer net.Dialer
+	conn, result := DoValue(ctx, func() (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}, opts...)
+	if err := result.Error(); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,97 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsBusyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "device busy",
+			err:      &os.PathError{Op: "open", Path: "/dev/x", Err: syscall.EBUSY},
+			expected: true,
+		},
+		{
+			name:     "try again",
+			err:      syscall.EAGAIN,
+			expected: true,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      fmt.Errorf("read: %w", os.ErrDeadlineExceeded),
+			expected: true,
+		},
+		{
+			name:     "file not found",
+			err:      &os.PathError{Op: "open", Path: "missing", Err: syscall.ENOENT},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IsBusyError(tt.err)
+			if result != tt.expected {
+				t.Errorf("IsBusyError(%v) = %v, expected %v", tt.err, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	data, err := ReadFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "content" {
+		t.Errorf("Expected 'content', got '%s'", data)
+	}
+
+	// Missing files are not retried
+	attempts := 0
+	_, err = ReadFile(context.Background(), filepath.Join(t.TempDir(), "missing"),
+		WithOnRetry(func(context.Context, int, error, time.Duration) { attempts++ }),
+	)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+	if attempts != 0 {
+		t.Errorf("Expected no retries for missing file, got %d", attempts)
+	}
+}
+
+func TestDial(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	conn, err := Dial(context.Background(), "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn.Close()
+}
@@ -2,7 +2,11 @@ package retrier
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net"
+	"net/url"
 	"syscall"
 	"testing"
 )
@@ -78,6 +82,32 @@ func TestIsNetworkError(t *testing.T) {
 			err:      context.DeadlineExceeded,
 			expected: true,
 		},
+		{
+			name:     "unexpected EOF",
+			err:      fmt.Errorf("read response: %w", io.ErrUnexpectedEOF),
+			expected: true,
+		},
+		{
+			name:     "unexpected EOF message without the sentinel",
+			err:      &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("unexpected EOF")},
+			expected: false,
+		},
+		{
+			name:     "TLS handshake timeout",
+			err:      &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("net/http: TLS handshake timeout")},
+			expected: true,
+		},
+		{
+			name: "HTTP/2 GOAWAY",
+			err: &url.Error{Op: "Post", URL: "https://example.com", Err: errors.New(
+				`http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=NO_ERROR, debug=""`)},
+			expected: true,
+		},
+		{
+			name:     "plain EOF",
+			err:      io.EOF,
+			expected: false,
+		},
 		{
 			name:     "non-network error",
 			err:      syscall.ENOENT, // File not found
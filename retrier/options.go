@@ -1,6 +1,9 @@
 package retrier
 
-import "time"
+import (
+	"math/rand"
+	"time"
+)
 
 // Option configures retry behavior
 type Option func(*config)
@@ -19,6 +22,24 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithMaxCumulativeDelay stops retrying once the next delay would take the sum of the delays between
+// attempts past d. Unlike the timeout, time spent in attempts is not counted, so it bounds how long
+// the caller waits on retries alone. The initial delay is not counted either
+func WithMaxCumulativeDelay(d time.Duration) Option {
+	return func(c *config) {
+		c.maxCumulativeDelay = d
+	}
+}
+
+// WithDelayDecorator adjusts every delay proposed by the policy, so callers can derive delays from errors,
+// such as the reset time of a throttling error, without writing a policy. Alignment and the cumulative
+// delay bound apply to the adjusted delay
+func WithDelayDecorator(decorator DelayDecorator) Option {
+	return func(c *config) {
+		c.delayDecorator = decorator
+	}
+}
+
 // WithRetryCondition sets the condition for retrying on errors
 func WithRetryCondition(condition RetryCondition) Option {
 	return func(c *config) {
@@ -27,7 +48,7 @@ func WithRetryCondition(condition RetryCondition) Option {
 }
 
 // WithOnRetry sets a callback function called on each retry attempt
-func WithOnRetry(callback func(attempt int, err error, delay time.Duration)) Option {
+func WithOnRetry(callback OnRetryFunc) Option {
 	return func(c *config) {
 		c.onRetry = callback
 	}
@@ -36,6 +57,9 @@ func WithOnRetry(callback func(attempt int, err error, delay time.Duration)) Opt
 // WithPolicy sets the retry policy
 func WithPolicy(policy RetryPolicy) Option {
 	return func(c *config) {
+		if c.jitter != nil {
+			c.conflicts = append(c.conflicts, "jitter is discarded by a policy set after WithJitter")
+		}
 		c.policy = policy
 	}
 }
@@ -55,11 +79,44 @@ func WithLinearBackoff(baseDelay time.Duration) Option {
 	return WithPolicy(NewLinearBackoffPolicy(baseDelay, 0))
 }
 
-// WithJitter adds jitter to the current policy
+// WithJitter adds jitter to the current policy. It must follow the option setting the policy
 func WithJitter(jitterFactor float64) Option {
 	return func(c *config) {
-		if c.policy != nil {
-			c.policy = NewJitterPolicy(c.policy, jitterFactor)
+		c.jitter = &jitterFactor
+		if c.policy == nil {
+			c.conflicts = append(c.conflicts, "jitter requires a retry policy")
+			return
 		}
+		c.policy = NewJitterPolicy(c.policy, jitterFactor)
+	}
+}
+
+// WithInitialDelay delays the first attempt, counted against the total timeout
+func WithInitialDelay(delay time.Duration) Option {
+	return func(c *config) {
+		c.initialDelay = delay
+	}
+}
+
+// WithAlignTo extends each retry delay to the next wall-clock boundary of the interval,
+// for rate-limited APIs whose quota resets at fixed times such as every full minute
+func WithAlignTo(interval time.Duration) Option {
+	return func(c *config) {
+		c.alignTo = interval
+	}
+}
+
+// WithRandSource sets the source of jitter randomness for all randomized policies of the operation,
+// instead of the global math/rand source, making delays deterministic in tests
+func WithRandSource(source rand.Source) Option {
+	return func(c *config) {
+		c.rng = newRand(source)
+	}
+}
+
+// WithConsecutiveSuccesses sets how many times in a row the check passed to Until must succeed
+func WithConsecutiveSuccesses(n int) Option {
+	return func(c *config) {
+		c.consecutiveSuccesses = n
 	}
 }
@@ -0,0 +1,51 @@
+package retrier
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// WithParallelism bounds how many inputs Map retries concurrently, GOMAXPROCS by default
+func WithParallelism(n int) Option {
+	return func(c *config) {
+		c.parallelism = n
+	}
+}
+
+// Map retries fn for every input independently, each with its own attempts and backoff,
+// while running at most the configured parallelism concurrently. Results are returned in input order.
+// Inputs not started before the context is done fail with the context error
+func Map[T any](ctx context.Context, inputs []T, fn func(ctx context.Context, input T) error, options ...Option) []*Result {
+	cfg := newConfig(options)
+	results := make([]*Result, len(inputs))
+
+	parallelism := cfg.parallelism
+	if parallelism == 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	// An invalid parallelism is reported by the validation of every operation
+	slots := make(chan struct{}, max(parallelism, 1))
+
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = &Result{LastErr: ctx.Err(), StartTime: time.Now()}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i] = do(ctx, func(ctx context.Context) error { return fn(ctx, input) }, cfg)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
@@ -38,6 +38,9 @@ type ExponentialBackoffPolicy struct {
 	jitter      float64
 	maxDelay    time.Duration
 	maxAttempts int
+	// maxElapsed bounds the sum of the delays between attempts, 0 means no limit
+	maxElapsed time.Duration
+	rng        *rand.Rand
 }
 
 // NewExponentialBackoffPolicy creates a new exponential backoff policy
@@ -59,19 +62,48 @@ func (p *ExponentialBackoffPolicy) WithMaxAttempts(maxAttempts int) *Exponential
 	return p
 }
 
+// WithRandSource sets the source of the jitter randomness instead of the global math/rand source
+func (p *ExponentialBackoffPolicy) WithRandSource(source rand.Source) *ExponentialBackoffPolicy {
+	p.rng = newRand(source)
+	return p
+}
+
 func (p *ExponentialBackoffPolicy) ShouldRetry(attempt int, err error) bool {
+	if p.maxElapsed > 0 && p.totalDelay(attempt) > p.maxElapsed {
+		return false
+	}
 	if p.maxAttempts <= 0 {
 		return true // No limit
 	}
 	return attempt < p.maxAttempts
 }
 
+// totalDelay returns the sum of the delays without jitter up to and including the delay after attempt
+func (p *ExponentialBackoffPolicy) totalDelay(attempt int) time.Duration {
+	var total time.Duration
+	for i := 0; i <= attempt && total <= p.maxElapsed; i++ {
+		delay := time.Duration(float64(p.baseDelay) * math.Pow(p.multiplier, float64(i)))
+		if p.maxDelay > 0 && delay > p.maxDelay {
+			delay = p.maxDelay
+		}
+		total += delay
+	}
+	return total
+}
+
 func (p *ExponentialBackoffPolicy) NextDelay(attempt int) time.Duration {
+	return p.nextDelayRand(attempt, nil)
+}
+
+func (p *ExponentialBackoffPolicy) nextDelayRand(attempt int, rng *rand.Rand) time.Duration {
+	if rng == nil {
+		rng = p.rng
+	}
 	delay := time.Duration(float64(p.baseDelay) * math.Pow(p.multiplier, float64(attempt)))
 	
 	// Apply jitter if specified
 	if p.jitter > 0 {
-		jitterAmount := float64(delay) * p.jitter * (rand.Float64()*2 - 1) // Random between -jitter and +jitter
+		jitterAmount := float64(delay) * p.jitter * (randFloat64(rng)*2 - 1) // Random between -jitter and +jitter
 		delay = time.Duration(float64(delay) + jitterAmount)
 	}
 	
@@ -139,6 +171,7 @@ func (p *LinearBackoffPolicy) NextDelay(attempt int) time.Duration {
 type JitterPolicy struct {
 	policy RetryPolicy
 	jitter float64
+	rng    *rand.Rand
 }
 
 // NewJitterPolicy creates a new jitter policy that wraps another policy
@@ -155,16 +188,29 @@ func NewJitterPolicy(policy RetryPolicy, jitter float64) *JitterPolicy {
 	}
 }
 
+// WithRandSource sets the source of the jitter randomness instead of the global math/rand source
+func (p *JitterPolicy) WithRandSource(source rand.Source) *JitterPolicy {
+	p.rng = newRand(source)
+	return p
+}
+
 func (p *JitterPolicy) ShouldRetry(attempt int, err error) bool {
 	return p.policy.ShouldRetry(attempt, err)
 }
 
 func (p *JitterPolicy) NextDelay(attempt int) time.Duration {
-	delay := p.policy.NextDelay(attempt)
+	return p.nextDelayRand(attempt, nil)
+}
+
+func (p *JitterPolicy) nextDelayRand(attempt int, rng *rand.Rand) time.Duration {
+	if rng == nil {
+		rng = p.rng
+	}
+	delay := policyDelay(p.policy, attempt, rng)
 	
 	if p.jitter > 0 {
 		// Add random jitter: delay * (1 ± jitter)
-		jitterAmount := float64(delay) * p.jitter * (rand.Float64()*2 - 1)
+		jitterAmount := float64(delay) * p.jitter * (randFloat64(rng)*2 - 1)
 		delay = time.Duration(float64(delay) + jitterAmount)
 	}
 	
@@ -198,6 +244,10 @@ func (p *ConditionalPolicy) NextDelay(attempt int) time.Duration {
 	return p.policy.NextDelay(attempt)
 }
 
+func (p *ConditionalPolicy) nextDelayRand(attempt int, rng *rand.Rand) time.Duration {
+	return policyDelay(p.policy, attempt, rng)
+}
+
 // CustomPolicy allows for custom retry logic
 type CustomPolicy struct {
 	shouldRetryFunc func(attempt int, err error) bool
@@ -0,0 +1,56 @@
+package retrier
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// randomizedPolicy is implemented by policies whose delays use randomness,
+// so a source configured with WithRandSource can be passed down through wrapping policies
+type randomizedPolicy interface {
+	// nextDelayRand is NextDelay using rng, or the policy's own source if rng is nil
+	nextDelayRand(attempt int, rng *rand.Rand) time.Duration
+}
+
+// policyDelay returns the next delay of the policy, using rng if the policy is randomized
+func policyDelay(policy RetryPolicy, attempt int, rng *rand.Rand) time.Duration {
+	if randomized, ok := policy.(randomizedPolicy); ok {
+		return randomized.nextDelayRand(attempt, rng)
+	}
+	return policy.NextDelay(attempt)
+}
+
+// randFloat64 returns a float from rng, or from the global source if rng is nil
+func randFloat64(rng *rand.Rand) float64 {
+	if rng == nil {
+		return rand.Float64()
+	}
+	return rng.Float64()
+}
+
+// lockedSource makes a rand.Source safe for concurrent use without contending on the global source
+type lockedSource struct {
+	mu     sync.Mutex
+	source rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.source.Seed(seed)
+}
+
+// newRand wraps the source for concurrent use, nil if the source is nil
+func newRand(source rand.Source) *rand.Rand {
+	if source == nil {
+		return nil
+	}
+	return rand.New(&lockedSource{source: source})
+}
@@ -0,0 +1,155 @@
+package retrier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RetryState is the progress of a resumable retry loop, saved before every wait
+type RetryState struct {
+	// Attempts is the number of attempts made so far
+	Attempts int `json:"attempts"`
+	// NextAttempt is the earliest time of the next attempt
+	NextAttempt time.Time `json:"next_attempt"`
+	// LastError is the message of the error of the last attempt
+	LastError string `json:"last_error"`
+}
+
+// StateStore persists the state of resumable retry loops by key
+type StateStore interface {
+	// Load returns the state saved for the key, false if there is none
+	Load(ctx context.Context, key string) (RetryState, bool, error)
+	// Save replaces the state of the key
+	Save(ctx context.Context, key string, state RetryState) error
+	// Delete removes the state of the key, if any
+	Delete(ctx context.Context, key string) error
+}
+
+// resumeState identifies the stored state of a resumable retry loop
+type resumeState struct {
+	key   string
+	store StateStore
+}
+
+// ResumeDo executes a function with retry logic like Do, persisting its progress in the store under the
+// key, so very long retry loops such as provisioning pollers continue where a previous process left off
+// after a restart: attempts are counted from the saved attempts and the first attempt waits for the saved
+// next attempt time. The state is deleted once the loop succeeds or gives up and kept when the context
+// ends. A store error ends the loop
+func ResumeDo(ctx context.Context, key string, fn RetryableFunc, store StateStore, options ...Option) *Result {
+	cfg := newConfig(options)
+	cfg.resume = &resumeState{key: key, store: store}
+	return do(ctx, func(context.Context) error { return fn() }, cfg)
+}
+
+// resumePoint returns the index of the first attempt and how long to wait before it: the saved progress
+// when resuming, otherwise the initial delay. At least one attempt is left, even if the saved state used
+// all attempts
+func (c *config) resumePoint(ctx context.Context) (int, time.Duration, error) {
+	if c.resume == nil {
+		return 0, c.initialDelay, nil
+	}
+
+	state, ok, err := c.resume.store.Load(ctx, c.resume.key)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load retry state '%s': %w", c.resume.key, err)
+	}
+	if !ok || state.Attempts <= 0 {
+		return 0, c.initialDelay, nil
+	}
+	return min(state.Attempts, c.maxAttempts-1), max(time.Until(state.NextAttempt), 0), nil
+}
+
+// saveProgress saves the state of a resumable loop before waiting for the next attempt
+func (c *config) saveProgress(ctx context.Context, attempts int, delay time.Duration, err error) error {
+	if c.resume == nil {
+		return nil
+	}
+
+	state := RetryState{Attempts: attempts, NextAttempt: time.Now().Add(delay), LastError: err.Error()}
+	if err := c.resume.store.Save(ctx, c.resume.key, state); err != nil {
+		return fmt.Errorf("failed to save retry state '%s': %w", c.resume.key, err)
+	}
+	return nil
+}
+
+// finishResume deletes the state of a resumable loop that succeeded or gave up. It is kept when the
+// context ended, so the loop can be resumed
+func (c *config) finishResume(ctx context.Context) error {
+	if c.resume == nil || ctx.Err() != nil {
+		return nil
+	}
+	if err := c.resume.store.Delete(ctx, c.resume.key); err != nil {
+		return fmt.Errorf("failed to delete retry state '%s': %w", c.resume.key, err)
+	}
+	return nil
+}
+
+// FileStateStore is a StateStore keeping the state of each key in a JSON file of a directory
+type FileStateStore struct {
+	dir string
+}
+
+// NewFileStateStore creates a store in the directory, which must exist
+func NewFileStateStore(dir string) *FileStateStore {
+	return &FileStateStore{dir: dir}
+}
+
+// path returns the file of the key, escaped so any key maps to a single file name
+func (s *FileStateStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// Load returns the state saved for the key, false if there is none
+func (s *FileStateStore) Load(ctx context.Context, key string) (RetryState, bool, error) {
+	var state RetryState
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, false, err
+	}
+	return state, true, nil
+}
+
+// Save replaces the state of the key, writing a temporary file renamed over the previous one
+// so a crash can't leave a partial state
+func (s *FileStateStore) Save(ctx context.Context, key string, state RetryState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".retry-state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// Delete removes the state of the key, if any
+func (s *FileStateStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
+	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RetryableFunc is a function that can be retried
 type RetryableFunc func() error
 
+// RetryableValueFunc is a function returning a value that can be retried
+type RetryableValueFunc[T any] func() (T, error)
+
 // RetryPolicy defines how retries should be performed
 type RetryPolicy interface {
 	// ShouldRetry determines if a retry should be attempted
@@ -24,6 +32,14 @@ type RetryPolicy interface {
 // RetryCondition determines if an error should trigger a retry
 type RetryCondition func(error) bool
 
+// DelayDecorator adjusts the delay the policy proposed after the failed attempt, numbered from 1, e.g.
+// to wait until the reset time of a throttling error. Negative results are treated as no delay
+type DelayDecorator func(attempt int, proposed time.Duration, err error) time.Duration
+
+// OnRetryFunc is called before waiting for the next attempt. ctx is the context of the operation, so
+// request-scoped values such as request IDs or loggers can be extracted from it
+type OnRetryFunc func(ctx context.Context, attempt int, err error, delay time.Duration)
+
 // Result contains the result of a retry operation
 type Result struct {
 	attempts  atomic.Int64
@@ -64,8 +80,30 @@ type config struct {
 	maxAttempts    int
 	timeout        time.Duration
 	retryCondition RetryCondition
-	onRetry        func(attempt int, err error, delay time.Duration)
+	onRetry        OnRetryFunc
 	policy         RetryPolicy
+	tracer         trace.Tracer
+	initialDelay   time.Duration
+	alignTo        time.Duration
+	rng            *rand.Rand
+	// consecutiveSuccesses is the streak of successes required by Until
+	consecutiveSuccesses int
+	// idempotencyKey generates the key passed to every attempt of DoWithKey
+	idempotencyKey func() string
+	// parallelism bounds the concurrent operations of Map, 0 means GOMAXPROCS
+	parallelism int
+	// jitter is the last jitter factor requested with WithJitter
+	jitter *float64
+	// conflicts records option combinations detected while applying options
+	conflicts []string
+	// failureInjector forces errors for attempts in tests
+	failureInjector FailureInjector
+	// maxCumulativeDelay bounds the sum of the delays between attempts, 0 means no bound
+	maxCumulativeDelay time.Duration
+	// delayDecorator adjusts the delays proposed by the policy
+	delayDecorator DelayDecorator
+	// resume persists the progress of loops run by ResumeDo
+	resume *resumeState
 }
 
 // Common retry conditions
@@ -127,11 +165,35 @@ func IsNetworkError(err error) bool {
 		return true
 	}
 
+	// Check for connections closed in the middle of a response
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
 	// Check for context timeout (often network related)
 	if errors.Is(err, context.DeadlineExceeded) {
 		return true
 	}
 
+	return isTransportError(err)
+}
+
+// transportErrorMessages are messages of TLS and HTTP/2 transport failures whose error types are
+// unexported or live in other modules, such as net/http's handshake timeout and http2.GoAwayError
+var transportErrorMessages = []string{
+	"TLS handshake timeout",
+	"http2: server sent GOAWAY",
+}
+
+// isTransportError reports whether the error message contains a known transport failure.
+// Wrapped errors such as *url.Error carry the message of the cause
+func isTransportError(err error) bool {
+	msg := err.Error()
+	for _, transportMsg := range transportErrorMessages {
+		if strings.Contains(msg, transportMsg) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -161,24 +223,40 @@ func IsTemporaryError(err error) bool {
 // defaultConfig returns default retry configuration
 func defaultConfig() *config {
 	return &config{
-		maxAttempts:    3,
-		timeout:        30 * time.Second,
-		retryCondition: RetryAlways,
-		policy:         NewExponentialBackoffPolicy(100*time.Millisecond, 2.0, 0, 5*time.Second),
+		maxAttempts:          3,
+		timeout:              30 * time.Second,
+		retryCondition:       RetryAlways,
+		policy:               NewExponentialBackoffPolicy(100*time.Millisecond, 2.0, 0, 5*time.Second),
+		consecutiveSuccesses: 1,
 	}
 }
 
-// Do executes a function with retry logic
-func Do(ctx context.Context, fn RetryableFunc, options ...Option) *Result {
+// newConfig applies the options over the default configuration
+func newConfig(options []Option) *config {
 	cfg := defaultConfig()
 	for _, opt := range options {
 		opt(cfg)
 	}
+	return cfg
+}
+
+// Do executes a function with retry logic.
+// An invalid configuration fails the result with ErrInvalidConfig without calling the function
+func Do(ctx context.Context, fn RetryableFunc, options ...Option) *Result {
+	return do(ctx, func(context.Context) error { return fn() }, newConfig(options))
+}
 
+// do runs the retry loop, passing the operation context bounded by the timeout to every attempt
+func do(ctx context.Context, fn func(ctx context.Context) error, cfg *config) *Result {
 	result := &Result{
 		StartTime: time.Now(),
 	}
 
+	if err := cfg.validate(); err != nil {
+		result.LastErr = err
+		return result
+	}
+
 	// Create context with timeout if specified
 	if cfg.timeout > 0 {
 		var cancel context.CancelFunc
@@ -186,70 +264,137 @@ func Do(ctx context.Context, fn RetryableFunc, options ...Option) *Result {
 		defer cancel()
 	}
 
-	for attempt := 0; attempt < cfg.maxAttempts; attempt++ {
+	ctx, endRetry := cfg.startRetrySpan(ctx)
+	defer endRetry(result)
+
+	// Continue where a resumed loop left off, otherwise delay the first attempt if configured
+	first, wait, err := cfg.resumePoint(ctx)
+	if err == nil && wait > 0 {
+		err = sleep(ctx, wait)
+	}
+	if err != nil {
+		result.LastErr = err
+		result.Duration = time.Since(result.StartTime)
+		return result
+	}
+
+	var slept time.Duration
+	for attempt := first; attempt < cfg.maxAttempts; attempt++ {
 		result.attempts.Store(int64(attempt + 1))
+		endAttempt := cfg.startAttemptSpan(ctx, attempt+1)
 
-		err := fn()
+		err := cfg.runAttempt(ctx, attempt+1, fn)
 		if err == nil {
+			endAttempt(nil, false, 0)
 			result.Success = true
+			if err := cfg.finishResume(ctx); err != nil {
+				result.LastErr = err
+			}
 			result.Duration = time.Since(result.StartTime)
 			return result
 		}
 
 		result.LastErr = err
 
-		// Check if we should retry
-		if !cfg.retryCondition(err) {
-			break
-		}
-
-		// Check if policy allows retry
-		if cfg.policy != nil && !cfg.policy.ShouldRetry(attempt, err) {
+		// Check if we should retry, if the policy allows it and that this is not the last attempt
+		retry := cfg.retryCondition(err) &&
+			(cfg.policy == nil || cfg.policy.ShouldRetry(attempt, err)) &&
+			attempt < cfg.maxAttempts-1
+		if !retry {
+			endAttempt(err, false, 0)
 			break
 		}
 
-		// Don't retry on the last attempt
-		if attempt == cfg.maxAttempts-1 {
+		delay := cfg.retryDelay(attempt, err)
+		if !cfg.withinDelayBudget(slept, delay) {
+			endAttempt(err, false, 0)
 			break
 		}
+		slept += delay
+		endAttempt(err, true, delay)
 
-		// Calculate delay
-		var delay time.Duration
-		if cfg.policy != nil {
-			delay = cfg.policy.NextDelay(attempt)
+		if saveErr := cfg.saveProgress(ctx, attempt+1, delay, err); saveErr != nil {
+			result.LastErr = saveErr
+			result.Duration = time.Since(result.StartTime)
+			return result
 		}
 
 		// Call retry callback
 		if cfg.onRetry != nil {
-			cfg.onRetry(attempt+1, err, delay)
+			cfg.onRetry(ctx, attempt+1, err, delay)
 		}
 
 		// Wait for the delay or context cancellation
-		select {
-		case <-ctx.Done():
-			result.LastErr = ctx.Err()
+		if err := sleep(ctx, delay); err != nil {
+			result.LastErr = err
 			result.Duration = time.Since(result.StartTime)
 			return result
-		case <-time.After(delay):
-			// Continue to next attempt
 		}
 	}
 
+	if err := cfg.finishResume(ctx); err != nil {
+		result.LastErr = errors.Join(result.LastErr, err)
+	}
 	result.Duration = time.Since(result.StartTime)
 	return result
 }
 
-// DoAsync executes a function with retry logic asynchronously
-func DoAsync(ctx context.Context, fn RetryableFunc, options ...Option) <-chan *Result {
-	resultChan := make(chan *Result, 1)
+// retryDelay returns the delay after the failed attempt with the index: the policy's delay, adjusted by
+// the delay decorator and aligned to the wall clock
+func (c *config) retryDelay(attempt int, err error) time.Duration {
+	var delay time.Duration
+	if c.policy != nil {
+		delay = policyDelay(c.policy, attempt, c.rng)
+	}
+	if c.delayDecorator != nil {
+		delay = max(c.delayDecorator(attempt+1, delay, err), 0)
+	}
+	if c.alignTo > 0 {
+		delay = alignDelay(time.Now(), delay, c.alignTo)
+	}
+	return delay
+}
+
+// withinDelayBudget reports whether waiting for the delay after having slept for slept
+// stays within the cumulative delay bound
+func (c *config) withinDelayBudget(slept, delay time.Duration) bool {
+	return c.maxCumulativeDelay <= 0 || slept+delay <= c.maxCumulativeDelay
+}
+
+// sleep waits for the delay or until the context is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 
-	go func() {
-		defer close(resultChan)
-		result := Do(ctx, fn, options...)
-		resultChan <- result
-	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// alignDelay extends the delay so the next attempt happens on the first wall-clock boundary
+// of the alignment at or after now+delay
+func alignDelay(now time.Time, delay, align time.Duration) time.Duration {
+	earliest := now.Add(delay)
+	next := earliest.Truncate(align)
+	if next.Before(earliest) {
+		next = next.Add(align)
+	}
+	return next.Sub(now)
+}
 
-	return resultChan
+// DoValue executes a function returning a value with retry logic.
+// The value of the last attempt is returned alongside the result
+func DoValue[T any](ctx context.Context, fn RetryableValueFunc[T], options ...Option) (T, *Result) {
+	var value T
+	result := Do(ctx, func() error {
+		v, err := fn()
+		value = v
+		return err
+	}, options...)
+	return value, result
 }
 
 // Retry is a convenience function that returns only the error
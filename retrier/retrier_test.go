@@ -0,0 +1,633 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithInitialDelay(t *testing.T) {
+	start := time.Now()
+	var firstAttempt time.Duration
+
+	result := Do(context.Background(), func() error {
+		firstAttempt = time.Since(start)
+		return nil
+	}, WithInitialDelay(50*time.Millisecond))
+
+	if !result.Success {
+		t.Fatalf("Expected success, got %v", result.Error())
+	}
+	if firstAttempt < 50*time.Millisecond {
+		t.Errorf("Expected first attempt after 50ms, got %v", firstAttempt)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	result = Do(ctx, func() error {
+		called = true
+		return nil
+	}, WithInitialDelay(time.Hour))
+
+	if called || !errors.Is(result.Error(), context.Canceled) {
+		t.Errorf("Expected cancellation during the initial delay, called %v error %v", called, result.Error())
+	}
+}
+
+func TestAlignDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		delay time.Duration
+		want  time.Duration
+	}{
+		{"rounds up to the next minute", time.Second, 50 * time.Second},
+		{"delay crossing a boundary", 55 * time.Second, 110 * time.Second},
+		{"exactly on a boundary", 50 * time.Second, 50 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alignDelay(now, tt.delay, time.Minute); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithRandSource(t *testing.T) {
+	delays := func() []time.Duration {
+		var delays []time.Duration
+		Do(context.Background(), func() error {
+			return errors.New("failed")
+		},
+			WithMaxAttempts(4),
+			WithPolicy(NewExponentialBackoffPolicy(time.Microsecond, 2, 0.5, 0)),
+			WithJitter(0.5),
+			WithRandSource(rand.NewSource(42)),
+			WithOnRetry(func(ctx context.Context, attempt int, err error, delay time.Duration) {
+				delays = append(delays, delay)
+			}),
+		)
+		return delays
+	}
+
+	first, second := delays(), delays()
+	if len(first) != 3 || !slices.Equal(first, second) {
+		t.Errorf("Expected identical delays with the same seed, got %v and %v", first, second)
+	}
+
+	policy := NewJitterPolicy(NewFixedBackoffPolicy(time.Second, 0), 0.5).WithRandSource(rand.NewSource(1))
+	same := NewJitterPolicy(NewFixedBackoffPolicy(time.Second, 0), 0.5).WithRandSource(rand.NewSource(1))
+	if policy.NextDelay(0) != same.NextDelay(0) {
+		t.Error("Expected per-policy sources with the same seed to produce the same delay")
+	}
+}
+
+func TestDoBatch(t *testing.T) {
+	failures := map[string]int{"b": 1, "c": 10}
+	permanent := errors.New("invalid item")
+
+	var retries int
+	result := DoBatch(context.Background(), []string{"a", "b", "c", "d"}, func(item string) error {
+		if item == "d" {
+			return permanent
+		}
+		if failures[item] > 0 {
+			failures[item]--
+			return errors.New("throttled")
+		}
+		return nil
+	},
+		WithMaxAttempts(3),
+		WithFixedBackoff(time.Millisecond),
+		WithRetryCondition(func(err error) bool { return !errors.Is(err, permanent) }),
+		WithOnRetry(func(ctx context.Context, attempt int, err error, delay time.Duration) { retries++ }),
+	)
+
+	if want := []int{1, 2, 3, 1}; !slices.Equal(result.Attempts, want) {
+		t.Errorf("Expected attempts %v, got %v", want, result.Attempts)
+	}
+	if result.Passes != 3 || retries != 2 {
+		t.Errorf("Expected 3 passes and 2 retries, got %d and %d", result.Passes, retries)
+	}
+	if len(result.Failed) != 2 || result.Failed[0].Item != "c" || result.Failed[1].Item != "d" {
+		t.Fatalf("Expected items c and d to fail, got %+v", result.Failed)
+	}
+	if !errors.Is(result.Error(), permanent) || result.IsSuccess() {
+		t.Errorf("Expected batch error to wrap item errors, got %v", result.Error())
+	}
+}
+
+func TestUntil(t *testing.T) {
+	results := []error{nil, errors.New("flap"), nil, nil, nil, nil}
+	calls := 0
+
+	result := Until(context.Background(), func() error {
+		err := results[calls]
+		calls++
+		return err
+	}, WithConsecutiveSuccesses(3), WithMaxAttempts(10), WithFixedBackoff(time.Millisecond))
+
+	if !result.IsSuccess() || calls != 5 {
+		t.Errorf("Expected success after 5 checks, got %v after %d", result, calls)
+	}
+
+	result = Until(context.Background(), func() error { return nil },
+		WithConsecutiveSuccesses(3), WithMaxAttempts(2), WithFixedBackoff(time.Millisecond))
+	if result.IsSuccess() || !errors.Is(result.Error(), ErrNotConsecutive) {
+		t.Errorf("Expected ErrNotConsecutive when attempts run out, got %v", result.Error())
+	}
+
+	result = Until(context.Background(), func() error { return errors.New("fatal") },
+		WithConsecutiveSuccesses(2), WithRetryCondition(RetryNever))
+	if result.Attempts() != 1 {
+		t.Errorf("Expected non-retryable errors to stop polling, got %d attempts", result.Attempts())
+	}
+
+	if err := Validate(WithConsecutiveSuccesses(0)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestWithOnRetryContext(t *testing.T) {
+	type requestIDKey struct{}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-7")
+
+	var ids []any
+	Do(ctx, func() error {
+		return errors.New("failed")
+	},
+		WithMaxAttempts(3),
+		WithFixedBackoff(time.Millisecond),
+		WithTimeout(time.Second),
+		WithOnRetry(func(ctx context.Context, attempt int, err error, delay time.Duration) {
+			ids = append(ids, ctx.Value(requestIDKey{}))
+		}),
+	)
+	if want := []any{"req-7", "req-7"}; !slices.Equal(ids, want) {
+		t.Errorf("Expected request IDs %v, got %v", want, ids)
+	}
+
+	var batchIDs []any
+	DoBatch(ctx, []int{1}, func(int) error {
+		return errors.New("failed")
+	},
+		WithMaxAttempts(2),
+		WithFixedBackoff(time.Millisecond),
+		WithOnRetry(func(ctx context.Context, attempt int, err error, delay time.Duration) {
+			batchIDs = append(batchIDs, ctx.Value(requestIDKey{}))
+		}),
+	)
+	if want := []any{"req-7"}; !slices.Equal(batchIDs, want) {
+		t.Errorf("Expected batch request IDs %v, got %v", want, batchIDs)
+	}
+}
+
+func TestDoWithKey(t *testing.T) {
+	var keys []string
+	result := DoWithKey(context.Background(), func(ctx context.Context, key string) error {
+		keys = append(keys, key)
+		if len(keys) < 3 {
+			return errors.New("gateway timeout")
+		}
+		return nil
+	}, WithIdempotencyKey(func() string { return "order-42" }), WithFixedBackoff(time.Millisecond))
+
+	if !result.IsSuccess() || !slices.Equal(keys, []string{"order-42", "order-42", "order-42"}) {
+		t.Errorf("Expected the same key on every attempt, got %v", keys)
+	}
+
+	var generated []string
+	for range 2 {
+		DoWithKey(context.Background(), func(ctx context.Context, key string) error {
+			generated = append(generated, key)
+			return nil
+		})
+	}
+	if generated[0] == "" || generated[0] == generated[1] {
+		t.Errorf("Expected a fresh random key per operation, got %v", generated)
+	}
+}
+
+func TestMap(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	calls := make(map[int]int)
+
+	results := Map(context.Background(), []int{1, 2, 3, 4, 5, 6}, func(ctx context.Context, input int) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		calls[input]++
+		attempt := calls[input]
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if input%2 == 0 && attempt < 2 {
+			return errors.New("conflict")
+		}
+		if input == 5 {
+			return errors.New("permanent")
+		}
+		return nil
+	}, WithParallelism(2), WithMaxAttempts(3), WithFixedBackoff(time.Millisecond))
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, got %d", peak)
+	}
+	for i, result := range results {
+		input := i + 1
+		switch {
+		case input == 5:
+			if result.IsSuccess() || result.Attempts() != 3 {
+				t.Errorf("Expected input 5 to fail after 3 attempts, got %v", result)
+			}
+		case input%2 == 0:
+			if !result.IsSuccess() || result.Attempts() != 2 {
+				t.Errorf("Expected input %d to succeed on the second attempt, got %v", input, result)
+			}
+		default:
+			if !result.IsSuccess() || result.Attempts() != 1 {
+				t.Errorf("Expected input %d to succeed at once, got %v", input, result)
+			}
+		}
+	}
+}
+
+func TestMaintenanceWindowPolicy(t *testing.T) {
+	// Nightly downtime from 23:30 to 01:00 UTC
+	window := MaintenanceWindow{Start: 23*time.Hour + 30*time.Minute, End: time.Hour, Location: time.UTC}
+	clock := time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)
+	now := func() time.Time { return clock }
+
+	policy := NewMaintenanceWindowPolicy(NewFixedBackoffPolicy(time.Minute, 0), window).WithClock(now)
+	if delay := policy.NextDelay(0); delay != time.Minute {
+		t.Errorf("Expected unchanged delay outside the window, got %v", delay)
+	}
+
+	clock = time.Date(2024, 3, 1, 23, 59, 30, 0, time.UTC)
+	if delay := policy.NextDelay(0); delay != time.Hour+30*time.Second {
+		t.Errorf("Expected delay extended to 01:00, got %v", delay)
+	}
+
+	suppressing := NewMaintenanceWindowPolicy(NewFixedBackoffPolicy(time.Minute, 0), window).WithClock(now).WithSuppressRetries()
+	if suppressing.ShouldRetry(0, errors.New("fail")) {
+		t.Error("Expected no retry during the window")
+	}
+	clock = time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC)
+	if !suppressing.ShouldRetry(0, errors.New("fail")) {
+		t.Error("Expected retry after the window")
+	}
+
+	weekends := NewMaintenanceWindowPolicy(NewFixedBackoffPolicy(time.Minute, 0), MaintenanceWindow{
+		Start:    2 * time.Hour,
+		End:      4 * time.Hour,
+		Weekdays: []time.Weekday{time.Sunday},
+		Location: time.UTC,
+	}).WithClock(now)
+	clock = time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC) // Saturday
+	if delay := weekends.NextDelay(0); delay != time.Minute {
+		t.Errorf("Expected no window on Saturday, got %v", delay)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	errThrottled := errors.New("throttled")
+	errInvalid := errors.New("invalid request")
+	wrappedThrottled := fmt.Errorf("call failed: %w", errThrottled)
+	opErr := fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: errInvalid})
+
+	retryable := Or(
+		OnErrorsIs(errThrottled, context.DeadlineExceeded),
+		And(OnErrorsAs[*net.OpError](), Not(OnErrorsIs(errInvalid))),
+	)
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{wrappedThrottled, true},
+		{context.DeadlineExceeded, true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{opErr, false},
+		{errInvalid, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("condition(%v) = %v, expected %v", tt.err, got, tt.want)
+		}
+	}
+
+	attempts := 0
+	Do(context.Background(), func() error {
+		attempts++
+		return wrappedThrottled
+	}, WithRetryCondition(OnErrorsIs(errThrottled)), WithMaxAttempts(3), WithFixedBackoff(time.Millisecond))
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+type testStatusCode uint32
+
+type codedError struct {
+	code testStatusCode
+}
+
+func (e *codedError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestOnCodes(t *testing.T) {
+	const (
+		codeUnavailable testStatusCode = 14
+		codeThrottled   testStatusCode = 8
+		codeInvalid     testStatusCode = 3
+	)
+	extract := func(err error) (testStatusCode, bool) {
+		var coded *codedError
+		if errors.As(err, &coded) {
+			return coded.code, true
+		}
+		return 0, false
+	}
+	retryable := OnCodes(extract, codeUnavailable, codeThrottled)
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&codedError{codeUnavailable}, true},
+		{fmt.Errorf("call: %w", &codedError{codeThrottled}), true},
+		{&codedError{codeInvalid}, false},
+		{errors.New("no code"), false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("condition(%v) = %v, expected %v", tt.err, got, tt.want)
+		}
+	}
+
+	awsCode := func(err error) (string, bool) { return err.Error(), true }
+	if !OnCodes(awsCode, "ThrottlingException")(errors.New("ThrottlingException")) {
+		t.Error("Expected string codes to match")
+	}
+}
+
+func TestDoAsyncFuture(t *testing.T) {
+	errBusy := errors.New("busy")
+	retryAll := WithRetryCondition(func(error) bool { return true })
+
+	var calls atomic.Int64
+	future := DoAsync(context.Background(), func() error {
+		if calls.Add(1) < 3 {
+			return errBusy
+		}
+		return nil
+	}, WithMaxAttempts(5), WithFixedBackoff(time.Millisecond), retryAll)
+
+	<-future.Done()
+	for range 2 {
+		result, err := future.Wait(context.Background())
+		if err != nil || !result.Success || result.Attempts() != 3 {
+			t.Fatalf("Expected success after 3 attempts, got %v, %v", result, err)
+		}
+	}
+
+	future = DoAsync(context.Background(), func() error {
+		return errBusy
+	}, WithMaxAttempts(1000), WithFixedBackoff(10*time.Millisecond), retryAll)
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := future.Wait(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected wait deadline, got %v", err)
+	}
+
+	future.Cancel()
+	result, err := future.Wait(context.Background())
+	if err != nil || result.Success || !errors.Is(result.LastErr, context.Canceled) {
+		t.Errorf("Expected cancelled result, got %v, %v", result, err)
+	}
+}
+
+func TestWithFailureInjector(t *testing.T) {
+	errInjected := errors.New("injected")
+
+	calls := 0
+	var retried []int
+	result := Do(context.Background(), func() error {
+		calls++
+		return nil
+	},
+		WithMaxAttempts(5),
+		WithFixedBackoff(time.Millisecond),
+		WithFailureInjector(FailFirst(2, errInjected)),
+		WithOnRetry(func(_ context.Context, attempt int, err error, _ time.Duration) {
+			if errors.Is(err, errInjected) {
+				retried = append(retried, attempt)
+			}
+		}),
+	)
+	if !result.Success || result.Attempts() != 3 || calls != 1 {
+		t.Errorf("Expected success on attempt 3 with one call, got %v after %d calls", result, calls)
+	}
+	if !slices.Equal(retried, []int{1, 2}) {
+		t.Errorf("Expected OnRetry for injected attempts 1 and 2, got %v", retried)
+	}
+
+	result = Do(context.Background(), func() error { return nil },
+		WithMaxAttempts(3), WithFixedBackoff(time.Millisecond), WithFailureInjector(FailFirst(3, errInjected)))
+	if result.Success || !errors.Is(result.LastErr, errInjected) {
+		t.Errorf("Expected exhaustion with the injected error, got %v", result)
+	}
+}
+
+func TestWithMaxCumulativeDelay(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	var delays []time.Duration
+	result := Do(context.Background(), func() error {
+		// Attempt time is not counted against the delay budget
+		time.Sleep(30 * time.Millisecond)
+		return errFailed
+	},
+		WithMaxAttempts(10),
+		WithFixedBackoff(20*time.Millisecond),
+		WithMaxCumulativeDelay(50*time.Millisecond),
+		WithOnRetry(func(_ context.Context, _ int, _ error, delay time.Duration) {
+			delays = append(delays, delay)
+		}),
+	)
+	if result.Attempts() != 3 || !errors.Is(result.LastErr, errFailed) {
+		t.Errorf("Expected 3 attempts within the delay budget, got %v", result)
+	}
+	if len(delays) != 2 {
+		t.Errorf("Expected 2 delays, got %v", delays)
+	}
+
+	if err := Validate(WithMaxCumulativeDelay(-time.Second)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig for a negative budget, got %v", err)
+	}
+}
+
+// throttledError carries the delay a rate limiter asked for
+type throttledError struct {
+	retryAfter time.Duration
+}
+
+func (e *throttledError) Error() string {
+	return "throttled"
+}
+
+func TestWithDelayDecorator(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	type call struct {
+		attempt  int
+		proposed time.Duration
+	}
+	var calls []call
+	var delays []time.Duration
+	attempt := 0
+	result := Do(context.Background(), func() error {
+		attempt++
+		if attempt == 2 {
+			return &throttledError{retryAfter: 3 * time.Millisecond}
+		}
+		return errFailed
+	},
+		WithMaxAttempts(4),
+		WithFixedBackoff(time.Millisecond),
+		WithDelayDecorator(func(attempt int, proposed time.Duration, err error) time.Duration {
+			calls = append(calls, call{attempt, proposed})
+			var throttled *throttledError
+			if errors.As(err, &throttled) {
+				return throttled.retryAfter
+			}
+			if attempt == 3 {
+				return -time.Second
+			}
+			return proposed
+		}),
+		WithOnRetry(func(_ context.Context, _ int, _ error, delay time.Duration) {
+			delays = append(delays, delay)
+		}),
+	)
+	if result.Attempts() != 4 {
+		t.Errorf("Expected 4 attempts, got %v", result)
+	}
+	wantCalls := []call{{1, time.Millisecond}, {2, time.Millisecond}, {3, time.Millisecond}}
+	if !slices.Equal(calls, wantCalls) {
+		t.Errorf("Expected decorator calls %v, got %v", wantCalls, calls)
+	}
+	wantDelays := []time.Duration{time.Millisecond, 3 * time.Millisecond, 0}
+	if !slices.Equal(delays, wantDelays) {
+		t.Errorf("Expected delays %v, got %v", wantDelays, delays)
+	}
+}
+
+func TestResumeDo(t *testing.T) {
+	store := NewFileStateStore(t.TempDir())
+	errFailed := errors.New("failed")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first process is stopped while waiting after the second attempt
+	calls := 0
+	result := ResumeDo(ctx, "provision/db-1", func() error {
+		calls++
+		return errFailed
+	},
+		store,
+		WithMaxAttempts(5),
+		WithFixedBackoff(time.Millisecond),
+		WithOnRetry(func(_ context.Context, attempt int, _ error, _ time.Duration) {
+			if attempt == 2 {
+				cancel()
+			}
+		}),
+	)
+	if result.Success || calls != 2 {
+		t.Fatalf("Expected the first run to stop after 2 attempts, got %d calls: %v", calls, result)
+	}
+	state, ok, err := store.Load(context.Background(), "provision/db-1")
+	if err != nil || !ok {
+		t.Fatalf("Expected saved state, got %v, %v", ok, err)
+	}
+	if state.Attempts != 2 || state.LastError != errFailed.Error() {
+		t.Errorf("Expected 2 attempts and the last error saved, got %+v", state)
+	}
+
+	// The next process continues the attempt count and removes the state on success
+	result = ResumeDo(context.Background(), "provision/db-1", func() error {
+		return nil
+	}, store, WithMaxAttempts(5))
+	if !result.Success || result.Attempts() != 3 {
+		t.Errorf("Expected success on attempt 3, got %v", result)
+	}
+	if _, ok, _ := store.Load(context.Background(), "provision/db-1"); ok {
+		t.Error("Expected state to be deleted after success")
+	}
+}
+
+func TestDoVersioned(t *testing.T) {
+	// A counter stored with a version, bumped concurrently during the first two attempts
+	var mu sync.Mutex
+	stored, storedVersion := 10, 1
+	loads := 0
+
+	load := func() (int, int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads++
+		return stored, storedVersion, nil
+	}
+	attempt := func(value, version int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if loads <= 2 {
+			// A concurrent writer wins the race
+			stored, storedVersion = stored+100, storedVersion+1
+		}
+		if version != storedVersion {
+			return fmt.Errorf("update counter: %w", ErrVersionConflict)
+		}
+		stored, storedVersion = value+1, storedVersion+1
+		return nil
+	}
+
+	result := DoVersioned(context.Background(), load, attempt,
+		WithMaxAttempts(5),
+		WithFixedBackoff(time.Millisecond),
+		WithRetryCondition(OnErrorsIs(ErrVersionConflict)),
+	)
+	if !result.Success || result.Attempts() != 3 || loads != 3 {
+		t.Fatalf("Expected success after reloading on every attempt, got %v with %d loads", result, loads)
+	}
+	if stored != 211 || storedVersion != 4 {
+		t.Errorf("Expected the increment applied to the fresh state, got %d at version %d", stored, storedVersion)
+	}
+
+	errUnavailable := errors.New("database unavailable")
+	result = DoVersioned(context.Background(), func() (int, int, error) {
+		return 0, 0, errUnavailable
+	}, attempt, WithMaxAttempts(3), WithFixedBackoff(time.Millisecond), WithRetryCondition(OnErrorsIs(ErrVersionConflict)))
+	if result.Attempts() != 1 || !errors.Is(result.LastErr, errUnavailable) {
+		t.Errorf("Expected the load error without retries, got %v", result)
+	}
+}
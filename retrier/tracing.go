@@ -0,0 +1,69 @@
+package retrier
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span and attribute names recorded by WithTracing
+const (
+	retrySpanName   = "retrier.Do"
+	attemptSpanName = "retrier.attempt"
+
+	attemptKey    = attribute.Key("retry.attempt")
+	attemptsKey   = attribute.Key("retry.attempts")
+	delayKey      = attribute.Key("retry.delay_ms")
+	successKey    = attribute.Key("retry.success")
+	willRetryKey  = attribute.Key("retry.will_retry")
+	maxAttemptKey = attribute.Key("retry.max_attempts")
+)
+
+// WithTracing records a span for the whole retry operation with a child span per attempt,
+// so traces show retry amplification instead of a single long client span
+func WithTracing(tracer trace.Tracer) Option {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
+
+// startRetrySpan starts the span covering all attempts. The returned function ends it
+func (c *config) startRetrySpan(ctx context.Context) (context.Context, func(result *Result)) {
+	if c.tracer == nil {
+		return ctx, func(*Result) {}
+	}
+
+	ctx, span := c.tracer.Start(ctx, retrySpanName, trace.WithAttributes(maxAttemptKey.Int(c.maxAttempts)))
+	return ctx, func(result *Result) {
+		span.SetAttributes(attemptsKey.Int(result.Attempts()), successKey.Bool(result.Success))
+		if !result.Success && result.LastErr != nil {
+			span.RecordError(result.LastErr)
+			span.SetStatus(codes.Error, result.LastErr.Error())
+		}
+		span.End()
+	}
+}
+
+// startAttemptSpan starts the span of a single attempt. The returned function ends it
+// with the attempt error and the delay before the next attempt, if one follows
+func (c *config) startAttemptSpan(ctx context.Context, attempt int) func(err error, retry bool, delay time.Duration) {
+	if c.tracer == nil {
+		return func(error, bool, time.Duration) {}
+	}
+
+	_, span := c.tracer.Start(ctx, attemptSpanName, trace.WithAttributes(attemptKey.Int(attempt)))
+	return func(err error, retry bool, delay time.Duration) {
+		span.SetAttributes(willRetryKey.Bool(retry))
+		if retry {
+			span.SetAttributes(delayKey.Int64(delay.Milliseconds()))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,66 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("retrier-test")
+
+	calls := 0
+	result := Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}, WithMaxAttempts(5), WithFixedBackoff(time.Millisecond), WithTracing(tracer))
+
+	if !result.Success {
+		t.Fatalf("Expected success, got %v", result.Error())
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 3 attempt spans and 1 retry span, got %d", len(spans))
+	}
+
+	root := spans[len(spans)-1]
+	if root.Name() != retrySpanName {
+		t.Errorf("Expected last ended span '%s', got '%s'", retrySpanName, root.Name())
+	}
+
+	for i, span := range spans[:3] {
+		if span.Name() != attemptSpanName {
+			t.Errorf("Expected span '%s', got '%s'", attemptSpanName, span.Name())
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("Expected attempt %d to be a child of the retry span", i+1)
+		}
+
+		failed := i < 2
+		if got := span.Status().Code == codes.Error; got != failed {
+			t.Errorf("Expected attempt %d error status %v, got %v", i+1, failed, got)
+		}
+
+		attrs := map[string]any{}
+		for _, attr := range span.Attributes() {
+			attrs[string(attr.Key)] = attr.Value.AsInterface()
+		}
+		if attrs[string(attemptKey)] != int64(i+1) {
+			t.Errorf("Expected attempt number %d, got %v", i+1, attrs[string(attemptKey)])
+		}
+		if _, ok := attrs[string(delayKey)]; ok != failed {
+			t.Errorf("Expected delay attribute on failed attempt %d only", i+1)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotConsecutive is reported for successful checks while the required streak of
+// consecutive successes is not reached yet
+var ErrNotConsecutive = errors.New("not enough consecutive successes")
+
+// Until calls fn until it succeeds the number of times in a row set with WithConsecutiveSuccesses,
+// for polling readiness checks that must be stable before being considered done. A failure resets
+// the streak. Checks are spaced by the retry policy and bounded by the attempts limit and timeout
+func Until(ctx context.Context, fn RetryableFunc, options ...Option) *Result {
+	required := newConfig(options).consecutiveSuccesses
+
+	streak := 0
+	check := func() error {
+		if err := fn(); err != nil {
+			streak = 0
+			return err
+		}
+
+		streak++
+		if streak < required {
+			return fmt.Errorf("%w: %d of %d", ErrNotConsecutive, streak, required)
+		}
+		return nil
+	}
+
+	// Successful checks short of the streak are always retried
+	options = append(options, func(c *config) {
+		if condition := c.retryCondition; condition != nil {
+			c.retryCondition = func(err error) bool {
+				return errors.Is(err, ErrNotConsecutive) || condition(err)
+			}
+		}
+	})
+
+	return Do(ctx, check, options...)
+}
@@ -0,0 +1,92 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrInvalidConfig is returned when retry options are invalid or conflict with each other
+var ErrInvalidConfig = errors.New("invalid retrier configuration")
+
+// validate reports invalid option values and conflicting option combinations
+func (c *config) validate() error {
+	var errs []error
+
+	if c.maxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("max attempts must be at least 1, got %d", c.maxAttempts))
+	}
+	if c.timeout < 0 {
+		errs = append(errs, fmt.Errorf("timeout must not be negative, got %v", c.timeout))
+	}
+	if c.initialDelay < 0 {
+		errs = append(errs, fmt.Errorf("initial delay must not be negative, got %v", c.initialDelay))
+	}
+	if c.maxCumulativeDelay < 0 {
+		errs = append(errs, fmt.Errorf("max cumulative delay must not be negative, got %v", c.maxCumulativeDelay))
+	}
+	if c.alignTo < 0 {
+		errs = append(errs, fmt.Errorf("alignment interval must not be negative, got %v", c.alignTo))
+	}
+	if c.consecutiveSuccesses < 1 {
+		errs = append(errs, fmt.Errorf("consecutive successes must be at least 1, got %d", c.consecutiveSuccesses))
+	}
+	if c.parallelism < 0 {
+		errs = append(errs, fmt.Errorf("parallelism must not be negative, got %d", c.parallelism))
+	}
+	if c.retryCondition == nil {
+		errs = append(errs, errors.New("retry condition must not be nil"))
+	}
+	if c.resume != nil && c.resume.store == nil {
+		errs = append(errs, errors.New("state store must not be nil"))
+	}
+	if c.jitter != nil && (*c.jitter < 0 || *c.jitter > 1) {
+		errs = append(errs, fmt.Errorf("jitter factor must be between 0 and 1, got %v", *c.jitter))
+	}
+	for _, conflict := range c.conflicts {
+		errs = append(errs, errors.New(conflict))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+}
+
+// Validate checks the options for invalid values and conflicting combinations
+func Validate(options ...Option) error {
+	return newConfig(options).validate()
+}
+
+// Retrier is a reusable retry configuration validated once at construction
+type Retrier struct {
+	options []Option
+}
+
+// New creates a retrier from the options, returning an error wrapping ErrInvalidConfig if they are invalid
+func New(options ...Option) (*Retrier, error) {
+	if err := Validate(options...); err != nil {
+		return nil, err
+	}
+	return &Retrier{options: options}, nil
+}
+
+// MustNew is like New but panics if the options are invalid
+func MustNew(options ...Option) *Retrier {
+	r, err := New(options...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Do executes a function with the retrier's options followed by the given overrides
+func (o *Retrier) Do(ctx context.Context, fn RetryableFunc, options ...Option) *Result {
+	return Do(ctx, fn, slices.Concat(o.options, options)...)
+}
+
+// Retry is like Do but returns only the error
+func (o *Retrier) Retry(ctx context.Context, fn RetryableFunc, options ...Option) error {
+	return o.Do(ctx, fn, options...).Error()
+}
@@ -0,0 +1,119 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		valid   bool
+	}{
+		{"defaults", nil, true},
+		{"jitter after policy", []Option{WithFixedBackoff(time.Millisecond), WithJitter(0.1)}, true},
+		{"negative timeout", []Option{WithTimeout(-time.Second)}, false},
+		{"zero attempts", []Option{WithMaxAttempts(0)}, false},
+		{"nil condition", []Option{WithRetryCondition(nil)}, false},
+		{"jitter without policy", []Option{WithPolicy(nil), WithJitter(0.1)}, false},
+		{"jitter before policy", []Option{WithJitter(0.1), WithFixedBackoff(time.Millisecond)}, false},
+		{"jitter out of range", []Option{WithJitter(1.5)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.options...)
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid options, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDoRejectsInvalidConfig(t *testing.T) {
+	called := false
+	result := Do(context.Background(), func() error {
+		called = true
+		return nil
+	}, WithTimeout(-time.Second))
+
+	if called {
+		t.Error("Expected function not to be called with invalid options")
+	}
+	if !errors.Is(result.Error(), ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", result.Error())
+	}
+	if result.Attempts() != 0 {
+		t.Errorf("Expected 0 attempts, got %d", result.Attempts())
+	}
+}
+
+func TestNewAndMustNew(t *testing.T) {
+	if _, err := New(WithMaxAttempts(-1)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig from New, got %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected MustNew to panic on invalid options")
+			}
+		}()
+		MustNew(WithMaxAttempts(-1))
+	}()
+
+	r := MustNew(WithMaxAttempts(2), WithFixedBackoff(time.Millisecond))
+	attempts := 0
+	err := r.Retry(context.Background(), func() error {
+		attempts++
+		return errors.New("failed")
+	})
+	if err == nil || attempts != 2 {
+		t.Errorf("Expected 2 failed attempts, got %d attempts and error %v", attempts, err)
+	}
+}
+
+func TestExponentialBuilder(t *testing.T) {
+	policy, err := NewExponentialBuilder().
+		Base(100 * time.Millisecond).
+		Multiplier(2).
+		MaxDelay(time.Second).
+		MaxAttempts(10).
+		MaxElapsed(2 * time.Second).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if delay := policy.NextDelay(2); delay != 400*time.Millisecond {
+		t.Errorf("Expected 400ms after the third attempt, got %v", delay)
+	}
+	if delay := policy.NextDelay(5); delay != time.Second {
+		t.Errorf("Expected delay capped at 1s, got %v", delay)
+	}
+
+	// 100+200+400+800ms = 1.5s fits, adding 1s exceeds the 2s budget
+	if !policy.ShouldRetry(3, errors.New("fail")) {
+		t.Error("Expected retry within the elapsed budget")
+	}
+	if policy.ShouldRetry(4, errors.New("fail")) {
+		t.Error("Expected no retry beyond the elapsed budget")
+	}
+
+	_, err = NewExponentialBuilder().Base(0).Multiplier(0.5).MaxDelay(time.Millisecond).Build()
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("Expected ErrInvalidConfig, got %v", err)
+	}
+	for _, want := range []string{"base delay", "multiplier"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %q, got %v", want, err)
+		}
+	}
+}
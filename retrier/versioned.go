@@ -0,0 +1,27 @@
+package retrier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict is returned, wrapped or as is, by versioned attempts whose compare-and-swap lost
+// against a concurrent write, e.g. a stale resourceVersion or a row version that no longer matches.
+// Retry only conflicts with WithRetryCondition(OnErrorsIs(ErrVersionConflict))
+var ErrVersionConflict = errors.New("version conflict")
+
+// DoVersioned executes a read-modify-write with retry logic for optimistic concurrency: every attempt
+// loads the current state and its version, so a retry never writes based on the stale state of a
+// previous attempt. The attempt function applies the change conditionally on the version, e.g. an
+// UPDATE ... WHERE version = ? or a Kubernetes update with the resourceVersion. Load errors are
+// retried like attempt errors
+func DoVersioned[V, N any](ctx context.Context, load func() (V, N, error), attempt func(value V, version N) error, options ...Option) *Result {
+	return Do(ctx, func() error {
+		value, version, err := load()
+		if err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+		return attempt(value, version)
+	}, options...)
+}
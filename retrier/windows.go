@@ -0,0 +1,128 @@
+package retrier
+
+import (
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// MaintenanceWindow is a recurring daily period, such as a dependency's nightly downtime
+type MaintenanceWindow struct {
+	// Start and End are offsets from midnight, e.g. 2*time.Hour for 02:00.
+	// A window ending before it starts spans midnight
+	Start time.Duration
+	End   time.Duration
+	// Weekdays restricts the window to the days it starts on, every day if empty
+	Weekdays []time.Weekday
+	// Location is the time zone of the offsets, time.Local if nil
+	Location *time.Location
+}
+
+// endAfter returns the end of the occurrence of the window containing t
+func (w MaintenanceWindow) endAfter(t time.Time) (time.Time, bool) {
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+
+	length := w.End - w.Start
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+
+	// The occurrence containing t started today or, when spanning midnight, yesterday
+	for _, days := range []int{0, -1} {
+		day := time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, loc)
+		if len(w.Weekdays) > 0 && !slices.Contains(w.Weekdays, day.Weekday()) {
+			continue
+		}
+		start := day.Add(w.Start)
+		end := start.Add(length)
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// MaintenanceWindowPolicy wraps another policy to avoid retrying during maintenance windows.
+// By default delays that would end inside a window are extended to the end of the window;
+// with WithSuppressRetries the operation gives up instead
+type MaintenanceWindowPolicy struct {
+	policy   RetryPolicy
+	windows  []MaintenanceWindow
+	suppress bool
+	now      func() time.Time
+}
+
+// NewMaintenanceWindowPolicy creates a policy that keeps retries of the wrapped policy out of the windows
+func NewMaintenanceWindowPolicy(policy RetryPolicy, windows ...MaintenanceWindow) *MaintenanceWindowPolicy {
+	return &MaintenanceWindowPolicy{
+		policy:  policy,
+		windows: windows,
+		now:     time.Now,
+	}
+}
+
+// WithSuppressRetries stops retrying when the current time or the next attempt falls into a window,
+// instead of waiting for the window to end
+func (p *MaintenanceWindowPolicy) WithSuppressRetries() *MaintenanceWindowPolicy {
+	p.suppress = true
+	return p
+}
+
+// WithClock sets the source of the current time, for tests
+func (p *MaintenanceWindowPolicy) WithClock(now func() time.Time) *MaintenanceWindowPolicy {
+	p.now = now
+	return p
+}
+
+func (p *MaintenanceWindowPolicy) ShouldRetry(attempt int, err error) bool {
+	if !p.policy.ShouldRetry(attempt, err) {
+		return false
+	}
+	if !p.suppress {
+		return true
+	}
+
+	now := p.now()
+	_, inWindow := p.windowEnd(now)
+	_, nextInWindow := p.windowEnd(now.Add(p.policy.NextDelay(attempt)))
+	return !inWindow && !nextInWindow
+}
+
+func (p *MaintenanceWindowPolicy) NextDelay(attempt int) time.Duration {
+	return p.nextDelayRand(attempt, nil)
+}
+
+func (p *MaintenanceWindowPolicy) nextDelayRand(attempt int, rng *rand.Rand) time.Duration {
+	delay := policyDelay(p.policy, attempt, rng)
+	if p.suppress {
+		return delay
+	}
+
+	now := p.now()
+	next := now.Add(delay)
+	// Adjacent or overlapping windows push the attempt further
+	for range len(p.windows) {
+		end, ok := p.windowEnd(next)
+		if !ok {
+			break
+		}
+		next = end
+	}
+	return next.Sub(now)
+}
+
+// windowEnd returns the latest end of the windows containing t
+func (p *MaintenanceWindowPolicy) windowEnd(t time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for _, window := range p.windows {
+		if end, ok := window.endAfter(t); ok && (!found || end.After(latest)) {
+			latest, found = end, true
+		}
+	}
+	return latest, found
+}
@@ -0,0 +1,3 @@
+module github.com/btchead/go-reusables/semaphore
+
+go 1.24.5
@@ -0,0 +1,112 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// keyedEntry is the lock of a single key, shared by all callers holding or waiting for it
+type keyedEntry struct {
+	lock chan struct{}
+	refs int
+}
+
+// KeyedMutex provides mutual exclusion per key, for example per user ID.
+// Locks for different keys don't block each other and unused keys are released automatically
+type KeyedMutex[K comparable] struct {
+	mu      sync.Mutex
+	entries map[K]*keyedEntry
+}
+
+// NewKeyedMutex creates an empty keyed mutex
+func NewKeyedMutex[K comparable]() *KeyedMutex[K] {
+	return &KeyedMutex[K]{entries: make(map[K]*keyedEntry)}
+}
+
+// Lock locks the key, blocking until it is available
+func (m *KeyedMutex[K]) Lock(key K) {
+	entry := m.acquireEntry(key)
+	entry.lock <- struct{}{}
+}
+
+// LockContext locks the key, blocking until it is available or the context is done
+func (m *KeyedMutex[K]) LockContext(ctx context.Context, key K) error {
+	entry := m.acquireEntry(key)
+
+	select {
+	case entry.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		m.releaseEntry(key)
+		return ctx.Err()
+	}
+}
+
+// LockWithin locks the key, waiting at most timeout
+func (m *KeyedMutex[K]) LockWithin(key K, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := m.LockContext(ctx, key); err != nil {
+		return fmt.Errorf("%w after %v", ErrTimeout, timeout)
+	}
+	return nil
+}
+
+// TryLock locks the key without blocking, reporting success
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	entry := m.acquireEntry(key)
+
+	select {
+	case entry.lock <- struct{}{}:
+		return true
+	default:
+		m.releaseEntry(key)
+		return false
+	}
+}
+
+// Unlock unlocks the key. It panics if the key is not locked
+func (m *KeyedMutex[K]) Unlock(key K) {
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	m.mu.Unlock()
+	if !ok {
+		panic("semaphore: unlock of unlocked key")
+	}
+
+	select {
+	case <-entry.lock:
+	default:
+		panic("semaphore: unlock of unlocked key")
+	}
+	m.releaseEntry(key)
+}
+
+// acquireEntry returns the entry for the key, registering the caller as a user
+func (m *KeyedMutex[K]) acquireEntry(key K) *keyedEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		entry = &keyedEntry{lock: make(chan struct{}, 1)}
+		m.entries[key] = entry
+	}
+	entry.refs++
+	return entry
+}
+
+// releaseEntry unregisters a user of the key and drops the entry once unused
+func (m *KeyedMutex[K]) releaseEntry(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.entries[key]
+	entry.refs--
+	if entry.refs == 0 {
+		delete(m.entries, key)
+	}
+}
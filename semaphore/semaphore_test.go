@@ -0,0 +1,94 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWeighted_BoundsConcurrency(t *testing.T) {
+	sem := NewWeighted(3)
+
+	var current, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sem.Acquire(context.Background(), 1); err != nil {
+				t.Errorf("Acquire failed: %v", err)
+				return
+			}
+			defer sem.Release(1)
+
+			n := current.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			current.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most 3 concurrent holders, got %d", peak.Load())
+	}
+}
+
+func TestWeighted_TryAcquireAndTimeout(t *testing.T) {
+	sem := NewWeighted(2)
+
+	if !sem.TryAcquire(2) {
+		t.Fatal("TryAcquire should succeed on an empty semaphore")
+	}
+	if sem.TryAcquire(1) {
+		t.Fatal("TryAcquire should fail on a full semaphore")
+	}
+
+	if err := sem.AcquireWithin(1, 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+
+	sem.Release(2)
+	if err := sem.AcquireWithin(2, 10*time.Millisecond); err != nil {
+		t.Fatalf("AcquireWithin should succeed after release: %v", err)
+	}
+}
+
+func TestKeyedMutex(t *testing.T) {
+	mutex := NewKeyedMutex[string]()
+
+	mutex.Lock("alice")
+
+	// Different keys don't block each other
+	if !mutex.TryLock("bob") {
+		t.Fatal("Locking a different key should succeed")
+	}
+	mutex.Unlock("bob")
+
+	if mutex.TryLock("alice") {
+		t.Fatal("Locking a held key should fail")
+	}
+
+	if err := mutex.LockWithin("alice", 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+
+	mutex.Unlock("alice")
+
+	if err := mutex.LockContext(context.Background(), "alice"); err != nil {
+		t.Fatalf("LockContext failed after unlock: %v", err)
+	}
+	mutex.Unlock("alice")
+
+	if len(mutex.entries) != 0 {
+		t.Errorf("Expected unused keys to be released, got %d entries", len(mutex.entries))
+	}
+}
@@ -0,0 +1,133 @@
+// Package semaphore provides context-aware concurrency primitives:
+// a weighted semaphore and a per-key mutex
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTimeout is returned when a resource could not be acquired within the given duration
+var ErrTimeout = errors.New("semaphore: acquire timed out")
+
+// waiter is a blocked Acquire call
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// Weighted is a semaphore bounding access to a resource of a fixed size.
+// Waiters are served in FIFO order so large requests are not starved by small ones
+type Weighted struct {
+	size    int64
+	cur     int64
+	mu      sync.Mutex
+	waiters list.List
+}
+
+// NewWeighted creates a semaphore with the given maximum combined weight
+func NewWeighted(size int64) *Weighted {
+	return &Weighted{size: size}
+}
+
+// Acquire acquires the semaphore with a weight of n, blocking until resources are available
+// or the context is done. On failure it returns the context error and leaves the semaphore unchanged
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		// Can never succeed, don't block the waiters queue
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(waiter{n: n, ready: ready})
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// Acquired after cancellation, give it back
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// The removed waiter may have been blocking smaller ones behind it
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// AcquireWithin acquires the semaphore with a weight of n, waiting at most timeout
+func (s *Weighted) AcquireWithin(n int64, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := s.Acquire(ctx, n); err != nil {
+		return fmt.Errorf("%w after %v", ErrTimeout, timeout)
+	}
+	return nil
+}
+
+// TryAcquire acquires the semaphore with a weight of n without blocking, reporting success
+func (s *Weighted) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases the semaphore with a weight of n
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters wakes waiters in FIFO order while resources are available (assumes lock is held)
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+
+		w := next.Value.(waiter)
+		if s.size-s.cur < w.n {
+			// Not enough resources for the next waiter, keep FIFO order
+			return
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
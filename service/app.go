@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/btchead/go-reusables/httpserver"
+)
+
+// App assembles an application from its configuration, logger, servers and workers, for the common
+// main function that loads configuration, starts everything and waits for a shutdown signal:
+//
+//	err := service.NewApp("orders").
+//		WithConfig(func() error { return cfg.LoadFromFile("config.yaml", &appConfig) }).
+//		WithLogger(logger).
+//		AddHTTP("api", &appConfig.HTTP, mux).
+//		AddWorker("outbox", relay.Run).
+//		Run(ctx)
+//
+// Mistakes made while assembling, such as a nil handler, are reported by Build and Run
+type App struct {
+	name       string
+	loadConfig func() error
+	logger     Logger
+	options    []Option
+	services   []appService
+	errs       []error
+}
+
+// appService is a service added to an App and its registration options. Services depending on the
+// configuration, such as HTTP servers, are created by newService when the app is built
+type appService struct {
+	service    Service
+	newService func() (Service, error)
+	options    []RegisterOption
+}
+
+// NewApp creates an application builder
+func NewApp(name string) *App {
+	return &App{name: name}
+}
+
+// WithConfig loads the configuration before any service is registered or started, so a broken
+// configuration fails the application before it starts partially
+func (o *App) WithConfig(load func() error) *App {
+	o.loadConfig = load
+	return o
+}
+
+// WithLogger sets the logger of the application and its manager, such as a go-reusables/log Logger
+func (o *App) WithLogger(logger Logger) *App {
+	o.logger = logger
+	return o
+}
+
+// WithOptions adds options of the manager, such as WithShutdownTimeout
+func (o *App) WithOptions(options ...Option) *App {
+	o.options = append(o.options, options...)
+	return o
+}
+
+// Add adds a service with its registration options
+func (o *App) Add(service Service, options ...RegisterOption) *App {
+	if service == nil {
+		o.errs = append(o.errs, errors.New("nil service added"))
+		return o
+	}
+	o.services = append(o.services, appService{service: service, options: options})
+	return o
+}
+
+// AddHTTP adds an httpserver.Server serving the handler, logging through the app's logger. The server is
+// created when the app is built, so the config can point into the configuration WithConfig loads; a nil
+// config uses the httpserver defaults. It is ready once listening, so a port in use fails the start.
+// Servers needing registration options can be created with httpserver.New and added with Add
+func (o *App) AddHTTP(name string, config *httpserver.Config, handler http.Handler, options ...httpserver.Option) *App {
+	if handler == nil {
+		o.errs = append(o.errs, fmt.Errorf("HTTP service '%s' has no handler", name))
+		return o
+	}
+	o.services = append(o.services, appService{newService: func() (Service, error) {
+		return o.newHTTPServer(name, config, handler, options)
+	}})
+	return o
+}
+
+// AddWorker adds a service running the function until its context is cancelled
+func (o *App) AddWorker(name string, worker ServiceFunc, options ...RegisterOption) *App {
+	if worker == nil {
+		o.errs = append(o.errs, fmt.Errorf("worker '%s' has no function", name))
+		return o
+	}
+	return o.Add(NewService(name, worker), options...)
+}
+
+// Build loads the configuration and returns a manager with the services registered, for applications
+// that need the manager before running it
+func (o *App) Build() (*Manager, error) {
+	if err := errors.Join(o.errs...); err != nil {
+		return nil, fmt.Errorf("invalid app '%s': %w", o.name, err)
+	}
+	if o.loadConfig != nil {
+		if err := o.loadConfig(); err != nil {
+			return nil, fmt.Errorf("failed to load configuration of app '%s': %w", o.name, err)
+		}
+	}
+
+	options := o.options
+	if o.logger != nil {
+		options = append([]Option{WithLogger(o.logger)}, options...)
+	}
+	manager := NewManager(options...)
+	for _, s := range o.services {
+		service := s.service
+		if s.newService != nil {
+			var err error
+			if service, err = s.newService(); err != nil {
+				return nil, fmt.Errorf("invalid app '%s': %w", o.name, err)
+			}
+		}
+		if err := manager.Register(service, s.options...); err != nil {
+			return nil, fmt.Errorf("invalid app '%s': %w", o.name, err)
+		}
+	}
+	return manager, nil
+}
+
+// Run builds the application and runs it with graceful shutdown until the context is cancelled or a
+// shutdown signal is received, like Manager.RunWithGracefulShutdown
+func (o *App) Run(ctx context.Context) error {
+	manager, err := o.Build()
+	if err != nil {
+		return err
+	}
+	manager.logger.Info("Starting application", "app", o.name)
+	return manager.RunWithGracefulShutdown(ctx)
+}
+
+// RunAndExitCode runs the application like Run and returns the exit code of Manager.RunAndExitCode,
+// ExitError when it fails to build:
+//
+//	os.Exit(app.RunAndExitCode(ctx))
+func (o *App) RunAndExitCode(ctx context.Context) int {
+	manager, err := o.Build()
+	if err != nil {
+		if o.logger != nil {
+			o.logger.Error("Application failed to build", "app", o.name, "error", err)
+		}
+		return ExitError
+	}
+	manager.logger.Info("Starting application", "app", o.name)
+	return manager.RunAndExitCode(ctx)
+}
+
+// newHTTPServer creates the server of an HTTP service from the loaded configuration
+func (o *App) newHTTPServer(name string, config *httpserver.Config, handler http.Handler, options []httpserver.Option) (*httpserver.Server, error) {
+	var serverConfig httpserver.Config
+	if config != nil {
+		serverConfig = *config
+	}
+
+	serverOptions := []httpserver.Option{httpserver.WithName(name)}
+	if o.logger != nil {
+		serverOptions = append(serverOptions, httpserver.WithLogger(o.logger))
+	}
+	server, err := httpserver.New(serverConfig, handler, append(serverOptions, options...)...)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP service '%s': %w", name, err)
+	}
+	return server, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// stopDeadlineKey is the context key holding the deadline a service has to stop by
+type stopDeadlineKey struct{}
+
+// DeadlineFromContext returns the deadline by which the service receiving the context in Stop
+// has to finish stopping. Outside of Stop it falls back to the context's own deadline
+func DeadlineFromContext(ctx context.Context) (time.Time, bool) {
+	if deadline, ok := ctx.Value(stopDeadlineKey{}).(time.Time); ok {
+		return deadline, true
+	}
+	return ctx.Deadline()
+}
+
+// RemainingFromContext returns the shutdown budget left to the service receiving the context in Stop,
+// zero once the deadline has passed
+func RemainingFromContext(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := DeadlineFromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(deadline), 0), true
+}
+
+// stopContext derives the context a service is stopped with. Contexts without a deadline get one
+// from the shutdown timeout, so a service always knows its budget; an earlier caller deadline is kept
+func (o *Manager) stopContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		if o.shutdownTimeout <= 0 {
+			return context.WithCancel(ctx)
+		}
+		deadline = time.Now().Add(o.shutdownTimeout)
+	}
+	return context.WithDeadline(context.WithValue(ctx, stopDeadlineKey{}, deadline), deadline)
+}
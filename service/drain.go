@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DrainResult reports how much of a channel DrainChannel processed before it returned
+type DrainResult struct {
+	// Processed is the number of items passed to the handler, including failed ones
+	Processed int
+	// Failed is the number of items the handler returned an error for
+	Failed int
+	// Abandoned is the number of items left buffered in the channel when the context ended
+	Abandoned int
+}
+
+// DrainChannel passes the items remaining in the channel to the handler until it is empty or closed,
+// or the context ends. Call it from a consumer's Stop with the stop context, so draining gives up at
+// the manager's shutdown deadline instead of overrunning it. The error joins the handler errors and,
+// when items were abandoned, the context error
+func DrainChannel[T any](ctx context.Context, ch <-chan T, handler func(ctx context.Context, item T) error) (DrainResult, error) {
+	var result DrainResult
+	var errs []error
+
+	for {
+		// Check the deadline first, a full channel would otherwise always win the select
+		if err := ctx.Err(); err != nil {
+			result.Abandoned = len(ch)
+			if result.Abandoned > 0 {
+				errs = append(errs, fmt.Errorf("abandoned %d items: %w", result.Abandoned, err))
+			}
+			return result, errors.Join(errs...)
+		}
+
+		select {
+		case item, ok := <-ch:
+			if !ok {
+				return result, errors.Join(errs...)
+			}
+			result.Processed++
+			if err := handler(ctx, item); err != nil {
+				result.Failed++
+				errs = append(errs, err)
+			}
+		default:
+			return result, errors.Join(errs...)
+		}
+	}
+}
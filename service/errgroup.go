@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// FromErrGroup creates a service running tightly coupled goroutines, such as a reader and a writer
+// sharing a connection, as one service. Start runs each function in an errgroup: the first error
+// cancels the context of the others and is returned once they all returned. Any of them can call
+// SignalReady when the service is created WithReadiness
+func FromErrGroup(name string, funcs ...ServiceFunc) *BaseService {
+	return NewService(name, func(ctx context.Context) error {
+		group, groupCtx := errgroup.WithContext(ctx)
+		for _, fn := range funcs {
+			group.Go(func() error {
+				return fn(groupCtx)
+			})
+		}
+		return group.Wait()
+	})
+}
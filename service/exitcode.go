@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"syscall"
+)
+
+// Exit codes returned by RunAndExitCode. Shutdowns triggered by a signal exit with 128 plus
+// the signal number, e.g. 130 for SIGINT and 143 for SIGTERM
+const (
+	// ExitOK is returned when the services ran and stopped cleanly
+	ExitOK = 0
+	// ExitError is returned when a service failed to start or stop
+	ExitError = 1
+	// ExitForced is returned when the shutdown was forced by a signal or the shutdown timeout
+	ExitForced = 2
+)
+
+// RunAndExitCode runs all services with graceful shutdown like RunWithGracefulShutdown and maps the
+// outcome to a conventional exit code, so orchestrators see meaningful statuses:
+//
+//	os.Exit(manager.RunAndExitCode(ctx))
+func (o *Manager) RunAndExitCode(ctx context.Context) int {
+	outcome := o.run(ctx)
+
+	code := exitCode(outcome)
+	o.logger.Info("Service manager exiting", "code", code)
+	return code
+}
+
+// exitCode maps a run outcome to its exit code
+func exitCode(outcome runOutcome) int {
+	switch {
+	case outcome.forced:
+		return ExitForced
+	case outcome.err != nil:
+		return ExitError
+	case outcome.signal != nil:
+		if sig, ok := outcome.signal.(syscall.Signal); ok {
+			return 128 + int(sig)
+		}
+		return ExitOK
+	default:
+		return ExitOK
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"expvar"
+	"sync"
+	"weak"
+)
+
+// expvarService is the exported view of a single service
+type expvarService struct {
+	State          string  `json:"state"`
+	Restarts       int64   `json:"restarts"`
+	UptimeSeconds  float64 `json:"uptime_seconds"`
+	Error          string  `json:"error,omitempty"`
+	Goroutines     *int    `json:"goroutines,omitempty"`
+	AllocatedBytes uint64  `json:"allocated_bytes,omitempty"`
+}
+
+// expvarManagers maps the published expvar names to the manager they report. expvar names are
+// process-global and can't be unpublished, so a later manager using a name takes it over. Managers are
+// referenced weakly, so a replaced or dropped manager can be garbage collected
+var expvarManagers struct {
+	mu       sync.Mutex
+	managers map[string]weak.Pointer[Manager]
+}
+
+// publishExpvar publishes the service states under the configured expvar name, replacing the manager
+// reported under it. Names published by other packages are left untouched
+func (o *Manager) publishExpvar() {
+	expvarManagers.mu.Lock()
+	defer expvarManagers.mu.Unlock()
+
+	if _, ok := expvarManagers.managers[o.expvarName]; !ok {
+		if expvar.Get(o.expvarName) != nil {
+			o.logger.Warn("Expvar name already published by another package, skipping", "name", o.expvarName)
+			return
+		}
+		if expvarManagers.managers == nil {
+			expvarManagers.managers = make(map[string]weak.Pointer[Manager])
+		}
+		name := o.expvarName
+		expvar.Publish(name, expvar.Func(func() any { return expvarSnapshotOf(name) }))
+	}
+	expvarManagers.managers[o.expvarName] = weak.Make(o)
+}
+
+// expvarSnapshotOf returns the snapshot of the manager published under the name, empty once it was
+// garbage collected
+func expvarSnapshotOf(name string) any {
+	expvarManagers.mu.Lock()
+	manager := expvarManagers.managers[name].Value()
+	expvarManagers.mu.Unlock()
+
+	if manager == nil {
+		return map[string]expvarService{}
+	}
+	return manager.expvarSnapshot()
+}
+
+// expvarSnapshot returns the current state of all services keyed by name. It reads the lock-free
+// service table, since Start and Stop hold the manager lock for as long as they run
+func (o *Manager) expvarSnapshot() any {
+	services := o.serviceSnapshot().services
+	snapshot := make(map[string]expvarService, len(services))
+	for _, state := range services {
+		info := expvarService{
+			State:         state.getState().String(),
+			UptimeSeconds: state.uptime().Seconds(),
+		}
+		if starts := state.starts.Load(); starts > 1 {
+			info.Restarts = starts - 1
+		}
+		if err := state.getError(); err != nil {
+			info.Error = err.Error()
+		}
+		resources := state.resources()
+		if !resources.SampledAt.IsZero() {
+			info.Goroutines = &resources.Goroutines
+		}
+		info.AllocatedBytes = resources.AllocatedBytes
+		snapshot[state.service.Name()] = info
+	}
+	return snapshot
+}
@@ -1,3 +1,24 @@
 module github.com/btchead/go-reusables/service
 
-go 1.24.5
\ No newline at end of file
+go 1.24.5
+
+require (
+	github.com/btchead/go-reusables/httpserver v0.1.0
+	go.opentelemetry.io/otel v1.40.0
+	go.opentelemetry.io/otel/sdk v1.40.0
+	go.opentelemetry.io/otel/trace v1.40.0
+	golang.org/x/sync v0.19.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.40.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+)
+
+// Builds inside the repository use the sibling module, consumers resolve the required tag
+replace github.com/btchead/go-reusables/httpserver => ../httpserver
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphFormat selects the output format of ExportGraph
+type GraphFormat string
+
+const (
+	// GraphDOT renders a Graphviz digraph
+	GraphDOT GraphFormat = "dot"
+	// GraphMermaid renders a Mermaid flowchart
+	GraphMermaid GraphFormat = "mermaid"
+)
+
+// graphNode is a service or an undeclared dependency in the exported graph
+type graphNode struct {
+	name       string
+	state      string
+	registered bool
+	dependsOn  []string
+}
+
+// ExportGraph renders the registered services, their dependencies and current states.
+// Dependencies come from WithDependsOn and from the providers resolved by a provided service's constructor.
+// Dependencies that are not registered services are drawn with a dashed outline
+func (o *Manager) ExportGraph(format GraphFormat) (string, error) {
+	nodes := o.graphNodes()
+
+	switch format {
+	case GraphDOT:
+		return renderDOT(nodes), nil
+	case GraphMermaid:
+		return renderMermaid(nodes), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format '%s'", format)
+	}
+}
+
+// graphNodes snapshots the services in registration order, followed by unregistered dependencies
+func (o *Manager) graphNodes() []graphNode {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var nodes []graphNode
+	seen := make(map[string]bool)
+	for _, state := range o.services {
+		name := state.service.Name()
+		nodes = append(nodes, graphNode{
+			name:       name,
+			state:      state.getState().String(),
+			registered: true,
+			dependsOn:  state.dependsOn,
+		})
+		seen[name] = true
+	}
+
+	for _, state := range o.services {
+		for _, dep := range state.dependsOn {
+			if !seen[dep] {
+				nodes = append(nodes, graphNode{name: dep})
+				seen[dep] = true
+			}
+		}
+	}
+	return nodes
+}
+
+// stateColors maps service states to fill colors shared by both formats
+var stateColors = map[string]string{
+	"stopped":   "#e0e0e0",
+	"starting":  "#fff3b0",
+	"running":   "#b7e4c7",
+	"completed": "#d0e6f7",
+	"stopping":  "#fff3b0",
+	"error":     "#f4a6a6",
+}
+
+func renderDOT(nodes []graphNode) string {
+	var b strings.Builder
+	b.WriteString("digraph services {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];\n")
+
+	for _, node := range nodes {
+		if !node.registered {
+			fmt.Fprintf(&b, "  %q [style=\"rounded,dashed\"];\n", node.name)
+			continue
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, fillcolor=%q];\n",
+			node.name, node.name+"\n"+node.state, stateColors[node.state])
+	}
+	for _, node := range nodes {
+		for _, dep := range node.dependsOn {
+			fmt.Fprintf(&b, "  %q -> %q;\n", node.name, dep)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderMermaid(nodes []graphNode) string {
+	// Mermaid ids must be plain identifiers, so nodes are numbered and labelled with their names
+	ids := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		ids[node.name] = fmt.Sprintf("s%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	for _, node := range nodes {
+		label := strings.ReplaceAll(node.name, `"`, "#quot;")
+		if !node.registered {
+			fmt.Fprintf(&b, "  %s[\"%s\"]:::unregistered\n", ids[node.name], label)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]:::%s\n", ids[node.name], label, node.state, node.state)
+	}
+	for _, node := range nodes {
+		for _, dep := range node.dependsOn {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[node.name], ids[dep])
+		}
+	}
+
+	for _, state := range []string{"stopped", "starting", "running", "completed", "stopping", "error"} {
+		fmt.Fprintf(&b, "  classDef %s fill:%s\n", state, stateColors[state])
+	}
+	b.WriteString("  classDef unregistered stroke-dasharray: 5 5\n")
+	return b.String()
+}
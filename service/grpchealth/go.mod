@@ -0,0 +1,27 @@
+module github.com/btchead/go-reusables/service/grpchealth
+
+go 1.25.0
+
+require (
+	github.com/btchead/go-reusables/service v0.1.0
+	google.golang.org/grpc v1.82.1
+)
+
+require (
+	github.com/btchead/go-reusables/httpserver v0.1.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.opentelemetry.io/otel v1.43.0 // indirect
+	go.opentelemetry.io/otel/trace v1.43.0 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
+
+// Builds inside the repository use the sibling modules, consumers resolve the required tags
+replace (
+	github.com/btchead/go-reusables/httpserver => ../../httpserver
+	github.com/btchead/go-reusables/service => ..
+)
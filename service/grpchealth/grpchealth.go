@@ -0,0 +1,128 @@
+// Package grpchealth exposes the health of a service manager through the standard grpc.health.v1
+// Health service, for gRPC load balancers and Kubernetes gRPC probes
+package grpchealth
+
+import (
+	"context"
+	"time"
+
+	"github.com/btchead/go-reusables/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithPollInterval sets how often Watch checks the manager for status changes, 1s by default
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *Server) {
+		o.pollInterval = interval
+	}
+}
+
+// Server implements the grpc.health.v1 Health service from the states of a manager's services.
+// The empty service name reports the aggregate status, serving when every service is running or,
+// for oneshot services, completed. Other names report the registered service of that name
+type Server struct {
+	healthpb.UnimplementedHealthServer
+
+	manager      *service.Manager
+	pollInterval time.Duration
+}
+
+// New creates a Health server for the manager
+func New(manager *service.Manager, opts ...Option) *Server {
+	s := &Server{
+		manager:      manager,
+		pollInterval: time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register registers a Health server for the manager on the gRPC server
+func Register(registrar grpc.ServiceRegistrar, manager *service.Manager, opts ...Option) *Server {
+	s := New(manager, opts...)
+	healthpb.RegisterHealthServer(registrar, s)
+	return s
+}
+
+// Check returns the status of the service, or NotFound for a name that is not registered
+func (o *Server) Check(_ context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	servingStatus, ok := o.status(req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "service '%s' not found", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// List returns the status of every service, with the aggregate status under the empty name
+func (o *Server) List(_ context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	statuses := map[string]*healthpb.HealthCheckResponse{}
+	for _, info := range o.manager.GetStatus() {
+		statuses[info.Name] = &healthpb.HealthCheckResponse{Status: servingStatus(info.State)}
+	}
+	aggregate, _ := o.status("")
+	statuses[""] = &healthpb.HealthCheckResponse{Status: aggregate}
+	return &healthpb.HealthListResponse{Statuses: statuses}, nil
+}
+
+// Watch sends the status of the service and then every change, until the client goes away.
+// Names that are not registered report SERVICE_UNKNOWN, as the service may be registered later
+func (o *Server) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		current, ok := o.status(req.GetService())
+		if !ok {
+			current = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// status returns the serving status of the named service, or the aggregate status for the empty name
+func (o *Server) status(name string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	if name != "" {
+		info, err := o.manager.ServiceInfo(name)
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN, false
+		}
+		return servingStatus(info.State), true
+	}
+
+	for _, info := range o.manager.GetStatus() {
+		if servingStatus(info.State) != healthpb.HealthCheckResponse_SERVING {
+			return healthpb.HealthCheckResponse_NOT_SERVING, true
+		}
+	}
+	return healthpb.HealthCheckResponse_SERVING, true
+}
+
+// servingStatus maps a service state to its serving status
+func servingStatus(state service.ServiceState) healthpb.HealthCheckResponse_ServingStatus {
+	switch state {
+	case service.StateRunning, service.StateCompleted:
+		return healthpb.HealthCheckResponse_SERVING
+	default:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+}
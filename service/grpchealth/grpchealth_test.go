@@ -0,0 +1,99 @@
+package grpchealth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/btchead/go-reusables/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchStream records the responses sent by Watch
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (o *watchStream) Context() context.Context {
+	return o.ctx
+}
+
+func (o *watchStream) Send(resp *healthpb.HealthCheckResponse) error {
+	o.sent <- resp.GetStatus()
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	manager := service.NewManager()
+	for _, name := range []string{"api", "worker"} {
+		svc := service.NewService(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		if err := manager.Register(svc); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	server := New(manager, WithPollInterval(5*time.Millisecond))
+	check := func(name string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		if err != nil {
+			t.Fatalf("Check %q failed: %v", name, err)
+		}
+		return resp.GetStatus()
+	}
+
+	if got := check(""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected aggregate SERVING, got %v", got)
+	}
+	if _, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for unknown service, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, sent: make(chan healthpb.HealthCheckResponse_ServingStatus, 4)}
+	done := make(chan error, 1)
+	go func() { done <- server.Watch(&healthpb.HealthCheckRequest{Service: "worker"}, stream) }()
+	if got := <-stream.sent; got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected initial SERVING, got %v", got)
+	}
+
+	if err := manager.StopService(context.Background(), "worker"); err != nil {
+		t.Fatalf("StopService failed: %v", err)
+	}
+	select {
+	case got := <-stream.sent:
+		if got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Expected NOT_SERVING after stop, got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not report the stopped service")
+	}
+	cancel()
+	if err := <-done; status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled when the client goes away, got %v", err)
+	}
+
+	if got := check("api"); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected api SERVING, got %v", got)
+	}
+	if got := check(""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected aggregate NOT_SERVING, got %v", got)
+	}
+
+	list, err := server.List(context.Background(), &healthpb.HealthListRequest{})
+	if err != nil || len(list.GetStatuses()) != 3 || list.GetStatuses()["worker"].GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected three statuses with worker NOT_SERVING, got %v, %v", list.GetStatuses(), err)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// heartbeat is the liveness signal sent while the critical services run
+type heartbeat struct {
+	interval time.Duration
+	beat     func(ctx context.Context) error
+	// critical are the services that must be running, all registered services when empty
+	critical []string
+}
+
+// WithHeartbeat calls beat every interval while all critical services are running, or all registered
+// services when none are named, so external watchdogs see the internal health instead of the mere
+// process: file-based Kubernetes liveness probes with TouchFile, or systemd WatchdogSec with a beat
+// sending WATCHDOG=1. Completed oneshot services count as running. Beat errors are logged
+func WithHeartbeat(interval time.Duration, beat func(ctx context.Context) error, critical ...string) Option {
+	return func(m *Manager) {
+		m.heartbeat = &heartbeat{interval: interval, beat: beat, critical: critical}
+	}
+}
+
+// TouchFile returns a heartbeat updating the modification time of the file, created if missing,
+// for liveness probes checking that it was touched recently, e.g. find /tmp/alive -mmin -1
+func TouchFile(path string) func(ctx context.Context) error {
+	return func(context.Context) error {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		now := time.Now()
+		return os.Chtimes(path, now, now)
+	}
+}
+
+// sendHeartbeats beats at the heartbeat interval while the critical services run, until the manager
+// context is cancelled by Shutdown
+func (o *Manager) sendHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(o.heartbeat.interval)
+	defer ticker.Stop()
+
+	for {
+		if o.criticalRunning() {
+			if err := o.heartbeat.beat(ctx); err != nil {
+				o.logger.Warn("Failed to send heartbeat", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// criticalRunning reports whether the critical services of the heartbeat are running or completed.
+// It is false when no services are registered or a critical service is not registered
+func (o *Manager) criticalRunning() bool {
+	table := o.serviceSnapshot()
+	states := table.services
+	if len(o.heartbeat.critical) > 0 {
+		states = make([]*serviceState, 0, len(o.heartbeat.critical))
+		for _, name := range o.heartbeat.critical {
+			state, exists := table.byName[name]
+			if !exists {
+				return false
+			}
+			states = append(states, state)
+		}
+	}
+
+	for _, state := range states {
+		if s := state.getState(); s != StateRunning && s != StateCompleted {
+			return false
+		}
+	}
+	return len(states) > 0
+}
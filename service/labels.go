@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// ErrInvalidSelector is returned for label selectors that cannot be parsed
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+// WithLabels attaches labels to the service for selection with StopByLabel and StatusByLabel
+func WithLabels(labels map[string]string) RegisterOption {
+	return func(s *serviceState) {
+		if s.labels == nil {
+			s.labels = make(map[string]string, len(labels))
+		}
+		maps.Copy(s.labels, labels)
+	}
+}
+
+// labelRequirement is a single term of a label selector
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool
+	// exists matches services that have the key, whatever its value
+	exists bool
+}
+
+// labelSelector matches services whose labels satisfy all requirements
+type labelSelector []labelRequirement
+
+// parseSelector parses comma-separated requirements of the form key=value, key!=value or key
+func parseSelector(selector string) (labelSelector, error) {
+	var requirements labelSelector
+	for term := range strings.SplitSeq(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("%w: empty term in '%s'", ErrInvalidSelector, selector)
+		}
+
+		var req labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			req.key, req.value, _ = strings.Cut(term, "!=")
+			req.negate = true
+		case strings.Contains(term, "="):
+			req.key, req.value, _ = strings.Cut(term, "=")
+		default:
+			req.key = term
+			req.exists = true
+		}
+
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+		if req.key == "" {
+			return nil, fmt.Errorf("%w: missing key in '%s'", ErrInvalidSelector, term)
+		}
+		requirements = append(requirements, req)
+	}
+	return requirements, nil
+}
+
+// matches reports whether the labels satisfy all requirements of the selector
+func (s labelSelector) matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.key]
+		switch {
+		case req.exists && !ok:
+			return false
+		case req.negate && ok && value == req.value:
+			return false
+		case !req.exists && !req.negate && (!ok || value != req.value):
+			return false
+		}
+	}
+	return true
+}
+
+// StatusByLabel returns the status of the services matching the label selector, e.g. "tier=ingress".
+// Selectors are comma-separated requirements of the form key=value, key!=value or key, all of which
+// must hold
+func (o *Manager) StatusByLabel(selector string) ([]ServiceInfo, error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.GetStatusWhere(func(info ServiceInfo) bool {
+		return sel.matches(info.Labels)
+	}), nil
+}
+
+// StopByLabel stops the running services matching the label selector, in reverse registration order.
+// See StatusByLabel for the selector syntax
+func (o *Manager) StopByLabel(ctx context.Context, selector string) error {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var errors []error
+	stopped := 0
+	for i := len(o.services) - 1; i >= 0; i-- {
+		state := o.services[i]
+		if !sel.matches(state.labels) || state.getState() == StateStopped {
+			continue
+		}
+
+		stopped++
+		if err := o.stopSingleService(ctx, state); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	o.logger.Info("Stopped services by label", "selector", selector, "count", stopped)
+	if len(errors) > 0 {
+		return fmt.Errorf("errors stopping services: %v", errors)
+	}
+	return nil
+}
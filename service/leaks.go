@@ -0,0 +1,124 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// leakPollInterval is how often goroutines are counted while waiting for them to exit after Shutdown
+const leakPollInterval = 10 * time.Millisecond
+
+// GoroutineLeak is a group of goroutines with the same stack still running after Shutdown that did not
+// exist when the services were started
+type GoroutineLeak struct {
+	// Service is the service that started the goroutines, empty when they were not started by a service
+	Service string
+	// Count is the number of leaked goroutines with this stack
+	Count int
+	// Stack is the symbolized stack of the goroutines, innermost frame first
+	Stack []string
+}
+
+// WithLeakDetection records the running goroutines when services start, and after Shutdown completes
+// waits up to grace for the goroutines started since to exit. Those still running are logged as suspected
+// leaks, attributed to the service that started them, and returned by Leaks. Services run with a pprof
+// "service" label for attribution, like with WithResourceSampling
+func WithLeakDetection(grace time.Duration) Option {
+	return func(m *Manager) {
+		m.leakGrace = grace
+	}
+}
+
+// Leaks returns the suspected goroutine leaks found by the last Shutdown, see WithLeakDetection
+func (o *Manager) Leaks() []GoroutineLeak {
+	o.leakMu.Lock()
+	defer o.leakMu.Unlock()
+	return append([]GoroutineLeak(nil), o.leaks...)
+}
+
+// recordLeakBaseline records the running goroutines before services start, when leak detection is enabled
+func (o *Manager) recordLeakBaseline() {
+	if o.leakGrace <= 0 {
+		return
+	}
+
+	groups, err := goroutineGroups()
+	if err != nil {
+		o.logger.Warn("Failed to record goroutine baseline", "error", err)
+		return
+	}
+	baseline := make(map[string]int, len(groups))
+	for _, group := range groups {
+		baseline[leakKey(group)] += group.count
+	}
+
+	o.leakMu.Lock()
+	defer o.leakMu.Unlock()
+	o.leakBaseline = baseline
+}
+
+// detectLeaks waits up to the grace period for the goroutines started since the baseline to exit,
+// then logs and stores the remaining ones
+func (o *Manager) detectLeaks() {
+	o.leakMu.Lock()
+	baseline := o.leakBaseline
+	o.leakMu.Unlock()
+	if o.leakGrace <= 0 || baseline == nil {
+		return
+	}
+
+	deadline := time.Now().Add(o.leakGrace)
+	var leaks []GoroutineLeak
+	for {
+		groups, err := goroutineGroups()
+		if err != nil {
+			o.logger.Warn("Failed to check for goroutine leaks", "error", err)
+			return
+		}
+		leaks = leakedGoroutines(baseline, groups)
+		if len(leaks) == 0 || !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(leakPollInterval)
+	}
+
+	for _, leak := range leaks {
+		o.logger.Warn("Suspected goroutine leak after shutdown",
+			"service", leak.Service, "goroutines", leak.Count, "stack", strings.Join(leak.Stack, " <- "))
+	}
+
+	o.leakMu.Lock()
+	defer o.leakMu.Unlock()
+	o.leaks = leaks
+}
+
+// leakedGoroutines returns the goroutine groups outnumbering the baseline. The goroutine taking the
+// profile is skipped, its stack differs between the baseline and the check
+func leakedGoroutines(baseline map[string]int, groups []goroutineGroup) []GoroutineLeak {
+	var leaks []GoroutineLeak
+	for _, group := range groups {
+		if profiling(group) {
+			continue
+		}
+		if extra := group.count - baseline[leakKey(group)]; extra > 0 {
+			leaks = append(leaks, GoroutineLeak{Service: group.labels[serviceLabel], Count: extra, Stack: group.frames})
+		}
+	}
+	return leaks
+}
+
+// leakKey identifies goroutines by stack and service, so a service leaking a goroutine with
+// the same stack as one of another service is still reported
+func leakKey(group goroutineGroup) string {
+	return group.labels[serviceLabel] + "@" + group.stack
+}
+
+// profiling reports whether the group is the goroutine writing the goroutine profile
+func profiling(group goroutineGroup) bool {
+	for _, frame := range group.frames {
+		if strings.HasPrefix(frame, "runtime/pprof.writeGoroutine") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrMailboxClosed is returned when sending to a mailbox whose service has stopped
+	ErrMailboxClosed = errors.New("mailbox closed")
+	// ErrMailboxFull is returned by TrySend when the mailbox buffer is full
+	ErrMailboxFull = errors.New("mailbox full")
+	// ErrNoMailbox is returned when a service has no mailbox of the requested type
+	ErrNoMailbox = errors.New("service has no mailbox")
+)
+
+// Mailbox is a bounded, typed queue of messages for a service, so sibling services can
+// send it commands without global channels. It is closed when its service stops and reopened,
+// empty, when the service starts again
+type Mailbox[T any] struct {
+	messages chan T
+	done     chan struct{}
+	mu       sync.RWMutex // held for reading while sending, so closing waits for senders
+
+	// lifecycle serializes closing and reopening, users counts the running instances of the service
+	lifecycle sync.Mutex
+	closed    bool
+	users     int
+}
+
+// NewMailbox creates a mailbox buffering up to size messages
+func NewMailbox[T any](size int) *Mailbox[T] {
+	return &Mailbox[T]{
+		messages: make(chan T, size),
+		done:     make(chan struct{}),
+	}
+}
+
+// Send queues the message, waiting for buffer space until the context is done or the mailbox closes
+func (o *Mailbox[T]) Send(ctx context.Context, msg T) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	select {
+	case <-o.done:
+		return ErrMailboxClosed
+	default:
+	}
+
+	select {
+	case o.messages <- msg:
+		return nil
+	case <-o.done:
+		return ErrMailboxClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend queues the message without waiting, returning ErrMailboxFull when the buffer is full
+func (o *Mailbox[T]) TrySend(msg T) error {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	select {
+	case <-o.done:
+		return ErrMailboxClosed
+	default:
+	}
+
+	select {
+	case o.messages <- msg:
+		return nil
+	default:
+		return ErrMailboxFull
+	}
+}
+
+// Receive returns the channel of queued messages. It is closed once the mailbox is closed
+// and the remaining messages are drained
+func (o *Mailbox[T]) Receive() <-chan T {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.messages
+}
+
+// Len returns the number of queued messages
+func (o *Mailbox[T]) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.messages)
+}
+
+// Close rejects further messages and closes the receive channel. It is safe to call more than once
+func (o *Mailbox[T]) Close() {
+	o.lifecycle.Lock()
+	defer o.lifecycle.Unlock()
+	o.close()
+}
+
+// close closes the mailbox unless it is closed already. It must be called with lifecycle held
+func (o *Mailbox[T]) close() {
+	if o.closed {
+		return
+	}
+	o.closed = true
+
+	// Unblock waiting senders before waiting for them to leave
+	close(o.done)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	close(o.messages)
+}
+
+// acquire counts a starting instance of the service, reopening the mailbox if it was closed
+func (o *Mailbox[T]) acquire() {
+	o.lifecycle.Lock()
+	defer o.lifecycle.Unlock()
+
+	o.users++
+	if !o.closed {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = make(chan T, cap(o.messages))
+	o.done = make(chan struct{})
+	o.closed = false
+}
+
+// release counts an exited instance of the service, closing the mailbox once none runs. While a
+// service is replaced both instances run, so the mailbox stays open for the replacement
+func (o *Mailbox[T]) release() {
+	o.lifecycle.Lock()
+	defer o.lifecycle.Unlock()
+
+	if o.users > 0 {
+		o.users--
+	}
+	if o.users == 0 {
+		o.close()
+	}
+}
+
+// serviceMailbox is implemented by every Mailbox type
+type serviceMailbox interface {
+	acquire()
+	release()
+}
+
+// WithMailbox attaches the mailbox to the registered service so other services can look it up
+// with MailboxOf. The mailbox is closed when the service stops and reopened when it starts again,
+// also after a Replace
+func WithMailbox[T any](mailbox *Mailbox[T]) RegisterOption {
+	return func(s *serviceState) {
+		s.mailbox = mailbox
+	}
+}
+
+// MailboxOf returns the mailbox of type T attached to the named service
+func MailboxOf[T any](m *Manager, name string) (*Mailbox[T], error) {
+	m.mu.RLock()
+	state, exists := m.serviceMap[name]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrServiceNotFound, name)
+	}
+
+	mailbox, ok := state.mailbox.(*Mailbox[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s' of %s", ErrNoMailbox, name, reflect.TypeFor[T]())
+	}
+	return mailbox, nil
+}
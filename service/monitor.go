@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// HealthChecker is implemented by services that can report their own health beyond being running
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// MonitorOption configures a SelfMonitor
+type MonitorOption func(*SelfMonitor)
+
+// WithCriticalServices limits monitoring to the named services. All services are monitored by default
+func WithCriticalServices(names ...string) MonitorOption {
+	return func(m *SelfMonitor) {
+		m.critical = names
+	}
+}
+
+// WithUnhealthyThreshold sets how long a service must stay unhealthy before escalation
+func WithUnhealthyThreshold(threshold time.Duration) MonitorOption {
+	return func(m *SelfMonitor) {
+		m.threshold = threshold
+	}
+}
+
+// WithOnUnhealthy sets a callback invoked once when a service exceeds the unhealthy threshold
+func WithOnUnhealthy(callback func(name string, unhealthyFor time.Duration, err error)) MonitorOption {
+	return func(m *SelfMonitor) {
+		m.onUnhealthy = callback
+	}
+}
+
+// WithExitOnUnhealthy terminates the process with the given exit code on escalation,
+// leaving restarts to the process supervisor
+func WithExitOnUnhealthy(code int) MonitorOption {
+	return func(m *SelfMonitor) {
+		m.exit = true
+		m.exitCode = code
+	}
+}
+
+// SelfMonitor is an in-process supervisor that periodically checks the health of the manager's
+// services and escalates when critical services stay unhealthy for longer than a threshold
+type SelfMonitor struct {
+	manager     *Manager
+	interval    time.Duration
+	threshold   time.Duration
+	critical    []string
+	onUnhealthy func(name string, unhealthyFor time.Duration, err error)
+	exit        bool
+	exitCode    int
+	exitFunc    func(code int)
+
+	mu        sync.Mutex
+	since     map[string]time.Time
+	escalated map[string]bool
+	// done is closed by Stop and renewed after every run, it is protected by mu
+	done chan struct{}
+}
+
+// NewSelfMonitor creates a monitor checking the manager's services every interval.
+// The monitor is a Service itself and is usually registered with the manager it watches
+func NewSelfMonitor(m *Manager, interval time.Duration, options ...MonitorOption) *SelfMonitor {
+	monitor := &SelfMonitor{
+		manager:   m,
+		interval:  interval,
+		threshold: 3 * interval,
+		exitFunc:  os.Exit,
+		since:     make(map[string]time.Time),
+		escalated: make(map[string]bool),
+		done:      make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(monitor)
+	}
+
+	return monitor
+}
+
+// Name returns the service name
+func (o *SelfMonitor) Name() string {
+	return "self-monitor"
+}
+
+// Start checks service health every interval until stopped
+func (o *SelfMonitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	o.mu.Lock()
+	done := o.done
+	o.mu.Unlock()
+	defer func() {
+		o.mu.Lock()
+		o.done = make(chan struct{})
+		o.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-done:
+			return nil
+		case <-ticker.C:
+			o.Check(ctx)
+		}
+	}
+}
+
+// Stop stops the monitoring loop
+func (o *SelfMonitor) Stop(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	select {
+	case <-o.done:
+		// Already closed
+	default:
+		close(o.done)
+	}
+	return nil
+}
+
+// Check evaluates the health of the monitored services once and escalates if needed
+func (o *SelfMonitor) Check(ctx context.Context) {
+	now := time.Now()
+
+	for name, err := range o.evaluate(ctx) {
+		o.mu.Lock()
+		if err == nil {
+			delete(o.since, name)
+			delete(o.escalated, name)
+			o.mu.Unlock()
+			continue
+		}
+
+		since, seen := o.since[name]
+		if !seen {
+			since = now
+			o.since[name] = now
+		}
+		unhealthyFor := now.Sub(since)
+		escalate := unhealthyFor >= o.threshold && !o.escalated[name]
+		if escalate {
+			o.escalated[name] = true
+		}
+		o.mu.Unlock()
+
+		if escalate {
+			o.escalate(name, unhealthyFor, err)
+		}
+	}
+}
+
+// evaluate returns the health of every monitored service, nil meaning healthy. The monitor runs as a
+// managed service, so it reads the lock-free snapshot: Stop holds the manager lock while waiting for it
+func (o *SelfMonitor) evaluate(ctx context.Context) map[string]error {
+	table := o.manager.serviceSnapshot()
+	names := o.critical
+	if len(names) == 0 {
+		names = make([]string, 0, len(table.services))
+		for _, state := range table.services {
+			names = append(names, state.service.Name())
+		}
+	}
+	states := make(map[string]*serviceState, len(names))
+	for _, name := range names {
+		states[name] = table.byName[name]
+	}
+
+	results := make(map[string]error, len(states))
+	for name, state := range states {
+		switch {
+		case state == nil:
+			results[name] = fmt.Errorf("service '%s' not registered", name)
+		case state.getState() == StateCompleted:
+			results[name] = nil
+		case state.getState() != StateRunning:
+			results[name] = fmt.Errorf("service '%s' is %s", name, state.getState())
+		default:
+			if checker, ok := state.service.(HealthChecker); ok {
+				results[name] = checker.HealthCheck(ctx)
+			} else {
+				results[name] = nil
+			}
+		}
+	}
+	return results
+}
+
+// escalate reports a service that exceeded the unhealthy threshold
+func (o *SelfMonitor) escalate(name string, unhealthyFor time.Duration, err error) {
+	o.manager.logger.Error("Critical service unhealthy", "service", name, "unhealthy_for", unhealthyFor, "error", err)
+
+	if o.onUnhealthy != nil {
+		o.onUnhealthy(name, unhealthyFor, err)
+	}
+
+	if o.exit {
+		o.manager.logger.Error("Exiting due to unhealthy critical service", "service", name, "code", o.exitCode)
+		o.exitFunc(o.exitCode)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOneshotFailed is returned when waiting for a oneshot service that failed or was stopped
+var ErrOneshotFailed = errors.New("oneshot service did not complete")
+
+// oneshotPollInterval is how often waiters check whether oneshot services completed
+const oneshotPollInterval = 5 * time.Millisecond
+
+// WithOneshot marks an init-style service, such as a migration or cache warmup, whose Start returns
+// once its work is done. When Start returns without error the service is completed: its state becomes
+// StateCompleted instead of StateStopped. Services naming it in WithDependsOn start once it completed
+func WithOneshot() RegisterOption {
+	return func(s *serviceState) {
+		s.oneshot = true
+	}
+}
+
+// WaitForCompleted waits until the named oneshot services completed. It fails with ErrOneshotFailed when
+// one of them fails, stops or isn't starting, and with the context error when the context ends first
+func (o *Manager) WaitForCompleted(ctx context.Context, names ...string) error {
+	o.mu.RLock()
+	states := make([]*serviceState, 0, len(names))
+	for _, name := range names {
+		state, exists := o.serviceMap[name]
+		if !exists {
+			o.mu.RUnlock()
+			return fmt.Errorf("%w: '%s'", ErrServiceNotFound, name)
+		}
+		states = append(states, state)
+	}
+	o.mu.RUnlock()
+
+	return waitCompleted(ctx, states)
+}
+
+// waitCompleted waits until all services completed. Stopped services fail the wait, as nothing would start
+// them while the caller holds the manager lock; parallel starts mark all their services starting up front
+func waitCompleted(ctx context.Context, states []*serviceState) error {
+	ticker := time.NewTicker(oneshotPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending := false
+		for _, state := range states {
+			switch state.getState() {
+			case StateCompleted:
+			case StateError, StateStopping:
+				return fmt.Errorf("%w: '%s': %v", ErrOneshotFailed, state.service.Name(), state.getError())
+			case StateStopped:
+				return fmt.Errorf("%w: '%s' is not started", ErrOneshotFailed, state.service.Name())
+			default:
+				pending = true
+			}
+		}
+		if !pending {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// oneshotDependencies returns the oneshot services the service depends on (assumes lock is held)
+func (o *Manager) oneshotDependencies(state *serviceState) []*serviceState {
+	var deps []*serviceState
+	for _, name := range state.dependsOn {
+		if dep, exists := o.serviceMap[name]; exists && dep.oneshot {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// checkOneshotOrder rejects sequential starts where a service depends on a oneshot started after it,
+// which would wait forever (assumes lock is held)
+func (o *Manager) checkOneshotOrder(services []*serviceState) error {
+	position := make(map[*serviceState]int, len(services))
+	for i, state := range services {
+		position[state] = i
+	}
+	for i, state := range services {
+		for _, dep := range o.oneshotDependencies(state) {
+			if position[dep] > i && dep.getState() != StateCompleted {
+				return fmt.Errorf("service '%s' depends on oneshot service '%s' which starts after it",
+					state.service.Name(), dep.service.Name())
+			}
+		}
+	}
+	return nil
+}
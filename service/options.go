@@ -43,6 +43,7 @@ func WithContext(ctx context.Context) Option {
 		if m.cancel != nil {
 			m.cancel() // Cancel the default context
 		}
+		m.parent = ctx
 		m.ctx, m.cancel = context.WithCancel(ctx)
 	}
 }
@@ -52,4 +53,37 @@ func WithServiceSequence(sequence ServiceSequence) Option {
 	return func(m *Manager) {
 		m.serviceSequence = sequence
 	}
-}
\ No newline at end of file
+}
+
+// WithStartTimeout sets the overall deadline for starting all services
+func WithStartTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.startTimeout = timeout
+	}
+}
+
+// RegisterOption configures a single registered service
+type RegisterOption func(*serviceState)
+
+// WithServiceStartTimeout sets how long the service may take to become ready
+func WithServiceStartTimeout(timeout time.Duration) RegisterOption {
+	return func(s *serviceState) {
+		s.startTimeout = timeout
+	}
+}
+
+// WithDependsOn declares the services this service depends on, for documentation via ExportGraph.
+// The service starts only after its oneshot dependencies completed
+func WithDependsOn(names ...string) RegisterOption {
+	return func(s *serviceState) {
+		s.dependsOn = append(s.dependsOn, names...)
+	}
+}
+
+// WithExpvar publishes service states, restart counts and uptimes via expvar under the given name
+// expvar names are process-global: a later manager using the same name takes it over
+func WithExpvar(name string) Option {
+	return func(m *Manager) {
+		m.expvarName = name
+	}
+}
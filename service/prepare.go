@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPrepareFailed is returned when a Preparable service fails to prepare. No service is started then
+var ErrPrepareFailed = errors.New("service prepare failed")
+
+// Preparable is implemented by services with expensive up-front checks, such as validating their
+// configuration or dialing their dependencies. Start prepares every service it is about to start before
+// starting any of them, so the application fails fast instead of starting partially
+type Preparable interface {
+	Prepare(ctx context.Context) error
+}
+
+// prepareServices prepares the services that are not running or completed yet, concurrently. Failed
+// services are set to StateError and all failures are returned, joined in an error wrapping
+// ErrPrepareFailed. It is used with mu held
+func (o *Manager) prepareServices(ctx context.Context, services []*serviceState) error {
+	errs := make([]error, len(services))
+	var wg sync.WaitGroup
+	for i, state := range services {
+		if current := state.getState(); current == StateRunning || current == StateCompleted {
+			continue
+		}
+		if _, ok := state.service.(Preparable); !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = o.prepareService(ctx, state)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrPrepareFailed, err)
+	}
+	return nil
+}
+
+// prepareService prepares a single service if it implements Preparable
+func (o *Manager) prepareService(ctx context.Context, state *serviceState) error {
+	preparable, ok := state.service.(Preparable)
+	if !ok {
+		return nil
+	}
+
+	name := state.service.Name()
+	o.logger.Debug("Preparing service", "service", name)
+	ctx, end := o.startServiceSpan(ctx, servicePrepareSpanName, state)
+	err := preparable.Prepare(ctx)
+	if err != nil {
+		err = fmt.Errorf("service '%s': %w", name, err)
+		o.logger.Error("Service prepare failed", "service", name, "error", err)
+		state.setError(err)
+		state.setState(StateError)
+	}
+	end(err)
+	return err
+}
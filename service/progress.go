@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// StartProgress reports a step of StartAsync: a service starting, its result, or the end of the start
+type StartProgress struct {
+	// Service is the name of the service, empty for the final report
+	Service string
+	// State is StateStarting when the service begins to start, then StateRunning, StateCompleted for
+	// oneshot services, or StateError. Services already running or completed are reported once
+	State ServiceState
+	// Err is the start error of the service, or of the whole start in the final report
+	Err error
+	// Duration is how long the service took to become ready or fail
+	Duration time.Duration
+	// Done marks the final report, sent once all services started or the start failed
+	Done bool
+}
+
+// StartAsync starts all registered services like Start, streaming the progress of every service as it
+// happens, e.g. to render a live startup checklist. The channel ends with a report with Done set and is
+// then closed. It must be read until closed, starting services wait for their reports to be received
+func (o *Manager) StartAsync(ctx context.Context) <-chan StartProgress {
+	progress := make(chan StartProgress)
+
+	var mu sync.Mutex
+	closed := false
+	report := func(p StartProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Services still starting after another one failed report after the final report
+		if !closed {
+			progress <- p
+		}
+	}
+
+	go func() {
+		err := o.start(ctx, report)
+
+		mu.Lock()
+		defer mu.Unlock()
+		progress <- StartProgress{Err: err, Done: true}
+		closed = true
+		close(progress)
+	}()
+	return progress
+}
+
+// reportStart sends the progress report if progress is being reported
+func reportStart(progress func(StartProgress), p StartProgress) {
+	if progress != nil {
+		progress(p)
+	}
+}
+
+// shutdownProgressBuffer is the number of events a ShutdownProgress channel holds for a slow reader
+const shutdownProgressBuffer = 64
+
+// ShutdownEvent reports a step of stopping services: a service beginning to stop, passing its stop
+// deadline, its result, or the end of Shutdown
+type ShutdownEvent struct {
+	// Service is the name of the service, empty for the final event
+	Service string
+	// State is StateStopping when the service begins to stop, then StateStopped or StateError.
+	// Completed oneshot services are reported once as stopped
+	State ServiceState
+	// TimedOut marks the event sent when the stop deadline passes while the service is still stopping
+	TimedOut bool
+	// Err is the stop error of the service, or of the whole shutdown in the final event
+	Err error
+	// Duration is how long the service has been stopping
+	Duration time.Duration
+	// Done marks the final event, sent when Shutdown completes
+	Done bool
+}
+
+// ShutdownProgress returns a channel receiving the stop events of services until the next Shutdown
+// completes, e.g. to show which services a long shutdown is waiting for. The channel ends with an event
+// with Done set and is then closed. Shutdown never waits for the reader: events that don't fit in the
+// channel's buffer are dropped
+func (o *Manager) ShutdownProgress() <-chan ShutdownEvent {
+	events := make(chan ShutdownEvent, shutdownProgressBuffer)
+
+	o.shutdownMu.Lock()
+	defer o.shutdownMu.Unlock()
+	o.shutdownSubs = append(o.shutdownSubs, events)
+	return events
+}
+
+// reportShutdown sends the event to the ShutdownProgress channels with room for it
+func (o *Manager) reportShutdown(event ShutdownEvent) {
+	o.shutdownMu.Lock()
+	defer o.shutdownMu.Unlock()
+
+	for _, events := range o.shutdownSubs {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// endShutdownProgress sends the final event and closes the ShutdownProgress channels
+func (o *Manager) endShutdownProgress(err error) {
+	o.shutdownMu.Lock()
+	defer o.shutdownMu.Unlock()
+
+	for _, events := range o.shutdownSubs {
+		select {
+		case events <- ShutdownEvent{Err: err, Done: true}:
+		default:
+		}
+		close(events)
+	}
+	o.shutdownSubs = nil
+}
+
+// reportStop reports a service beginning to stop and, if it is still stopping when ctx reaches its
+// deadline, the timeout. The returned function reports the result once the service stopped
+func (o *Manager) reportStop(ctx context.Context, name string) func(err error) {
+	began := time.Now()
+	o.reportShutdown(ShutdownEvent{Service: name, State: StateStopping})
+
+	// mu orders a timeout racing with the result before it
+	var mu sync.Mutex
+	finished := false
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			mu.Lock()
+			defer mu.Unlock()
+			if !finished && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				o.reportShutdown(ShutdownEvent{Service: name, State: StateStopping, TimedOut: true,
+					Err: ctx.Err(), Duration: time.Since(began)})
+			}
+		}
+	}()
+
+	return func(err error) {
+		mu.Lock()
+		finished = true
+		mu.Unlock()
+		close(stopped)
+
+		state := StateStopped
+		if err != nil {
+			state = StateError
+		}
+		o.reportShutdown(ShutdownEvent{Service: name, State: state, Err: err, Duration: time.Since(began)})
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrProviderNotFound is returned when resolving a name without a provider
+var ErrProviderNotFound = errors.New("provider not found")
+
+// Resolver resolves provided values by name
+type Resolver interface {
+	Resolve(name string) (any, error)
+}
+
+// Constructor builds a provided value. Dependencies must be resolved through the given resolver
+type Constructor func(r Resolver) (any, error)
+
+// provider is a lazily constructed named value
+type provider struct {
+	constructor Constructor
+	value       any
+	built       bool
+	deps        []string // providers resolved by the constructor
+}
+
+// Provide registers a lazily constructed value under the name. Values are constructed once, on first
+// resolution or when the manager starts, after their dependencies. A failed construction is retried on
+// the next resolution. Constructed values implementing Service are registered automatically,
+// so with SequenceFIFO services start in dependency order
+func (o *Manager) Provide(name string, constructor Constructor) error {
+	o.providersMu.Lock()
+	defer o.providersMu.Unlock()
+
+	if _, exists := o.providers[name]; exists {
+		return fmt.Errorf("provider with name '%s' already registered", name)
+	}
+
+	o.providers[name] = &provider{constructor: constructor}
+	o.providerOrder = append(o.providerOrder, name)
+	o.logger.Debug("Provider registered", "provider", name)
+	return nil
+}
+
+// Resolve returns the value provided under the name, constructing it and its dependencies if needed.
+// Services constructed after Start are registered but not started
+func (o *Manager) Resolve(name string) (any, error) {
+	o.providersMu.Lock()
+	defer o.providersMu.Unlock()
+
+	return (&resolution{manager: o}).Resolve(name)
+}
+
+// Resolve returns the value provided under the name as T
+func Resolve[T any](r Resolver, name string) (T, error) {
+	var zero T
+
+	value, err := r.Resolve(name)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("provider '%s' returned %T, not %T", name, value, zero)
+	}
+	return typed, nil
+}
+
+// constructProviders constructs every provider not constructed yet, in registration order
+func (o *Manager) constructProviders() error {
+	o.providersMu.Lock()
+	defer o.providersMu.Unlock()
+
+	for _, name := range o.providerOrder {
+		if _, err := (&resolution{manager: o}).Resolve(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolution tracks the providers being constructed to detect dependency cycles.
+// It is used with providersMu held
+type resolution struct {
+	manager *Manager
+	stack   []string
+}
+
+func (r *resolution) Resolve(name string) (any, error) {
+	p, exists := r.manager.providers[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: '%s'", ErrProviderNotFound, name)
+	}
+	if len(r.stack) > 0 {
+		parent := r.manager.providers[r.stack[len(r.stack)-1]]
+		if !slices.Contains(parent.deps, name) {
+			parent.deps = append(parent.deps, name)
+		}
+	}
+	if p.built {
+		return p.value, nil
+	}
+
+	for _, pending := range r.stack {
+		if pending == name {
+			return nil, fmt.Errorf("dependency cycle: %s -> %s", strings.Join(r.stack, " -> "), name)
+		}
+	}
+
+	p.deps = nil
+	r.stack = append(r.stack, name)
+	value, err := p.constructor(r)
+	r.stack = r.stack[:len(r.stack)-1]
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct '%s': %w", name, err)
+	}
+	if service, ok := value.(Service); ok {
+		if err := r.manager.Register(service, WithDependsOn(p.deps...)); err != nil {
+			return nil, fmt.Errorf("failed to register '%s': %w", name, err)
+		}
+	}
+
+	p.value, p.built = value, true
+	r.manager.logger.Debug("Provider constructed", "provider", name)
+	return value, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serviceLabel is the pprof label carrying the service name on its goroutines
+const serviceLabel = "service"
+
+// AllocationReporter is implemented by services that track the memory they allocate, e.g. the sizes
+// of their buffers and caches. Go can't attribute heap allocations to goroutines, so services opt in
+type AllocationReporter interface {
+	AllocatedBytes() uint64
+}
+
+// ResourceUsage is the sampled resource usage of a service
+type ResourceUsage struct {
+	// Goroutines is the number of goroutines started by the service, directly or indirectly
+	Goroutines int
+	// AllocatedBytes is reported by services implementing AllocationReporter, 0 otherwise
+	AllocatedBytes uint64
+	// SampledAt is when the goroutines were counted, zero if resource sampling is disabled
+	SampledAt time.Time
+}
+
+// WithResourceSampling counts the goroutines of every service at the interval, reported in
+// ServiceInfo.Resources and expvar. Services run with a pprof "service" label that goroutines they
+// start inherit, which also attributes CPU and goroutine profiles to services
+func WithResourceSampling(interval time.Duration) Option {
+	return func(m *Manager) {
+		m.resourceInterval = interval
+	}
+}
+
+// resources returns the last sampled resource usage of the service
+func (s *serviceState) resources() ResourceUsage {
+	var usage ResourceUsage
+	if sampledAt := s.sampledAt.Load(); sampledAt != 0 {
+		usage.Goroutines = int(s.goroutines.Load())
+		usage.SampledAt = time.Unix(0, sampledAt)
+	}
+	if reporter, ok := s.service.(AllocationReporter); ok {
+		usage.AllocatedBytes = reporter.AllocatedBytes()
+	}
+	return usage
+}
+
+// startWithLabels calls Start with the service's pprof label if resource sampling or leak detection is enabled
+func (o *Manager) startWithLabels(ctx context.Context, state *serviceState) error {
+	if o.resourceInterval <= 0 && o.leakGrace <= 0 {
+		return state.service.Start(ctx)
+	}
+
+	var err error
+	pprof.Do(ctx, pprof.Labels(serviceLabel, state.service.Name()), func(ctx context.Context) {
+		err = state.service.Start(ctx)
+	})
+	return err
+}
+
+// sampleResources counts the goroutines of all services at the sampling interval until the manager
+// context is cancelled by Shutdown
+func (o *Manager) sampleResources(ctx context.Context) {
+	ticker := time.NewTicker(o.resourceInterval)
+	defer ticker.Stop()
+
+	for {
+		o.sampleGoroutines()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleGoroutines stores the number of goroutines labeled with each service name
+func (o *Manager) sampleGoroutines() {
+	counts, err := goroutinesByLabel(serviceLabel)
+	if err != nil {
+		o.logger.Warn("Failed to sample service goroutines", "error", err)
+		return
+	}
+
+	now := time.Now().UnixNano()
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, state := range o.services {
+		state.goroutines.Store(int64(counts[state.service.Name()]))
+		state.sampledAt.Store(now)
+	}
+}
+
+// goroutinesByLabel counts the goroutines by the value of the pprof label
+func goroutinesByLabel(key string) (map[string]int, error) {
+	groups, err := goroutineGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, group := range groups {
+		if value, ok := group.labels[key]; ok {
+			counts[value] += group.count
+		}
+	}
+	return counts, nil
+}
+
+// goroutineGroup is a set of goroutines with the same stack and labels
+type goroutineGroup struct {
+	count int
+	// stack is the program counters of the stack, identifying it
+	stack  string
+	labels map[string]string
+	// frames are the symbolized frames, e.g. "main.worker+0x2a /app/main.go:42"
+	frames []string
+}
+
+// goroutineGroups parses the goroutine profile's text format, where stacks are grouped with their
+// count, labels and frames
+func goroutineGroups() ([]goroutineGroup, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil, err
+	}
+
+	var groups []goroutineGroup
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if labels, ok := strings.CutPrefix(line, "# labels: "); ok && len(groups) > 0 {
+			json.Unmarshal([]byte(labels), &groups[len(groups)-1].labels)
+			continue
+		}
+		if frame, ok := strings.CutPrefix(line, "#\t"); ok && len(groups) > 0 {
+			// Frames look like "#	0x47d82a	main.worker+0x2a	/app/main.go:42"
+			fields := strings.Fields(frame)
+			if len(fields) >= 2 {
+				group := &groups[len(groups)-1]
+				group.frames = append(group.frames, strings.Join(fields[1:], " "))
+			}
+			continue
+		}
+
+		// Stack headers look like "3 @ 0x47d82a 0x480985"
+		if n, stack, ok := strings.Cut(line, " @ "); ok {
+			count, _ := strconv.Atoi(n)
+			groups = append(groups, goroutineGroup{count: count, stack: stack})
+		}
+	}
+	return groups, scanner.Err()
+}
@@ -2,13 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Service represents a service that can be started and stopped
@@ -18,16 +23,47 @@ type Service interface {
 	Stop(ctx context.Context) error
 }
 
+// ReadinessReporter is implemented by services that can signal when they are ready to serve.
+// The manager waits for the returned channel to be closed instead of assuming readiness
+// shortly after Start was called. A nil channel means readiness is not reported
+type ReadinessReporter interface {
+	Ready() <-chan struct{}
+}
+
+// ErrStartTimeout is returned when a service does not become ready within its start deadline
+var ErrStartTimeout = errors.New("service start timed out")
+
+// ErrServiceNotFound is returned by lookups of services that are not registered
+var ErrServiceNotFound = errors.New("service not found")
+
+// startupGracePeriod is how long a service that does not report readiness is given
+// to fail before it is considered running
+const startupGracePeriod = 10 * time.Millisecond
+
 // ServiceFunc represents the main service logic function
 type ServiceFunc func(ctx context.Context) error
 
+// readyKey is the context key holding the readiness signal of a BaseService
+type readyKey struct{}
+
+// SignalReady marks the BaseService running the given context as ready.
+// It is a no-op if the service was not created with readiness reporting enabled
+func SignalReady(ctx context.Context) {
+	if signal, ok := ctx.Value(readyKey{}).(func()); ok {
+		signal()
+	}
+}
+
 // BaseService provides a clean service implementation that handles common patterns
 type BaseService struct {
 	name      string
 	startFunc ServiceFunc
 	stopFunc  ServiceFunc
-	done      chan struct{}
 	running   atomic.Bool
+	// mu protects done and ready, which are renewed after every run so the service can be restarted
+	mu    sync.Mutex
+	done  chan struct{}
+	ready chan struct{}
 }
 
 // NewService creates a service with just a name and start function
@@ -46,6 +82,20 @@ func (o *BaseService) WithStopFunc(stopFunc ServiceFunc) *BaseService {
 	return o
 }
 
+// WithReadiness makes the manager wait until the start function calls SignalReady
+func (o *BaseService) WithReadiness() *BaseService {
+	o.ready = make(chan struct{})
+	return o
+}
+
+// Ready returns a channel closed once the service signalled readiness,
+// or nil if readiness reporting is not enabled
+func (o *BaseService) Ready() <-chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.ready
+}
+
 // Name returns the service name
 func (o *BaseService) Name() string {
 	return o.name
@@ -58,6 +108,11 @@ func (o *BaseService) Start(ctx context.Context) error {
 	}
 
 	defer o.running.Store(false)
+	defer o.renew()
+
+	o.mu.Lock()
+	done, ready := o.done, o.ready
+	o.mu.Unlock()
 
 	// Create a context that gets cancelled when Stop is called
 	serviceCtx, cancel := context.WithCancel(ctx)
@@ -66,14 +121,22 @@ func (o *BaseService) Start(ctx context.Context) error {
 	// Monitor for stop signal in background
 	go func() {
 		select {
-		case <-o.done:
+		case <-done:
 			cancel()
 		case <-serviceCtx.Done():
 		}
 	}()
 
+	runCtx := serviceCtx
+	if ready != nil {
+		var once sync.Once
+		runCtx = context.WithValue(serviceCtx, readyKey{}, func() {
+			once.Do(func() { close(ready) })
+		})
+	}
+
 	// Run the user's service logic
-	return o.startFunc(serviceCtx)
+	return o.startFunc(runCtx)
 }
 
 // Stop gracefully stops the service
@@ -87,6 +150,8 @@ func (o *BaseService) Stop(ctx context.Context) error {
 
 	// Signal the service to stop
 	if o.running.Load() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
 		select {
 		case <-o.done:
 			// Already closed
@@ -97,6 +162,17 @@ func (o *BaseService) Stop(ctx context.Context) error {
 	return nil
 }
 
+// renew replaces the stop and readiness channels after a run, so the service can be started again
+func (o *BaseService) renew() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.done = make(chan struct{})
+	if o.ready != nil {
+		o.ready = make(chan struct{})
+	}
+}
+
 // IsRunning returns true if the service is currently running
 func (o *BaseService) IsRunning() bool {
 	return o.running.Load()
@@ -104,13 +180,23 @@ func (o *BaseService) IsRunning() bool {
 
 // serviceState represents the atomic state of a service
 type serviceState struct {
-	service   Service
-	state     atomic.Int32 // ServiceState as int32
-	ctx       context.Context
-	cancel    context.CancelFunc
-	lastError error
-	mu        sync.RWMutex   // protects lastError
-	wg        sync.WaitGroup // tracks service goroutines
+	service      Service
+	state        atomic.Int32 // ServiceState as int32
+	ctx          context.Context
+	cancel       context.CancelFunc
+	lastError    error
+	mu           sync.RWMutex   // protects lastError
+	wg           sync.WaitGroup // tracks service goroutines
+	startTimeout time.Duration
+	starts       atomic.Int64 // number of times the service was started
+	runningSince atomic.Int64 // unix nanoseconds the service entered StateRunning, 0 if not running
+	stateSince   atomic.Int64 // unix nanoseconds the service entered its current state
+	dependsOn    []string
+	labels       map[string]string
+	mailbox      serviceMailbox
+	goroutines   atomic.Int64 // goroutines counted by the last resource sample
+	sampledAt    atomic.Int64 // unix nanoseconds of the last resource sample, 0 if never sampled
+	oneshot      bool         // Start returning without error means the service completed
 }
 
 // Manager manages the lifecycle of multiple services
@@ -123,9 +209,52 @@ type Manager struct {
 	logger          Logger
 	mu              sync.RWMutex
 	waitGroup       sync.WaitGroup
+	// parent is the application context the manager context is derived from on every (re)start
+	parent          context.Context
 	ctx             context.Context
 	cancel          context.CancelFunc
 	serviceSequence ServiceSequence
+	startTimeout    time.Duration
+	expvarName      string
+	providers       map[string]*provider
+	providerOrder   []string
+	providersMu     sync.Mutex // serializes provider construction
+	// resourceInterval is the resource sampling interval, 0 disables sampling
+	resourceInterval time.Duration
+	// heartbeat is sent while the critical services run, nil disables heartbeats
+	heartbeat *heartbeat
+	// table is a copy-on-write snapshot of services and serviceMap, republished whenever they change
+	table atomic.Pointer[serviceTable]
+	// tracer records lifecycle spans, nil disables tracing
+	tracer trace.Tracer
+	// leakGrace is how long goroutines may take to exit after Shutdown, 0 disables leak detection
+	leakGrace    time.Duration
+	leakMu       sync.Mutex
+	leakBaseline map[string]int
+	leaks        []GoroutineLeak
+	// shutdownSubs receive the stop events of services until the next Shutdown completes
+	shutdownMu   sync.Mutex
+	shutdownSubs []chan ShutdownEvent
+}
+
+// serviceTable is a snapshot of the registered services, read without the manager lock
+type serviceTable struct {
+	services []*serviceState
+	byName   map[string]*serviceState
+}
+
+// publishServices replaces the snapshot of the registered services. It is used with mu held
+func (o *Manager) publishServices() {
+	o.table.Store(&serviceTable{services: slices.Clone(o.services), byName: maps.Clone(o.serviceMap)})
+}
+
+// serviceSnapshot returns the registered services without taking the manager lock. Code running inside
+// managed services, such as the SelfMonitor, must use it: Stop holds the lock while it waits for them
+func (o *Manager) serviceSnapshot() *serviceTable {
+	if table := o.table.Load(); table != nil {
+		return table
+	}
+	return &serviceTable{}
 }
 
 // ServiceState represents the current state of a service
@@ -137,13 +266,41 @@ const (
 	StateRunning
 	StateStopping
 	StateError
+	// StateCompleted is the state of oneshot services whose Start returned without error
+	StateCompleted
 )
 
+// String returns the lowercase name of the state
+func (s ServiceState) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateStopping:
+		return "stopping"
+	case StateError:
+		return "error"
+	case StateCompleted:
+		return "completed"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
 // ServiceInfo contains information about a service's current state
 type ServiceInfo struct {
 	Name  string
 	State ServiceState
 	Error error
+	// StateSince is when the service entered its current state, for staleness checks
+	StateSince time.Time
+	// Labels are the labels attached with WithLabels
+	Labels map[string]string
+	// Resources is the sampled resource usage, see WithResourceSampling
+	Resources ResourceUsage
 }
 
 // NewManager creates a new service manager with default configuration
@@ -152,10 +309,12 @@ func NewManager(options ...Option) *Manager {
 	m := &Manager{
 		services:        make([]*serviceState, 0),
 		serviceMap:      make(map[string]*serviceState),
+		providers:       make(map[string]*provider),
 		shutdownTimeout: 30 * time.Second,
 		gracefulSignals: []os.Signal{syscall.SIGTERM, syscall.SIGINT},
 		forceSignals:    []os.Signal{syscall.SIGKILL},
 		logger:          NoOpLogger{},
+		parent:          context.Background(),
 		ctx:             ctx,
 		cancel:          cancel,
 		serviceSequence: SequenceNone,
@@ -165,14 +324,52 @@ func NewManager(options ...Option) *Manager {
 		opt(m)
 	}
 
+	if m.expvarName != "" {
+		m.publishExpvar()
+	}
+	if m.resourceInterval > 0 {
+		go m.sampleResources(m.ctx)
+	}
+	if m.heartbeat != nil {
+		go m.sendHeartbeats(m.ctx)
+	}
+
 	return m
 }
 
 // setState atomically sets the service state
 func (s *serviceState) setState(state ServiceState) {
+	now := time.Now().UnixNano()
+	if state == StateRunning {
+		s.runningSince.Store(now)
+	} else {
+		s.runningSince.Store(0)
+	}
+	s.stateSince.Store(now)
 	s.state.Store(int32(state))
 }
 
+// info returns a snapshot of the service state
+func (s *serviceState) info() ServiceInfo {
+	return ServiceInfo{
+		Name:       s.service.Name(),
+		State:      s.getState(),
+		Error:      s.getError(),
+		StateSince: time.Unix(0, s.stateSince.Load()),
+		Labels:     maps.Clone(s.labels),
+		Resources:  s.resources(),
+	}
+}
+
+// uptime returns how long the service has been running, 0 if it is not running
+func (s *serviceState) uptime() time.Duration {
+	since := s.runningSince.Load()
+	if since == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, since))
+}
+
 // getState atomically gets the service state
 func (s *serviceState) getState() ServiceState {
 	return ServiceState(s.state.Load())
@@ -193,7 +390,7 @@ func (s *serviceState) getError() error {
 }
 
 // Register adds a service to the manager
-func (o *Manager) Register(service Service) error {
+func (o *Manager) Register(service Service, options ...RegisterOption) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
@@ -212,29 +409,67 @@ func (o *Manager) Register(service Service) error {
 	}
 	state.setState(StateStopped)
 
+	for _, opt := range options {
+		opt(state)
+	}
+
 	o.services = append(o.services, state)
 	o.serviceMap[service.Name()] = state
+	o.publishServices()
 	o.logger.Debug("Service registered", "service", service.Name())
 	return nil
 }
 
 // Start starts all registered services
 func (o *Manager) Start(ctx context.Context) error {
+	return o.start(ctx, nil)
+}
+
+// start starts all registered services, reporting each service to progress if it is set
+func (o *Manager) start(ctx context.Context, progress func(StartProgress)) error {
+	// Construct provided values first, registering the services among them
+	if err := o.constructProviders(); err != nil {
+		return err
+	}
+
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
+	o.renewContext()
+	o.recordLeakBaseline()
 	o.logger.Info("Starting all services", "count", len(o.services))
 
+	ctx, end := o.startSpan(ctx, startSpanName, serviceCountKey.Int(len(o.services)))
+	err := o.startServices(ctx, progress)
+	end(err)
+	return err
+}
+
+// startServices starts the services in the configured sequence. It is used with mu held
+func (o *Manager) startServices(ctx context.Context, progress func(StartProgress)) error {
+	// Bound the overall start if configured, the caller context is still used for stopping on failure
+	startCtx := ctx
+	if o.startTimeout > 0 {
+		var cancel context.CancelFunc
+		startCtx, cancel = context.WithTimeout(ctx, o.startTimeout)
+		defer cancel()
+	}
+
+	// Prepare every service before starting any, so a failing check leaves nothing half started
+	if err := o.prepareServices(startCtx, o.services); err != nil {
+		return err
+	}
+
 	// Start services based on sequence configuration
 	switch o.serviceSequence {
 	case SequenceNone:
-		return o.startServicesParallel(ctx)
+		return o.startServicesParallel(ctx, startCtx, progress)
 	case SequenceFIFO:
-		return o.startServicesSequential(ctx, false)
+		return o.startServicesSequential(ctx, startCtx, false, progress)
 	case SequenceLIFO:
-		return o.startServicesSequential(ctx, true)
+		return o.startServicesSequential(ctx, startCtx, true, progress)
 	default:
-		return o.startServicesParallel(ctx)
+		return o.startServicesParallel(ctx, startCtx, progress)
 	}
 }
 
@@ -243,22 +478,33 @@ func (o *Manager) Stop(ctx context.Context) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	return o.stopAllServices(ctx)
+	ctx, end := o.startSpan(ctx, stopSpanName, serviceCountKey.Int(len(o.services)))
+	err := o.stopAllServices(ctx)
+	end(err)
+	return err
 }
 
 // startServicesParallel starts all services in parallel
-func (o *Manager) startServicesParallel(ctx context.Context) error {
+func (o *Manager) startServicesParallel(ctx, startCtx context.Context, progress func(StartProgress)) error {
 	errChan := make(chan error, len(o.services))
 	startedServices := make([]*serviceState, 0, len(o.services))
 
 	for _, state := range o.services {
-		if state.getState() == StateRunning {
+		if current := state.getState(); current == StateRunning || current == StateCompleted {
 			o.logger.Debug("Service already running, skipping", "service", state.service.Name())
+			reportStart(progress, StartProgress{Service: state.service.Name(), State: current})
 			continue
 		}
 
 		startedServices = append(startedServices, state)
-		go o.startSingleService(state, errChan)
+	}
+
+	// Mark all services starting first, so services waiting for a oneshot don't mistake it for stopped
+	for _, state := range startedServices {
+		state.setState(StateStarting)
+	}
+	for _, state := range startedServices {
+		go o.startSingleService(startCtx, state, errChan, progress)
 	}
 
 	// Wait for all services to start or fail
@@ -275,7 +521,7 @@ func (o *Manager) startServicesParallel(ctx context.Context) error {
 }
 
 // startServicesSequential starts services in sequence (FIFO or LIFO)
-func (o *Manager) startServicesSequential(ctx context.Context, reverse bool) error {
+func (o *Manager) startServicesSequential(ctx, startCtx context.Context, reverse bool, progress func(StartProgress)) error {
 	services := o.services
 	if reverse {
 		services = make([]*serviceState, len(o.services))
@@ -285,14 +531,19 @@ func (o *Manager) startServicesSequential(ctx context.Context, reverse bool) err
 		}
 	}
 
+	if err := o.checkOneshotOrder(services); err != nil {
+		return err
+	}
+
 	for _, state := range services {
-		if state.getState() == StateRunning {
+		if current := state.getState(); current == StateRunning || current == StateCompleted {
 			o.logger.Debug("Service already running, skipping", "service", state.service.Name())
+			reportStart(progress, StartProgress{Service: state.service.Name(), State: current})
 			continue
 		}
 
 		errChan := make(chan error, 1)
-		go o.startSingleService(state, errChan)
+		go o.startSingleService(startCtx, state, errChan, progress)
 
 		if err := <-errChan; err != nil {
 			o.logger.Error("Service start failed, stopping all services", "error", err)
@@ -306,9 +557,50 @@ func (o *Manager) startServicesSequential(ctx context.Context, reverse bool) err
 }
 
 // startSingleService starts a single service and reports the result
-func (o *Manager) startSingleService(state *serviceState, errChan chan<- error) {
-	o.logger.Debug("Starting service", "service", state.service.Name())
+func (o *Manager) startSingleService(ctx context.Context, state *serviceState, errChan chan<- error, progress func(StartProgress)) {
+	name := state.service.Name()
+	o.logger.Debug("Starting service", "service", name)
+	reportStart(progress, StartProgress{Service: name, State: StateStarting})
+
+	ctx, end := o.startServiceSpan(ctx, serviceStartSpanName, state)
+	began := time.Now()
+	err := o.launchService(ctx, state)
+	end(err)
+	result := StartProgress{Service: name, State: state.getState(), Err: err, Duration: time.Since(began)}
+	if err != nil {
+		result.State = StateError
+	}
+	reportStart(progress, result)
+	errChan <- err
+}
+
+// launchService runs the service in its own goroutine and waits until it is ready,
+// fails or misses its start deadline
+func (o *Manager) launchService(ctx context.Context, state *serviceState) error {
+	name := state.service.Name()
 	state.setState(StateStarting)
+	state.starts.Add(1)
+	state.setError(nil)
+
+	// Init-style dependencies such as migrations must have finished first
+	if deps := o.oneshotDependencies(state); len(deps) > 0 {
+		if err := waitCompleted(ctx, deps); err != nil {
+			err = fmt.Errorf("service '%s' dependencies did not complete: %w", name, err)
+			state.setError(err)
+			state.setState(StateError)
+			return err
+		}
+	}
+
+	// Every run gets a fresh context, the previous one was cancelled when the service stopped
+	state.cancel()
+	state.ctx, state.cancel = context.WithCancel(o.ctx)
+	serviceCtx := state.ctx
+
+	exited := make(chan struct{})
+	if state.mailbox != nil {
+		state.mailbox.acquire()
+	}
 
 	// Start service in a goroutine so it can run independently
 	state.wg.Add(1)
@@ -316,9 +608,13 @@ func (o *Manager) startSingleService(state *serviceState, errChan chan<- error)
 	go func() {
 		defer state.wg.Done()
 		defer o.waitGroup.Done()
+		defer close(exited)
+		if state.mailbox != nil {
+			defer state.mailbox.release()
+		}
 
-		if err := state.service.Start(state.ctx); err != nil {
-			o.logger.Error("Service failed during execution", "service", state.service.Name(), "error", err)
+		if err := o.startWithLabels(serviceCtx, state); err != nil {
+			o.logger.Error("Service failed during execution", "service", name, "error", err)
 			state.setError(err)
 			state.setState(StateError)
 			return
@@ -326,28 +622,70 @@ func (o *Manager) startSingleService(state *serviceState, errChan chan<- error)
 
 		// Service.Start should block until the service stops
 		// When it returns without error, the service has stopped cleanly
+		if state.oneshot {
+			state.setState(StateCompleted)
+			o.logger.Info("Oneshot service completed", "service", name)
+			return
+		}
 		state.setState(StateStopped)
-		o.logger.Info("Service stopped cleanly", "service", state.service.Name())
+		o.logger.Info("Service stopped cleanly", "service", name)
 	}()
 
-	// Give the service a moment to start up
-	time.Sleep(10 * time.Millisecond)
+	// Services reporting readiness are waited for, others get a moment to start up
+	// Oneshot services are waited for until they complete
+	var ready <-chan struct{}
+	if reporter, ok := state.service.(ReadinessReporter); ok && !state.oneshot {
+		ready = reporter.Ready()
+	}
+	var grace <-chan time.Time
+	if ready == nil && !state.oneshot {
+		grace = time.After(startupGracePeriod)
+	}
+	var deadline <-chan time.Time
+	if state.startTimeout > 0 {
+		deadline = time.After(state.startTimeout)
+	}
+
+	select {
+	case <-ready:
+	case <-grace:
+	case <-exited:
+	case <-deadline:
+		return o.failStart(state, fmt.Errorf("%w: service '%s' not ready within %v", ErrStartTimeout, name, state.startTimeout))
+	case <-ctx.Done():
+		return o.failStart(state, fmt.Errorf("%w: service '%s': %w", ErrStartTimeout, name, ctx.Err()))
+	}
 
 	// Check if service failed to start
 	if state.getState() == StateError {
-		errChan <- fmt.Errorf("failed to start service '%s': %w", state.service.Name(), state.getError())
-		return
+		return fmt.Errorf("failed to start service '%s': %w", name, state.getError())
+	}
+	if state.oneshot {
+		return nil
 	}
 
 	state.setState(StateRunning)
-	o.logger.Info("Service started successfully", "service", state.service.Name())
-	errChan <- nil
+	o.logger.Info("Service started successfully", "service", name)
+	return nil
+}
+
+// failStart cancels a service that missed its start deadline and records the error
+func (o *Manager) failStart(state *serviceState, err error) error {
+	o.logger.Error("Service start timed out", "service", state.service.Name(), "error", err)
+	state.cancel()
+	state.setError(err)
+	state.setState(StateError)
+	return err
 }
 
 // stopAllServices stops all services (internal helper, assumes lock is held)
 func (o *Manager) stopAllServices(ctx context.Context) error {
 	var errors []error
 
+	// Services share the shutdown budget, each gets a context with the same deadline
+	ctx, cancel := o.stopContext(ctx)
+	defer cancel()
+
 	o.logger.Info("Stopping all services", "count", len(o.services))
 
 	// Stop services based on sequence configuration
@@ -427,14 +765,29 @@ func (o *Manager) stopServicesSequential(ctx context.Context, reverse bool) []er
 }
 
 // stopSingleService stops a single service
-func (o *Manager) stopSingleService(ctx context.Context, state *serviceState) error {
+func (o *Manager) stopSingleService(ctx context.Context, state *serviceState) (err error) {
+	ctx, end := o.startServiceSpan(ctx, serviceStopSpanName, state)
+	defer func() { end(err) }()
+
+	// Completed oneshots have nothing left to stop, they run again on the next start
+	if state.getState() == StateCompleted {
+		state.setState(StateStopped)
+		o.reportShutdown(ShutdownEvent{Service: state.service.Name(), State: StateStopped})
+		return nil
+	}
+
 	o.logger.Debug("Stopping service", "service", state.service.Name())
 	state.setState(StateStopping)
 
+	stopCtx, cancel := o.stopContext(ctx)
+	defer cancel()
+	stopped := o.reportStop(stopCtx, state.service.Name())
+	defer func() { stopped(err) }()
+
 	// Cancel the service context
 	state.cancel()
 
-	if err := state.service.Stop(ctx); err != nil {
+	if err := state.service.Stop(stopCtx); err != nil {
 		o.logger.Error("Service stop failed", "service", state.service.Name(), "error", err)
 		state.setError(err)
 		state.setState(StateError)
@@ -467,36 +820,11 @@ func (o *Manager) StartService(ctx context.Context, name string) error {
 		return fmt.Errorf("service '%s' is already running", name)
 	}
 
-	state.setState(StateStarting)
-
-	// Start service in a goroutine
-	state.wg.Add(1)
-	o.waitGroup.Add(1)
-	go func() {
-		defer state.wg.Done()
-		defer o.waitGroup.Done()
-
-		if err := state.service.Start(state.ctx); err != nil {
-			o.logger.Error("Service failed during execution", "service", name, "error", err)
-			state.setError(err)
-			state.setState(StateError)
-			return
-		}
-
-		state.setState(StateStopped)
-		o.logger.Info("Service stopped cleanly", "service", name)
-	}()
-
-	// Give the service a moment to start
-	time.Sleep(10 * time.Millisecond)
-
-	if state.getState() == StateError {
-		return fmt.Errorf("failed to start service '%s': %w", name, state.getError())
+	o.renewContext()
+	if err := o.prepareService(ctx, state); err != nil {
+		return fmt.Errorf("%w: %w", ErrPrepareFailed, err)
 	}
-
-	state.setState(StateRunning)
-	o.logger.Info("Service started successfully", "service", name)
-	return nil
+	return o.launchService(ctx, state)
 }
 
 // StopService stops a specific service by name
@@ -516,11 +844,17 @@ func (o *Manager) StopService(ctx context.Context, name string) error {
 		o.logger.Warn("Attempted to stop already stopped service", "service", name)
 		return fmt.Errorf("service '%s' is not running", name)
 	}
+	if state.getState() == StateCompleted {
+		state.setState(StateStopped)
+		return nil
+	}
 
 	state.setState(StateStopping)
 	state.cancel()
 
-	if err := state.service.Stop(ctx); err != nil {
+	stopCtx, cancel := o.stopContext(ctx)
+	defer cancel()
+	if err := state.service.Stop(stopCtx); err != nil {
 		o.logger.Error("Failed to stop service", "service", name, "error", err)
 		state.setError(err)
 		state.setState(StateError)
@@ -535,6 +869,70 @@ func (o *Manager) StopService(ctx context.Context, name string) error {
 	return nil
 }
 
+// Replace swaps a registered service for a new instance with the same name without downtime.
+// If the old instance is running, the new one is started and waited for readiness before the old one
+// is stopped. When the new instance fails to start, the old one keeps running and the error is returned
+func (o *Manager) Replace(ctx context.Context, name string, service Service) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	old, exists := o.serviceMap[name]
+	if !exists {
+		return fmt.Errorf("%w: '%s'", ErrServiceNotFound, name)
+	}
+	if service.Name() != name {
+		return fmt.Errorf("replacement for service '%s' is named '%s'", name, service.Name())
+	}
+
+	serviceCtx, cancel := context.WithCancel(o.ctx)
+	state := &serviceState{
+		service:      service,
+		ctx:          serviceCtx,
+		cancel:       cancel,
+		startTimeout: old.startTimeout,
+		dependsOn:    old.dependsOn,
+		labels:       old.labels,
+		oneshot:      old.oneshot,
+		mailbox:      old.mailbox,
+	}
+	state.setState(StateStopped)
+
+	wasRunning := old.getState() == StateRunning
+	if wasRunning {
+		o.logger.Info("Starting replacement service", "service", name)
+		if err := o.prepareService(ctx, state); err != nil {
+			state.cancel()
+			o.logger.Error("Replacement service failed to prepare, keeping current instance", "service", name, "error", err)
+			return fmt.Errorf("failed to replace service '%s': %w: %w", name, ErrPrepareFailed, err)
+		}
+		if err := o.launchService(ctx, state); err != nil {
+			state.cancel()
+			o.logger.Error("Replacement service failed to start, keeping current instance", "service", name, "error", err)
+			return fmt.Errorf("failed to replace service '%s': %w", name, err)
+		}
+	}
+
+	for i, registered := range o.services {
+		if registered == old {
+			o.services[i] = state
+		}
+	}
+	o.serviceMap[name] = state
+	o.publishServices()
+
+	if !wasRunning {
+		old.cancel()
+		o.logger.Info("Service replaced", "service", name)
+		return nil
+	}
+
+	if err := o.stopSingleService(ctx, old); err != nil {
+		return fmt.Errorf("service '%s' replaced but the old instance failed to stop: %w", name, err)
+	}
+	o.logger.Info("Service replaced", "service", name)
+	return nil
+}
+
 // IsRunning checks if a service is currently running
 // This method is lock-free for better performance
 func (o *Manager) IsRunning(name string) bool {
@@ -556,26 +954,61 @@ func (o *Manager) GetStatus() []ServiceInfo {
 
 	status := make([]ServiceInfo, 0, len(o.services))
 	for _, state := range o.services {
-		info := ServiceInfo{
-			Name: state.service.Name(),
-		}
+		status = append(status, state.info())
+	}
+
+	return status
+}
 
-		info.State = state.getState()
-		info.Error = state.getError()
+// GetStatusWhere returns the status of the registered services matching the filter
+func (o *Manager) GetStatusWhere(filter func(ServiceInfo) bool) []ServiceInfo {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
 
-		status = append(status, info)
+	var status []ServiceInfo
+	for _, state := range o.services {
+		if info := state.info(); filter(info) {
+			status = append(status, info)
+		}
 	}
 
 	return status
 }
 
+// ServiceInfo returns the status of a single service
+func (o *Manager) ServiceInfo(name string) (ServiceInfo, error) {
+	o.mu.RLock()
+	state, exists := o.serviceMap[name]
+	o.mu.RUnlock()
+
+	if !exists {
+		return ServiceInfo{}, fmt.Errorf("%w: '%s'", ErrServiceNotFound, name)
+	}
+
+	return state.info(), nil
+}
+
 // RunWithGracefulShutdown runs all services and handles graceful shutdown
 func (o *Manager) RunWithGracefulShutdown(ctx context.Context) error {
+	return o.run(ctx).err
+}
+
+// runOutcome describes how a run with graceful shutdown ended
+type runOutcome struct {
+	// signal is the shutdown signal received, nil if the context was cancelled
+	signal os.Signal
+	// forced is set when the shutdown was forced by a signal or the shutdown timeout
+	forced bool
+	err    error
+}
+
+// run starts all services and shuts them down on context cancellation or a shutdown signal
+func (o *Manager) run(ctx context.Context) runOutcome {
 	o.logger.Info("Starting service manager with graceful shutdown")
 
 	// Start all services
 	if err := o.Start(ctx); err != nil {
-		return err
+		return runOutcome{err: err}
 	}
 
 	// Setup signal handling for graceful shutdown
@@ -593,18 +1026,19 @@ func (o *Manager) RunWithGracefulShutdown(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
 		o.logger.Info("Context cancelled, initiating graceful shutdown")
-		return o.Shutdown(ctx)
+		return runOutcome{err: o.Shutdown(ctx)}
 	case sig := <-sigChan:
 		o.logger.Info("Graceful shutdown signal received", "signal", sig)
-		return o.gracefulShutdown()
+		forced, err := o.gracefulShutdown()
+		return runOutcome{signal: sig, forced: forced, err: err}
 	case sig := <-forceChan:
 		o.logger.Warn("Force shutdown signal received", "signal", sig)
-		return o.Shutdown(context.Background())
+		return runOutcome{signal: sig, forced: true, err: o.Shutdown(context.Background())}
 	}
 }
 
-// gracefulShutdown performs a graceful shutdown with timeout
-func (o *Manager) gracefulShutdown() error {
+// gracefulShutdown shuts down within the shutdown timeout and reports whether the timeout forced it
+func (o *Manager) gracefulShutdown() (forced bool, err error) {
 	o.logger.Info("Starting graceful shutdown", "timeout", o.shutdownTimeout)
 	ctx, cancel := context.WithTimeout(context.Background(), o.shutdownTimeout)
 	defer cancel()
@@ -622,10 +1056,10 @@ func (o *Manager) gracefulShutdown() error {
 		} else {
 			o.logger.Info("Graceful shutdown completed successfully")
 		}
-		return err
+		return false, err
 	case <-ctx.Done():
 		o.logger.Warn("Graceful shutdown timeout reached, forcing shutdown", "timeout", o.shutdownTimeout)
-		return o.Shutdown(context.Background())
+		return true, o.Shutdown(context.Background())
 	}
 }
 
@@ -641,12 +1075,33 @@ func (o *Manager) Services() []Service {
 	return services
 }
 
+// renewContext derives a new manager context if the previous one was cancelled by Shutdown,
+// so a shut down manager can be started again. It is used with mu held
+func (o *Manager) renewContext() {
+	if o.ctx.Err() == nil || o.parent.Err() != nil {
+		return
+	}
+
+	o.logger.Debug("Restarting service manager")
+	o.ctx, o.cancel = context.WithCancel(o.parent)
+	if o.resourceInterval > 0 {
+		go o.sampleResources(o.ctx)
+	}
+	if o.heartbeat != nil {
+		go o.sendHeartbeats(o.ctx)
+	}
+}
+
 // Shutdown gracefully shuts down the manager and all services
 func (o *Manager) Shutdown(ctx context.Context) error {
 	o.logger.Info("Shutting down service manager")
+	ctx, end := o.startSpan(ctx, shutdownSpanName)
 
-	// Cancel the manager context
-	o.cancel()
+	// Cancel the manager context, replaced under the lock by restarts
+	o.mu.RLock()
+	cancel := o.cancel
+	o.mu.RUnlock()
+	cancel()
 
 	// Stop all services
 	err := o.Stop(ctx)
@@ -655,8 +1110,11 @@ func (o *Manager) Shutdown(ctx context.Context) error {
 	o.logger.Debug("Waiting for all service goroutines to complete")
 	o.waitGroup.Wait()
 	o.logger.Debug("All service goroutines completed")
+	o.detectLeaks()
+	o.endShutdownProgress(err)
 
 	o.logger.Info("Service manager shutdown complete")
+	end(err)
 	return err
 }
 
@@ -695,7 +1153,9 @@ func (o *Manager) WaitForAllServices() {
 	o.logger.Info("All services completed")
 }
 
-// Context returns the manager's context
+// Context returns the manager's context. A restart after Shutdown replaces it
 func (o *Manager) Context() context.Context {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
 	return o.ctx
 }
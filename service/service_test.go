@@ -0,0 +1,1352 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btchead/go-reusables/httpserver"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestManager_StartTimeout(t *testing.T) {
+	t.Run("service that never becomes ready", func(t *testing.T) {
+		manager := NewManager()
+
+		svc := NewService("stuck", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}).WithReadiness()
+
+		if err := manager.Register(svc, WithServiceStartTimeout(50*time.Millisecond)); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		err := manager.Start(context.Background())
+		if !errors.Is(err, ErrStartTimeout) {
+			t.Fatalf("Expected ErrStartTimeout, got %v", err)
+		}
+
+		if manager.IsRunning("stuck") {
+			t.Error("Service should not be running after start timeout")
+		}
+	})
+
+	t.Run("overall start deadline", func(t *testing.T) {
+		manager := NewManager(WithStartTimeout(50 * time.Millisecond))
+
+		svc := NewService("stuck", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}).WithReadiness()
+
+		if err := manager.Register(svc); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		if err := manager.Start(context.Background()); !errors.Is(err, ErrStartTimeout) {
+			t.Fatalf("Expected ErrStartTimeout, got %v", err)
+		}
+	})
+
+	t.Run("service that signals readiness", func(t *testing.T) {
+		manager := NewManager()
+
+		svc := NewService("ready", func(ctx context.Context) error {
+			SignalReady(ctx)
+			<-ctx.Done()
+			return nil
+		}).WithReadiness()
+
+		if err := manager.Register(svc, WithServiceStartTimeout(time.Second)); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+
+		if err := manager.Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+
+		if !manager.IsRunning("ready") {
+			t.Error("Service should be running after signalling readiness")
+		}
+
+		if err := manager.Shutdown(context.Background()); err != nil {
+			t.Errorf("Shutdown failed: %v", err)
+		}
+	})
+}
+
+func TestManager_Expvar(t *testing.T) {
+	manager := NewManager(WithExpvar("test_services"))
+
+	svc := NewService("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := manager.Register(svc); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	published := expvar.Get("test_services")
+	if published == nil {
+		t.Fatal("Expected expvar to be published")
+	}
+
+	var snapshot map[string]struct {
+		State    string `json:"state"`
+		Restarts int64  `json:"restarts"`
+	}
+	if err := json.Unmarshal([]byte(published.String()), &snapshot); err != nil {
+		t.Fatalf("Failed to decode expvar: %v", err)
+	}
+
+	if snapshot["worker"].State != "running" {
+		t.Errorf("Expected state 'running', got '%s'", snapshot["worker"].State)
+	}
+}
+
+func TestManager_ExpvarReplaced(t *testing.T) {
+	first := NewManager(WithExpvar("test_services_replaced"))
+	if err := first.Register(NewService("first", func(ctx context.Context) error { return nil })); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	second := NewManager(WithExpvar("test_services_replaced"))
+	if err := second.Register(NewService("second", func(ctx context.Context) error { return nil })); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// Start and Stop hold the manager lock, the snapshot must not wait for it
+	second.mu.Lock()
+	done := make(chan string, 1)
+	go func() { done <- expvar.Get("test_services_replaced").String() }()
+
+	var published string
+	select {
+	case published = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expvar snapshot blocked on the manager lock")
+	}
+	second.mu.Unlock()
+
+	var snapshot map[string]struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(published), &snapshot); err != nil {
+		t.Fatalf("Failed to decode expvar: %v", err)
+	}
+
+	if _, ok := snapshot["second"]; !ok {
+		t.Errorf("Expected the second manager's services, got %v", snapshot)
+	}
+	if _, ok := snapshot["first"]; ok {
+		t.Errorf("Expected the first manager to be replaced, got %v", snapshot)
+	}
+}
+
+func TestManager_ServiceInfo(t *testing.T) {
+	manager := NewManager()
+
+	before := time.Now()
+	for _, name := range []string{"api", "worker"} {
+		svc := NewService(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		if err := manager.Register(svc); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	if err := manager.StartService(context.Background(), "api"); err != nil {
+		t.Fatalf("StartService failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	info, err := manager.ServiceInfo("api")
+	if err != nil {
+		t.Fatalf("ServiceInfo failed: %v", err)
+	}
+	if info.State != StateRunning {
+		t.Errorf("Expected state running, got %s", info.State)
+	}
+	if info.StateSince.Before(before) {
+		t.Errorf("Expected state timestamp after %v, got %v", before, info.StateSince)
+	}
+
+	if _, err := manager.ServiceInfo("missing"); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("Expected ErrServiceNotFound, got %v", err)
+	}
+
+	stopped := manager.GetStatusWhere(func(info ServiceInfo) bool {
+		return info.State == StateStopped
+	})
+	if len(stopped) != 1 || stopped[0].Name != "worker" {
+		t.Errorf("Expected only 'worker' to be stopped, got %v", stopped)
+	}
+}
+
+type unhealthyService struct {
+	*BaseService
+}
+
+func (s unhealthyService) HealthCheck(ctx context.Context) error {
+	return errors.New("backend unreachable")
+}
+
+func TestSelfMonitor_EscalatesAfterThreshold(t *testing.T) {
+	manager := NewManager()
+
+	svc := unhealthyService{NewService("db", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})}
+	if err := manager.Register(svc); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	escalations := 0
+	monitor := NewSelfMonitor(manager, time.Millisecond,
+		WithCriticalServices("db"),
+		WithUnhealthyThreshold(20*time.Millisecond),
+		WithOnUnhealthy(func(name string, unhealthyFor time.Duration, err error) {
+			escalations++
+		}),
+	)
+
+	monitor.Check(context.Background())
+	if escalations != 0 {
+		t.Fatal("Should not escalate before the threshold")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	monitor.Check(context.Background())
+	monitor.Check(context.Background())
+
+	if escalations != 1 {
+		t.Errorf("Expected exactly one escalation, got %d", escalations)
+	}
+}
+
+func TestManager_Provide(t *testing.T) {
+	manager := NewManager(WithServiceSequence(SequenceFIFO))
+
+	type pool struct{ dsn string }
+	var order []string
+
+	manager.Provide("api", func(r Resolver) (any, error) {
+		db, err := Resolve[*pool](r, "db")
+		if err != nil {
+			return nil, err
+		}
+		order = append(order, "api")
+		return NewService("api", func(ctx context.Context) error {
+			if db.dsn == "" {
+				return errors.New("missing pool")
+			}
+			<-ctx.Done()
+			return nil
+		}), nil
+	})
+	manager.Provide("db", func(r Resolver) (any, error) {
+		dsn, err := Resolve[string](r, "dsn")
+		if err != nil {
+			return nil, err
+		}
+		order = append(order, "db")
+		return &pool{dsn: dsn}, nil
+	})
+	manager.Provide("dsn", func(r Resolver) (any, error) {
+		return "postgres://localhost", nil
+	})
+
+	if err := manager.Provide("dsn", nil); err == nil {
+		t.Error("Expected error for duplicate provider")
+	}
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	if len(order) != 2 || order[0] != "db" || order[1] != "api" {
+		t.Errorf("Expected dependencies constructed first, got %v", order)
+	}
+	if !manager.IsRunning("api") {
+		t.Error("Expected provided service to be registered and started")
+	}
+
+	first, _ := manager.Resolve("db")
+	second, _ := manager.Resolve("db")
+	if first != second {
+		t.Error("Expected provided value to be constructed once")
+	}
+
+	if _, err := Resolve[int](manager, "dsn"); err == nil {
+		t.Error("Expected error for mismatched type")
+	}
+	if _, err := manager.Resolve("missing"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("Expected ErrProviderNotFound, got %v", err)
+	}
+}
+
+func TestManager_ProvideCycle(t *testing.T) {
+	manager := NewManager()
+	manager.Provide("a", func(r Resolver) (any, error) { return r.Resolve("b") })
+	manager.Provide("b", func(r Resolver) (any, error) { return r.Resolve("a") })
+
+	_, err := manager.Resolve("a")
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: a -> b -> a") {
+		t.Errorf("Expected dependency cycle error, got %v", err)
+	}
+}
+
+func TestManager_ExportGraph(t *testing.T) {
+	manager := NewManager()
+
+	manager.Provide("db", func(r Resolver) (any, error) {
+		return NewService("db", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}), nil
+	})
+	manager.Provide("api", func(r Resolver) (any, error) {
+		if _, err := r.Resolve("db"); err != nil {
+			return nil, err
+		}
+		return NewService("api", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}), nil
+	})
+	worker := NewService("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	if err := manager.Register(worker, WithDependsOn("db", "queue")); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	dot, err := manager.ExportGraph(GraphDOT)
+	if err != nil {
+		t.Fatalf("ExportGraph failed: %v", err)
+	}
+	for _, want := range []string{`"api" -> "db";`, `"worker" -> "queue";`, `"queue" [style="rounded,dashed"];`, `label="api\nrunning"`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected DOT output to contain %s, got:\n%s", want, dot)
+		}
+	}
+
+	mermaid, err := manager.ExportGraph(GraphMermaid)
+	if err != nil {
+		t.Fatalf("ExportGraph failed: %v", err)
+	}
+	if !strings.HasPrefix(mermaid, "flowchart LR\n") || !strings.Contains(mermaid, `["worker<br/>running"]:::running`) {
+		t.Errorf("Unexpected Mermaid output:\n%s", mermaid)
+	}
+
+	if _, err := manager.ExportGraph("svg"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestManager_Replace(t *testing.T) {
+	manager := NewManager()
+
+	blueStopped := make(chan struct{})
+	blue := NewService("api", func(ctx context.Context) error {
+		<-ctx.Done()
+		close(blueStopped)
+		return nil
+	})
+	if err := manager.Register(blue, WithServiceStartTimeout(time.Second)); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	broken := NewService("api", func(ctx context.Context) error {
+		return errors.New("bind: address already in use")
+	})
+	if err := manager.Replace(context.Background(), "api", broken); err == nil {
+		t.Fatal("Expected error for replacement failing to start")
+	}
+	select {
+	case <-blueStopped:
+		t.Fatal("Old instance should keep running when the replacement fails")
+	default:
+	}
+
+	green := NewService("api", func(ctx context.Context) error {
+		SignalReady(ctx)
+		<-ctx.Done()
+		return nil
+	}).WithReadiness()
+	if err := manager.Replace(context.Background(), "api", green); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	select {
+	case <-blueStopped:
+	case <-time.After(time.Second):
+		t.Fatal("Old instance should be stopped after the swap")
+	}
+	if !manager.IsRunning("api") || len(manager.GetStatus()) != 1 {
+		t.Errorf("Expected the replacement to be the only running 'api', got %v", manager.GetStatus())
+	}
+
+	if err := manager.Replace(context.Background(), "missing", green); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("Expected ErrServiceNotFound, got %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome runOutcome
+		want    int
+	}{
+		{"context cancelled", runOutcome{}, ExitOK},
+		{"service error", runOutcome{err: errors.New("stop failed")}, ExitError},
+		{"SIGINT", runOutcome{signal: syscall.SIGINT}, 130},
+		{"SIGTERM", runOutcome{signal: syscall.SIGTERM}, 143},
+		{"error after signal", runOutcome{signal: syscall.SIGTERM, err: errors.New("stop failed")}, ExitError},
+		{"forced", runOutcome{signal: syscall.SIGTERM, forced: true}, ExitForced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.outcome); got != tt.want {
+				t.Errorf("Expected exit code %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	manager := NewManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if code := manager.RunAndExitCode(ctx); code != ExitOK {
+		t.Errorf("Expected clean exit for cancelled context, got %d", code)
+	}
+}
+
+func TestMailbox(t *testing.T) {
+	type command struct{ name string }
+
+	manager := NewManager()
+	inbox := NewMailbox[command](1)
+	handled := make(chan string, 2)
+
+	work := func(ctx context.Context) error {
+		for {
+			select {
+			case cmd := <-inbox.Receive():
+				handled <- cmd.name
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+	if err := manager.Register(NewService("worker", work), WithMailbox(inbox)); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	mailbox, err := MailboxOf[command](manager, "worker")
+	if err != nil {
+		t.Fatalf("MailboxOf failed: %v", err)
+	}
+	if err := mailbox.Send(context.Background(), command{name: "flush"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got := <-handled; got != "flush" {
+		t.Errorf("Expected 'flush' to be handled, got %q", got)
+	}
+
+	if _, err := MailboxOf[string](manager, "worker"); !errors.Is(err, ErrNoMailbox) {
+		t.Errorf("Expected ErrNoMailbox for mismatched type, got %v", err)
+	}
+
+	if err := manager.StopService(context.Background(), "worker"); err != nil {
+		t.Fatalf("StopService failed: %v", err)
+	}
+	if err := mailbox.Send(context.Background(), command{name: "late"}); !errors.Is(err, ErrMailboxClosed) {
+		t.Errorf("Expected ErrMailboxClosed after stop, got %v", err)
+	}
+	if _, open := <-mailbox.Receive(); open {
+		t.Error("Expected receive channel to be closed after stop")
+	}
+
+	// The mailbox reopens on restart and is kept by a replacement
+	sendAndWait := func(name string) {
+		t.Helper()
+		mailbox, err := MailboxOf[command](manager, "worker")
+		if err != nil {
+			t.Fatalf("MailboxOf failed: %v", err)
+		}
+		if err := mailbox.Send(context.Background(), command{name: name}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if got := <-handled; got != name {
+			t.Errorf("Expected %q to be handled, got %q", name, got)
+		}
+	}
+	if err := manager.StartService(context.Background(), "worker"); err != nil {
+		t.Fatalf("StartService failed: %v", err)
+	}
+	sendAndWait("restarted")
+
+	if err := manager.Replace(context.Background(), "worker", NewService("worker", work)); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	sendAndWait("replaced")
+
+	manager.Shutdown(context.Background())
+	if err := mailbox.Send(context.Background(), command{name: "late"}); !errors.Is(err, ErrMailboxClosed) {
+		t.Errorf("Expected ErrMailboxClosed after shutdown, got %v", err)
+	}
+}
+
+func TestManager_Labels(t *testing.T) {
+	manager := NewManager()
+
+	block := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+	manager.Register(NewService("http", block), WithLabels(map[string]string{"tier": "ingress", "team": "edge"}))
+	manager.Register(NewService("grpc", block), WithLabels(map[string]string{"tier": "ingress"}))
+	manager.Register(NewService("worker", block), WithLabels(map[string]string{"tier": "backend"}))
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	status, err := manager.StatusByLabel("tier=ingress,team!=edge")
+	if err != nil {
+		t.Fatalf("StatusByLabel failed: %v", err)
+	}
+	if len(status) != 1 || status[0].Name != "grpc" {
+		t.Errorf("Expected only grpc, got %v", status)
+	}
+
+	if _, err := manager.StatusByLabel("tier=ingress,,"); !errors.Is(err, ErrInvalidSelector) {
+		t.Errorf("Expected ErrInvalidSelector, got %v", err)
+	}
+
+	if err := manager.StopByLabel(context.Background(), "tier=ingress"); err != nil {
+		t.Fatalf("StopByLabel failed: %v", err)
+	}
+	for name, running := range map[string]bool{"http": false, "grpc": false, "worker": true} {
+		if manager.IsRunning(name) != running {
+			t.Errorf("Expected %s running=%v", name, running)
+		}
+	}
+}
+
+// allocatingService reports a fixed allocation for resource sampling
+type allocatingService struct {
+	Service
+}
+
+func (allocatingService) AllocatedBytes() uint64 { return 4096 }
+
+func TestManager_ResourceSampling(t *testing.T) {
+	manager := NewManager(WithResourceSampling(10 * time.Millisecond))
+	defer manager.Shutdown(context.Background())
+
+	workers := NewService("workers", func(ctx context.Context) error {
+		for range 3 {
+			go func() { <-ctx.Done() }()
+		}
+		<-ctx.Done()
+		return nil
+	})
+	manager.Register(allocatingService{workers})
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		info, _ := manager.ServiceInfo("workers")
+		// Start and its helpers plus the three workers
+		if info.Resources.Goroutines >= 4 {
+			if info.Resources.AllocatedBytes != 4096 {
+				t.Errorf("Expected reported allocation, got %d", info.Resources.AllocatedBytes)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected at least 4 goroutines, got %+v", info.Resources)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManager_Restart(t *testing.T) {
+	manager := NewManager(WithServiceSequence(SequenceFIFO))
+
+	var starts atomic.Int32
+	api := NewService("api", func(ctx context.Context) error {
+		starts.Add(1)
+		SignalReady(ctx)
+		<-ctx.Done()
+		return nil
+	}).WithReadiness()
+	manager.Register(api)
+	manager.Register(NewSelfMonitor(manager, time.Hour))
+
+	for cycle := 1; cycle <= 3; cycle++ {
+		if err := manager.Start(context.Background()); err != nil {
+			t.Fatalf("Start %d failed: %v", cycle, err)
+		}
+		if err := manager.Start(context.Background()); err != nil {
+			t.Fatalf("Repeated start %d failed: %v", cycle, err)
+		}
+		if manager.Context().Err() != nil {
+			t.Fatalf("Expected a live manager context in cycle %d", cycle)
+		}
+		for _, info := range manager.GetStatus() {
+			if info.State != StateRunning {
+				t.Fatalf("Expected %s running in cycle %d, got %v", info.Name, cycle, info.State)
+			}
+		}
+
+		if err := manager.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown %d failed: %v", cycle, err)
+		}
+		for _, info := range manager.GetStatus() {
+			if info.State != StateStopped {
+				t.Fatalf("Expected %s stopped after cycle %d, got %v", info.Name, cycle, info.State)
+			}
+		}
+	}
+
+	if got := starts.Load(); got != 3 {
+		t.Errorf("Expected 3 starts, got %d", got)
+	}
+
+	// Single services restart too
+	manager.Start(context.Background())
+	defer manager.Shutdown(context.Background())
+	if err := manager.StopService(context.Background(), "api"); err != nil {
+		t.Fatalf("StopService failed: %v", err)
+	}
+	if err := manager.StartService(context.Background(), "api"); err != nil {
+		t.Fatalf("StartService failed: %v", err)
+	}
+	if !manager.IsRunning("api") {
+		t.Error("Expected api running after restart")
+	}
+}
+
+func TestDrainChannel(t *testing.T) {
+	ch := make(chan int, 10)
+	for i := 1; i <= 5; i++ {
+		ch <- i
+	}
+
+	var sum int
+	result, err := DrainChannel(context.Background(), ch, func(ctx context.Context, item int) error {
+		sum += item
+		if item == 3 {
+			return errors.New("item 3 failed")
+		}
+		return nil
+	})
+	if want := (DrainResult{Processed: 5, Failed: 1}); result != want || sum != 15 {
+		t.Errorf("Expected %+v with sum 15, got %+v with sum %d", want, result, sum)
+	}
+	if err == nil || !strings.Contains(err.Error(), "item 3 failed") {
+		t.Errorf("Expected the handler error, got %v", err)
+	}
+
+	// Draining stops at the deadline and reports the items left behind
+	for i := 0; i < 10; i++ {
+		ch <- i
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err = DrainChannel(ctx, ch, func(ctx context.Context, item int) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if result.Processed == 0 || result.Processed+result.Abandoned != 10 || result.Abandoned == 0 {
+		t.Errorf("Expected some processed and some abandoned items, got %+v", result)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+
+	// A closed channel is drained to the end
+	close(ch)
+	if result, err := DrainChannel(context.Background(), ch, func(context.Context, int) error { return nil }); err != nil || result.Abandoned != 0 {
+		t.Errorf("Expected the closed channel to drain, got %+v, %v", result, err)
+	}
+}
+
+func TestManager_StartAsync(t *testing.T) {
+	manager := NewManager(WithServiceSequence(SequenceFIFO))
+	for _, name := range []string{"db", "api"} {
+		manager.Register(NewService(name, func(ctx context.Context) error {
+			SignalReady(ctx)
+			<-ctx.Done()
+			return nil
+		}).WithReadiness())
+	}
+	manager.Register(NewService("broken", func(ctx context.Context) error {
+		return errors.New("bad credentials")
+	}).WithReadiness())
+
+	var steps []string
+	var final StartProgress
+	for p := range manager.StartAsync(context.Background()) {
+		if p.Done {
+			final = p
+			continue
+		}
+		steps = append(steps, p.Service+":"+p.State.String())
+	}
+
+	want := []string{"db:starting", "db:running", "api:starting", "api:running", "broken:starting", "broken:error"}
+	if strings.Join(steps, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected progress %v, got %v", want, steps)
+	}
+	if final.Err == nil || !strings.Contains(final.Err.Error(), "bad credentials") {
+		t.Errorf("Expected the start error in the final report, got %v", final.Err)
+	}
+}
+
+func TestManager_Oneshot(t *testing.T) {
+	manager := NewManager()
+
+	var migrated atomic.Bool
+	manager.Register(NewService("migrate", func(ctx context.Context) error {
+		time.Sleep(30 * time.Millisecond)
+		migrated.Store(true)
+		return nil
+	}), WithOneshot())
+
+	var sawMigrated atomic.Bool
+	manager.Register(NewService("api", func(ctx context.Context) error {
+		sawMigrated.Store(migrated.Load())
+		<-ctx.Done()
+		return nil
+	}), WithDependsOn("migrate"))
+	monitor := NewSelfMonitor(manager, time.Hour)
+	manager.Register(monitor)
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer manager.Shutdown(context.Background())
+
+	if !sawMigrated.Load() {
+		t.Error("Expected api to start after the migration completed")
+	}
+	if state := manager.serviceMap["migrate"].getState(); state != StateCompleted {
+		t.Errorf("Expected migrate completed, got %v", state)
+	}
+	if err := manager.WaitForCompleted(context.Background(), "migrate"); err != nil {
+		t.Errorf("WaitForCompleted failed: %v", err)
+	}
+	if err := monitor.evaluate(context.Background())["migrate"]; err != nil {
+		t.Errorf("Expected a completed oneshot to be healthy, got %v", err)
+	}
+
+	mermaid, err := manager.ExportGraph(GraphMermaid)
+	if err != nil {
+		t.Fatalf("ExportGraph failed: %v", err)
+	}
+	if !strings.Contains(mermaid, ":::completed") || !strings.Contains(mermaid, "classDef completed fill:") {
+		t.Errorf("Expected the completed oneshot to be styled, got:\n%s", mermaid)
+	}
+
+	// Starting a dependent alone fails fast instead of waiting for a oneshot nothing starts
+	if err := manager.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := manager.StartService(ctx, "api"); !errors.Is(err, ErrOneshotFailed) {
+		t.Errorf("Expected ErrOneshotFailed for a stopped oneshot, got %v", err)
+	}
+
+	// A failed oneshot fails its dependents
+	failing := NewManager()
+	failing.Register(NewService("migrate", func(ctx context.Context) error {
+		return errors.New("schema locked")
+	}), WithOneshot())
+	failing.Register(NewService("api", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}), WithDependsOn("migrate"))
+	if err := failing.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "schema locked") {
+		t.Errorf("Expected the oneshot failure, got %v", err)
+	}
+}
+
+func TestManager_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	manager := NewManager(WithTracer(provider.Tracer("service-test")), WithServiceSequence(SequenceFIFO))
+
+	manager.Register(NewService("api", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}))
+	manager.Register(NewService("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}).WithStopFunc(func(ctx context.Context) error {
+		return errors.New("stuck")
+	}))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Shutdown(context.Background()); err == nil {
+		t.Fatal("Expected worker stop error")
+	}
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	if len(spans[startSpanName]) != 1 || len(spans[serviceStartSpanName]) != 2 {
+		t.Fatalf("Expected a start span with two service spans, got %v", spans)
+	}
+	for _, span := range spans[serviceStartSpanName] {
+		if span.Parent().SpanID() != spans[startSpanName][0].SpanContext().SpanID() {
+			t.Errorf("Expected service start span to be a child of the start span")
+		}
+	}
+
+	if len(spans[shutdownSpanName]) != 1 || len(spans[stopSpanName]) != 1 || len(spans[serviceStopSpanName]) != 2 {
+		t.Fatalf("Expected shutdown, stop and two service stop spans, got %v", spans)
+	}
+	if spans[stopSpanName][0].Parent().SpanID() != spans[shutdownSpanName][0].SpanContext().SpanID() {
+		t.Error("Expected the stop span to be a child of the shutdown span")
+	}
+	for _, span := range spans[serviceStopSpanName] {
+		var name, state string
+		for _, attr := range span.Attributes() {
+			switch attr.Key {
+			case serviceNameKey:
+				name = attr.Value.AsString()
+			case serviceStateKey:
+				state = attr.Value.AsString()
+			}
+		}
+		if failed := name == "worker"; failed != (span.Status().Code == codes.Error) {
+			t.Errorf("Unexpected status %v for %s", span.Status(), name)
+		}
+		if name == "api" && state != StateStopped.String() {
+			t.Errorf("Expected api stop span state stopped, got %s", state)
+		}
+	}
+}
+
+func TestManager_LeakDetection(t *testing.T) {
+	manager := NewManager(WithLeakDetection(50 * time.Millisecond))
+
+	release := make(chan struct{})
+	defer close(release)
+	manager.Register(NewService("leaky", func(ctx context.Context) error {
+		// Ignores cancellation
+		go func() { <-release }()
+		<-ctx.Done()
+		return nil
+	}))
+	manager.Register(NewService("clean", func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			<-ctx.Done()
+			close(done)
+		}()
+		<-done
+		return nil
+	}))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	leaks := manager.Leaks()
+	if len(leaks) != 1 || leaks[0].Service != "leaky" || leaks[0].Count != 1 {
+		t.Fatalf("Expected one goroutine leaked by leaky, got %+v", leaks)
+	}
+	if !strings.Contains(strings.Join(leaks[0].Stack, "\n"), "TestManager_LeakDetection") {
+		t.Errorf("Expected the leaked stack to point at the test, got %v", leaks[0].Stack)
+	}
+}
+
+func TestManager_StopDeadline(t *testing.T) {
+	manager := NewManager(WithShutdownTimeout(5*time.Second), WithServiceSequence(SequenceFIFO))
+
+	deadlines := make(map[string]time.Time)
+	for _, name := range []string{"first", "second"} {
+		// Runs until stopped, Shutdown cancelling the service context must not stop it early
+		release := make(chan struct{})
+		manager.Register(NewService(name, func(ctx context.Context) error {
+			<-release
+			return nil
+		}).WithStopFunc(func(ctx context.Context) error {
+			defer close(release)
+			deadline, ok := DeadlineFromContext(ctx)
+			if !ok {
+				t.Errorf("Expected %s to get a stop deadline", name)
+			}
+			if remaining, _ := RemainingFromContext(ctx); remaining <= 0 || remaining > 5*time.Second {
+				t.Errorf("Expected remaining budget within the shutdown timeout, got %v", remaining)
+			}
+			deadlines[name] = deadline
+			return nil
+		}))
+	}
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if len(deadlines) != 2 || !deadlines["first"].Equal(deadlines["second"]) {
+		t.Errorf("Expected services to share the shutdown deadline, got %v", deadlines)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expected, _ := ctx.Deadline()
+	if deadline, ok := DeadlineFromContext(ctx); !ok || !deadline.Equal(expected) {
+		t.Errorf("Expected the context deadline outside of Stop, got %v", deadline)
+	}
+}
+
+func TestManager_ShutdownProgress(t *testing.T) {
+	manager := NewManager(WithServiceSequence(SequenceFIFO), WithShutdownTimeout(50*time.Millisecond))
+
+	// Services run until stopped, so they are still running when Shutdown gets to them
+	stoppable := func(name string, stopDelay time.Duration) Service {
+		release := make(chan struct{})
+		return NewService(name, func(ctx context.Context) error {
+			<-release
+			return nil
+		}).WithStopFunc(func(ctx context.Context) error {
+			time.Sleep(stopDelay)
+			close(release)
+			return nil
+		})
+	}
+	manager.Register(stoppable("slow", 100*time.Millisecond))
+	manager.Register(stoppable("fast", 0))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	progress := manager.ShutdownProgress()
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	var steps []string
+	done := false
+	for event := range progress {
+		switch {
+		case event.Done:
+			done = true
+		case event.TimedOut:
+			steps = append(steps, event.Service+":timeout")
+		default:
+			steps = append(steps, event.Service+":"+event.State.String())
+		}
+	}
+
+	want := []string{"fast:stopping", "fast:stopped", "slow:stopping", "slow:timeout", "slow:stopped"}
+	if strings.Join(steps, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected shutdown progress %v, got %v", want, steps)
+	}
+	if !done {
+		t.Error("Expected a final event before the channel is closed")
+	}
+}
+
+// preparableService is a BaseService with a Prepare step
+type preparableService struct {
+	*BaseService
+	prepare func(ctx context.Context) error
+}
+
+func (s *preparableService) Prepare(ctx context.Context) error {
+	return s.prepare(ctx)
+}
+
+func TestManager_Prepare(t *testing.T) {
+	manager := NewManager(WithServiceSequence(SequenceFIFO))
+
+	var started atomic.Int32
+	run := func(ctx context.Context) error {
+		started.Add(1)
+		<-ctx.Done()
+		return nil
+	}
+	dbErr := errors.New("connection refused")
+	var prepared atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	manager.Register(NewService("plain", run))
+	manager.Register(&preparableService{BaseService: NewService("db", run), prepare: func(ctx context.Context) error {
+		prepared.Add(1)
+		if fail.Load() {
+			return dbErr
+		}
+		return nil
+	}})
+	manager.Register(&preparableService{BaseService: NewService("cache", run), prepare: func(ctx context.Context) error {
+		prepared.Add(1)
+		return nil
+	}})
+
+	err := manager.Start(context.Background())
+	if !errors.Is(err, ErrPrepareFailed) || !errors.Is(err, dbErr) || !strings.Contains(err.Error(), "service 'db'") {
+		t.Fatalf("Expected ErrPrepareFailed wrapping the db error, got %v", err)
+	}
+	if started.Load() != 0 {
+		t.Errorf("Expected no service to start after a failed prepare, %d started", started.Load())
+	}
+	if prepared.Load() != 2 {
+		t.Errorf("Expected every preparable service to be prepared, got %d", prepared.Load())
+	}
+	if info, _ := manager.ServiceInfo("db"); info.State != StateError || !errors.Is(info.Error, dbErr) {
+		t.Errorf("Expected db in the error state with its prepare error, got %v", info)
+	}
+
+	fail.Store(false)
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if started.Load() != 3 {
+		t.Errorf("Expected all services to start, %d started", started.Load())
+	}
+
+	if err := manager.StopService(context.Background(), "db"); err != nil {
+		t.Fatalf("StopService failed: %v", err)
+	}
+	fail.Store(true)
+	if err := manager.StartService(context.Background(), "db"); !errors.Is(err, ErrPrepareFailed) {
+		t.Errorf("Expected StartService to prepare the service, got %v", err)
+	}
+	manager.Shutdown(context.Background())
+}
+
+func TestApp(t *testing.T) {
+	configErr := errors.New("missing database url")
+	var workerRuns atomic.Int32
+	worker := func(ctx context.Context) error {
+		workerRuns.Add(1)
+		<-ctx.Done()
+		return nil
+	}
+
+	err := NewApp("orders").WithConfig(func() error { return configErr }).AddWorker("relay", worker).Run(context.Background())
+	if !errors.Is(err, configErr) || workerRuns.Load() != 0 {
+		t.Fatalf("Expected the config error before starting anything, got %v after %d runs", err, workerRuns.Load())
+	}
+	if _, err := NewApp("orders").AddHTTP("api", nil, nil).AddWorker("relay", nil).Build(); err == nil ||
+		!strings.Contains(err.Error(), "'api' has no handler") || !strings.Contains(err.Error(), "'relay' has no function") {
+		t.Errorf("Expected the assembly errors, got %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	halfTLS := &httpserver.Config{TLS: httpserver.TLSConfig{CertFile: "server.crt"}}
+	if _, err := NewApp("orders").AddHTTP("api", halfTLS, handler).Build(); err == nil || !strings.Contains(err.Error(), "HTTP service 'api'") {
+		t.Errorf("Expected the invalid server config, got %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	// The server is created from the configuration once it is loaded
+	ctx, cancel := context.WithCancel(context.Background())
+	var appConfig struct{ HTTP httpserver.Config }
+	manager, err := NewApp("orders").
+		WithConfig(func() error { appConfig.HTTP.Addr = addr; return nil }).
+		WithOptions(WithShutdownTimeout(time.Second)).
+		AddHTTP("api", &appConfig.HTTP, handler).
+		AddWorker("relay", worker).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.RunWithGracefulShutdown(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !(manager.IsRunning("api") && manager.IsRunning("relay")) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		t.Fatalf("Expected the HTTP server to serve, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("Expected the handler's status, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if _, err := http.Get("http://" + addr); err == nil {
+		t.Error("Expected the HTTP server to be shut down")
+	}
+}
+
+func TestApp_Restart(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	manager, err := NewApp("orders").AddHTTP("api", &httpserver.Config{Addr: addr}, handler).Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	for run := range 2 {
+		if err := manager.Start(context.Background()); err != nil {
+			t.Fatalf("Start %d failed: %v", run, err)
+		}
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			t.Fatalf("Run %d: expected the HTTP server to serve, got %v", run, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusTeapot {
+			t.Errorf("Run %d: expected the handler's status, got %d", run, resp.StatusCode)
+		}
+		if err := manager.Shutdown(context.Background()); err != nil {
+			t.Errorf("Shutdown %d failed: %v", run, err)
+		}
+	}
+}
+
+func TestFromErrGroup(t *testing.T) {
+	errRead := errors.New("connection reset")
+	var writerCancelled atomic.Bool
+	svc := FromErrGroup("conn",
+		func(ctx context.Context) error {
+			return errRead
+		},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			writerCancelled.Store(true)
+			return nil
+		},
+	)
+	if err := svc.Start(context.Background()); !errors.Is(err, errRead) {
+		t.Errorf("Expected the first error, got %v", err)
+	}
+	if !writerCancelled.Load() {
+		t.Error("Expected the other goroutines to be cancelled and waited for")
+	}
+
+	var running atomic.Int32
+	svc = FromErrGroup("workers",
+		func(ctx context.Context) error {
+			running.Add(1)
+			SignalReady(ctx)
+			<-ctx.Done()
+			return nil
+		},
+		func(ctx context.Context) error {
+			running.Add(1)
+			<-ctx.Done()
+			return nil
+		},
+	).WithReadiness()
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Start(context.Background())
+	}()
+	select {
+	case <-svc.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("Expected readiness to be signalled from a group function")
+	}
+	for running.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := svc.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean stop, got %v", err)
+	}
+}
+
+func TestManager_Heartbeat(t *testing.T) {
+	var beats atomic.Int32
+	manager := NewManager(WithHeartbeat(2*time.Millisecond, func(ctx context.Context) error {
+		beats.Add(1)
+		return nil
+	}, "api", "worker"))
+	defer manager.Shutdown(context.Background())
+
+	run := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+	manager.Register(NewService("api", run))
+	manager.Register(NewService("worker", run))
+	manager.Register(NewService("optional", run))
+
+	waitBeats := func(want bool) {
+		t.Helper()
+		before := beats.Load()
+		time.Sleep(20 * time.Millisecond)
+		if got := beats.Load() > before; got != want {
+			t.Errorf("Expected heartbeats %v, got %d beats", want, beats.Load()-before)
+		}
+	}
+
+	waitBeats(false)
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitBeats(true)
+
+	// Services not named critical don't stop the heartbeat
+	if err := manager.StopService(context.Background(), "optional"); err != nil {
+		t.Fatalf("StopService failed: %v", err)
+	}
+	waitBeats(true)
+
+	if err := manager.StopService(context.Background(), "worker"); err != nil {
+		t.Fatalf("StopService failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	waitBeats(false)
+
+	path := filepath.Join(t.TempDir(), "alive")
+	touch := TouchFile(path)
+	if err := touch(context.Background()); err != nil {
+		t.Fatalf("TouchFile failed: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	if err := touch(context.Background()); err != nil {
+		t.Fatalf("TouchFile failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || time.Since(info.ModTime()) > time.Minute {
+		t.Errorf("Expected the file to be touched, got %v", err)
+	}
+}
+
+func TestSelfMonitor_Shutdown(t *testing.T) {
+	manager := NewManager(WithServiceSequence(SequenceFIFO))
+	manager.Register(NewSelfMonitor(manager, time.Millisecond))
+	// Stopped before the monitor, which keeps ticking meanwhile
+	manager.Register(NewService("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}))
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Stop holds the manager lock while waiting for the monitor, unlike Shutdown it doesn't cancel the
+	// manager context first
+	done := make(chan error, 1)
+	go func() {
+		if err := manager.Stop(context.Background()); err != nil {
+			done <- err
+			return
+		}
+		done <- manager.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown hung while the monitor was ticking")
+	}
+}
+
+func TestManager_ContextDuringRestart(t *testing.T) {
+	manager := NewManager()
+	manager.Register(NewService("api", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			_ = manager.Context().Err()
+		}
+	}()
+	for range 3 {
+		if err := manager.Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		manager.Shutdown(context.Background())
+	}
+	<-done
+
+	if manager.Context().Err() == nil {
+		t.Error("Expected the context of a shut down manager to be cancelled")
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span and attribute names recorded by WithTracer
+const (
+	startSpanName        = "service.Manager.Start"
+	stopSpanName         = "service.Manager.Stop"
+	shutdownSpanName     = "service.Manager.Shutdown"
+	serviceStartSpanName = "service.start"
+	serviceStopSpanName  = "service.stop"
+
+	servicePrepareSpanName = "service.prepare"
+
+	serviceNameKey  = attribute.Key("service.name")
+	serviceStateKey = attribute.Key("service.state")
+	serviceCountKey = attribute.Key("service.count")
+)
+
+// WithTracer records spans for Start, Stop and Shutdown with a child span per service started or
+// stopped, carrying its name and resulting state, so slow starts and shutdown hangs show up in traces
+func WithTracer(tracer trace.Tracer) Option {
+	return func(m *Manager) {
+		m.tracer = tracer
+	}
+}
+
+// startSpan starts a span when tracing is enabled. The returned function ends it, recording the error
+func (o *Manager) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(err error, attrs ...attribute.KeyValue)) {
+	if o.tracer == nil {
+		return ctx, func(error, ...attribute.KeyValue) {}
+	}
+
+	ctx, span := o.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error, attrs ...attribute.KeyValue) {
+		span.SetAttributes(attrs...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// startServiceSpan starts the span of a single service. The returned function ends it with the state
+// the service reached
+func (o *Manager) startServiceSpan(ctx context.Context, name string, state *serviceState) (context.Context, func(err error)) {
+	ctx, end := o.startSpan(ctx, name, serviceNameKey.String(state.service.Name()))
+	return ctx, func(err error) {
+		end(err, serviceStateKey.String(state.getState().String()))
+	}
+}
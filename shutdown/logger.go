@@ -0,0 +1,18 @@
+package shutdown
+
+// Logger is the logging interface used by the coordinator.
+// It is satisfied by go-reusables/log loggers
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+}
+
+// NoOpLogger is a logger that does nothing
+type NoOpLogger struct{}
+
+func (o NoOpLogger) Debug(msg string, keysAndValues ...any) {}
+func (o NoOpLogger) Info(msg string, keysAndValues ...any)  {}
+func (o NoOpLogger) Warn(msg string, keysAndValues ...any)  {}
+func (o NoOpLogger) Error(msg string, keysAndValues ...any) {}
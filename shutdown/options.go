@@ -0,0 +1,30 @@
+package shutdown
+
+import (
+	"os"
+	"time"
+)
+
+// Option configures a Coordinator
+type Option func(*Coordinator)
+
+// WithTimeout sets the deadline for running all shutdown hooks
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Coordinator) {
+		c.timeout = timeout
+	}
+}
+
+// WithSignals sets the signals that trigger shutdown
+func WithSignals(signals ...os.Signal) Option {
+	return func(c *Coordinator) {
+		c.signals = signals
+	}
+}
+
+// WithLogger sets the logger for the coordinator
+func WithLogger(logger Logger) Option {
+	return func(c *Coordinator) {
+		c.logger = logger
+	}
+}
@@ -0,0 +1,203 @@
+// Package shutdown coordinates graceful shutdown of small programs that do not need
+// full service orchestration: a context cancelled on termination signals and
+// cleanup hooks run in reverse registration order within a deadline
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrTimeout is returned by Wait when the hooks did not finish within the shutdown timeout
+	ErrTimeout = errors.New("shutdown timeout exceeded")
+	// ErrForced is returned by Wait when a second signal interrupted the shutdown hooks
+	ErrForced = errors.New("shutdown forced by signal")
+)
+
+// Hook is a cleanup function run on shutdown. The context expires at the shutdown deadline
+type Hook func(ctx context.Context) error
+
+// Coordinator cancels a context on termination signals and runs the registered hooks
+type Coordinator struct {
+	timeout time.Duration
+	signals []os.Signal
+	logger  Logger
+
+	mu      sync.Mutex
+	hooks   []Hook
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running bool
+	done    chan struct{}
+	err     error
+}
+
+// New creates a coordinator listening for SIGTERM and SIGINT with a 30 second timeout
+func New(options ...Option) *Coordinator {
+	c := &Coordinator{
+		timeout: 30 * time.Second,
+		signals: []os.Signal{syscall.SIGTERM, syscall.SIGINT},
+		logger:  NoOpLogger{},
+		done:    make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// Listen starts listening for the shutdown signals and returns a context cancelled when one
+// is received or the parent context is done. Calling Listen again returns the same context
+func (c *Coordinator) Listen(ctx context.Context) context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ctx != nil {
+		return c.ctx
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, c.signals...)
+	c.logger.Debug("Shutdown signals registered", "signals", c.signals)
+
+	go func() {
+		defer signal.Stop(sigChan)
+
+		select {
+		case sig := <-sigChan:
+			c.logger.Info("Shutdown signal received", "signal", sig)
+		case <-c.ctx.Done():
+			c.logger.Info("Context cancelled, initiating shutdown")
+		}
+
+		c.cancel()
+		c.run(sigChan)
+	}()
+
+	return c.ctx
+}
+
+// Register adds a hook run on shutdown. Hooks run in reverse registration order,
+// hooks registered once shutdown has started are not run
+func (c *Coordinator) Register(hook Hook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		c.logger.Warn("Shutdown hook registered after shutdown started, ignoring")
+		return
+	}
+	c.hooks = append(c.hooks, hook)
+}
+
+// Trigger starts the shutdown as if a signal had been received. It has no effect before Listen
+func (c *Coordinator) Trigger() {
+	c.mu.Lock()
+	cancel := c.cancel
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Wait blocks until the shutdown hooks have run and returns their joined errors.
+// It blocks forever if Listen was never called
+func (c *Coordinator) Wait() error {
+	<-c.done
+	return c.err
+}
+
+// run executes the hooks in reverse order until the deadline or a second signal
+func (c *Coordinator) run(sigChan <-chan os.Signal) {
+	c.mu.Lock()
+	c.running = true
+	hooks := c.hooks
+	c.mu.Unlock()
+
+	c.logger.Info("Running shutdown hooks", "hooks", len(hooks), "timeout", c.timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var errs []error
+loop:
+	for i := len(hooks) - 1; i >= 0; i-- {
+		result := make(chan error, 1)
+		go func(hook Hook) {
+			result <- hook(ctx)
+		}(hooks[i])
+
+		select {
+		case err := <-result:
+			if err != nil {
+				c.logger.Error("Shutdown hook failed", "hook", i, "error", err)
+				errs = append(errs, fmt.Errorf("shutdown hook %d: %w", i, err))
+			}
+		case <-ctx.Done():
+			c.logger.Warn("Shutdown timeout reached, skipping remaining hooks", "timeout", c.timeout, "remaining", i+1)
+			errs = append(errs, ErrTimeout)
+			break loop
+		case sig := <-sigChan:
+			c.logger.Warn("Second shutdown signal received, skipping remaining hooks", "signal", sig, "remaining", i+1)
+			errs = append(errs, ErrForced)
+			break loop
+		}
+	}
+
+	c.err = errors.Join(errs...)
+	if c.err == nil {
+		c.logger.Info("Shutdown completed successfully")
+	}
+	close(c.done)
+}
+
+var (
+	defaultMu          sync.Mutex
+	defaultCoordinator *Coordinator
+)
+
+// Default returns the package level coordinator used by Listen, Register and Wait
+func Default() *Coordinator {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultCoordinator == nil {
+		defaultCoordinator = New()
+	}
+	return defaultCoordinator
+}
+
+// Listen applies the options to the default coordinator and starts listening for shutdown signals
+func Listen(ctx context.Context, options ...Option) context.Context {
+	c := Default()
+
+	c.mu.Lock()
+	if c.ctx == nil {
+		for _, opt := range options {
+			opt(c)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.Listen(ctx)
+}
+
+// Register adds a hook to the default coordinator
+func Register(hook Hook) {
+	Default().Register(hook)
+}
+
+// Wait blocks until the default coordinator has run its hooks
+func Wait() error {
+	return Default().Wait()
+}
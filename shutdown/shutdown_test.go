@@ -0,0 +1,123 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_HooksRunInReverseOrder(t *testing.T) {
+	c := New()
+	ctx := c.Listen(context.Background())
+
+	var mu sync.Mutex
+	var order []int
+	for i := range 3 {
+		c.Register(func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, i)
+			return nil
+		})
+	}
+
+	c.Trigger()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected context to be cancelled")
+	}
+
+	if err := c.Wait(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(order) != 3 || order[0] != 2 || order[1] != 1 || order[2] != 0 {
+		t.Errorf("Expected hooks in reverse order, got %v", order)
+	}
+}
+
+func Test_HookErrorsAreJoined(t *testing.T) {
+	c := New()
+	c.Listen(context.Background())
+
+	errClose := errors.New("close failed")
+	ran := false
+	c.Register(func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	c.Register(func(ctx context.Context) error {
+		return errClose
+	})
+
+	c.Trigger()
+	err := c.Wait()
+
+	if !errors.Is(err, errClose) {
+		t.Errorf("Expected hook error, got %v", err)
+	}
+	if !ran {
+		t.Error("Expected remaining hooks to run after a failure")
+	}
+}
+
+func Test_Timeout(t *testing.T) {
+	c := New(WithTimeout(50 * time.Millisecond))
+	c.Listen(context.Background())
+
+	ran := false
+	c.Register(func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	c.Register(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	c.Trigger()
+	start := time.Now()
+	err := c.Wait()
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+	if ran {
+		t.Error("Expected hooks after the deadline to be skipped")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Wait to return at the deadline, took %v", elapsed)
+	}
+}
+
+func Test_ParentContextCancellation(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+
+	ran := make(chan struct{})
+	Register(func(ctx context.Context) error {
+		close(ran)
+		return nil
+	})
+	ctx := Listen(parent, WithTimeout(time.Second))
+
+	if Listen(parent) != ctx {
+		t.Error("Expected Listen to return the same context")
+	}
+
+	cancel()
+	if err := Wait(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case <-ran:
+	default:
+		t.Error("Expected hook registered before Listen to run")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected context to be cancelled")
+	}
+}